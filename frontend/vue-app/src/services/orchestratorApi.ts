@@ -46,4 +46,8 @@ orchestratorApi.interceptors.response.use(
     }
 );
 
+// Cabecera If-Match con la versión leída del workflow. El servicio responde 412
+// si otra persona lo modificó entretanto.
+export const ifMatch = (version: number) => ({ 'If-Match': `"${version}"` });
+
 export default orchestratorApi;
\ No newline at end of file
@@ -1,11 +1,12 @@
 // src/stores/workflowStore.ts
 import { defineStore } from 'pinia';
-import orchestratorApi from '@/services/orchestratorApi';
-import type { Workflow, ExecutionLog } from '@/types';
+import orchestratorApi, { ifMatch } from '@/services/orchestratorApi';
+import type { Workflow, WorkflowFilters, ExecutionLog, ExecutionPage, ImportWorkflowsResponse } from '@/types';
 
 interface WorkflowState {
     workflows: Workflow[];
     executions: ExecutionLog[]; // Para el historial del workflow seleccionado
+    executionsCursor: string; // Cursor de la siguiente página del historial ('' si no hay más)
     isLoading: boolean;
     error: string | null;
 }
@@ -14,21 +15,27 @@ export const useWorkflowStore = defineStore('workflows', {
     state: (): WorkflowState => ({
         workflows: [],
         executions: [],
+        executionsCursor: '',
         isLoading: false,
         error: null,
     }),
     getters: {
         allWorkflows: (state) => state.workflows,
         getExecutions: (state) => state.executions,
+        hasMoreExecutions: (state) => state.executionsCursor !== '',
         isWorkflowsLoading: (state) => state.isLoading,
         getWorkflowError: (state) => state.error,
     },
     actions: {
-        async fetchWorkflows() {
+        // Los filtros vacíos no se envían; tag se repite en la URL (tag=a&tag=b:c).
+        async fetchWorkflows(filters: WorkflowFilters = {}) {
             this.isLoading = true;
             this.error = null;
             try {
-                const response = await orchestratorApi.get<Workflow[]>('/workflows');
+                const params = Object.fromEntries(
+                    Object.entries(filters).filter(([, value]) => value !== undefined && value !== '' && !(Array.isArray(value) && value.length === 0)),
+                );
+                const response = await orchestratorApi.get<Workflow[]>('/workflows', { params, paramsSerializer: { indexes: null } });
                 this.workflows = response.data || [];
             } catch (err: any) {
                 this.error = err.response?.data?.error || 'No se pudieron cargar los workflows';
@@ -38,13 +45,19 @@ export const useWorkflowStore = defineStore('workflows', {
         },
 
         // Acción para obtener el historial de un workflow específico
-        async fetchExecutionsForWorkflow(workflowId: string) {
+        // Con loadMore=true añade la siguiente página al historial ya cargado.
+        async fetchExecutionsForWorkflow(workflowId: string, loadMore = false) {
             this.isLoading = true;
             this.error = null;
-            this.executions = []; // Limpiamos el historial anterior antes de cargar el nuevo
+            if (!loadMore) {
+                this.executions = []; // Limpiamos el historial anterior antes de cargar el nuevo
+                this.executionsCursor = '';
+            }
             try {
-                const response = await orchestratorApi.get<ExecutionLog[]>(`/workflows/${workflowId}/executions`);
-                this.executions = response.data || [];
+                const params = loadMore && this.executionsCursor ? { cursor: this.executionsCursor } : {};
+                const response = await orchestratorApi.get<ExecutionPage>(`/workflows/${workflowId}/executions`, { params });
+                this.executions = [...this.executions, ...(response.data.items || [])];
+                this.executionsCursor = response.data.next_cursor || '';
             } catch (err: any) {
                 this.error = err.response?.data?.error || 'No se pudo cargar el historial de ejecuciones';
             } finally {
@@ -52,28 +65,79 @@ export const useWorkflowStore = defineStore('workflows', {
             }
         },
 
+        // Los listados no incluyen logs; se piden al abrir una ejecución.
+        async fetchExecution(executionId: string): Promise<ExecutionLog> {
+            const response = await orchestratorApi.get<ExecutionLog>(`/executions/${executionId}`);
+            return response.data;
+        },
+
         async createWorkflow(payload: any) {
             // ... tu código existente ...
             await orchestratorApi.post<Workflow>('/workflows', payload);
             await this.fetchWorkflows(); // Forzar recarga
         },
 
-        async updateWorkflow(id: string, payload: any) {
-            // ... tu código existente ...
-            const response = await orchestratorApi.put<Workflow>(`/workflows/${id}`, payload);
-            const index = this.workflows.findIndex(wf => wf.id === id);
-            if (index !== -1) { this.workflows[index] = response.data; }
+        // version es la que se leyó al abrir el formulario; si otra persona guardó
+        // después, el servicio responde 412 y recargamos la lista.
+        async updateWorkflow(id: string, payload: any, version: number) {
+            try {
+                const response = await orchestratorApi.put<Workflow>(`/workflows/${id}`, payload, { headers: ifMatch(version) });
+                const index = this.workflows.findIndex(wf => wf.id === id);
+                if (index !== -1) { this.workflows[index] = response.data; }
+            } catch (err: any) {
+                await this.handleStaleWorkflow(err);
+                throw err;
+            }
         },
 
         async deleteWorkflow(workflowId: string) {
-            // ... tu código existente ...
-            await orchestratorApi.delete(`/workflows/${workflowId}`);
-            this.workflows = this.workflows.filter(wf => wf.id !== workflowId);
+            const workflow = this.workflows.find(wf => wf.id === workflowId);
+            try {
+                await orchestratorApi.delete(`/workflows/${workflowId}`, { headers: ifMatch(workflow?.version ?? 0) });
+                this.workflows = this.workflows.filter(wf => wf.id !== workflowId);
+            } catch (err: any) {
+                this.error = err.response?.data?.error || 'No se pudo eliminar el workflow';
+                await this.handleStaleWorkflow(err);
+                throw err;
+            }
+        },
+
+        // Descarga el bundle YAML con los workflows indicados (todos si no se indica ninguno).
+        async exportWorkflows(ids: string[] = []): Promise<string> {
+            const response = await orchestratorApi.get<string>('/workflows/export', {
+                params: { id: ids },
+                paramsSerializer: { indexes: null },
+                responseType: 'text',
+            });
+            return response.data;
+        },
+
+        // Importa un bundle YAML/JSON. Con dryRun solo devuelve el plan; los errores de
+        // validación llegan como 400 con el mismo formato de respuesta.
+        async importWorkflows(bundle: string, onConflict: 'skip' | 'overwrite' | 'rename', dryRun: boolean): Promise<ImportWorkflowsResponse> {
+            try {
+                const response = await orchestratorApi.post<ImportWorkflowsResponse>('/workflows/import', bundle, {
+                    params: { on_conflict: onConflict, dry_run: dryRun },
+                    headers: { 'Content-Type': 'application/yaml' },
+                });
+                return response.data;
+            } catch (err: any) {
+                if (err.response?.data?.results) { return err.response.data; }
+                throw err;
+            }
+        },
+
+        async handleStaleWorkflow(err: any) {
+            if (err.response?.status !== 412) { return; }
+            await this.fetchWorkflows();
+            // fetchWorkflows limpia el error; lo fijamos después para que la vista lo muestre.
+            this.error = 'El workflow fue modificado por otra persona. Se han recargado los datos.';
         },
 
         clearWorkflows() {
             this.workflows = [];
             this.executions = [];
+            this.executionsCursor = '';
             this.isLoading = false;
             this.error = null;
         },
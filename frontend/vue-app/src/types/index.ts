@@ -22,13 +22,47 @@ export interface Workflow {
   user_id: string;
   name: string;
   description: string;
+  tags?: Record<string, string>;
+  folder?: string;
+  managed_by?: string; // Origen de la sincronización declarativa; si existe, es de solo lectura
   trigger: any;
   actions: any[];
+  on_failure?: any[];
+  fail_fast?: boolean;
+  retention?: { max_age_days?: number; max_count?: number };
   is_enabled: boolean;
+  version: number; // Se envía como If-Match al editar o eliminar
   created_at: string;
   updated_at: string;
 }
 
+// Filtros de GET /workflows; tag admite "clave" o "clave:valor".
+export interface WorkflowFilters {
+  q?: string;
+  tag?: string[];
+  folder?: string;
+  trigger_type?: 'schedule' | 'webhook';
+  enabled?: boolean;
+  last_run_status?: string;
+}
+
+// Respuesta de POST /workflows/import (también en dry-run).
+export interface ImportWorkflowResult {
+  name: string;
+  action?: 'create' | 'overwrite' | 'rename' | 'skip' | 'unchanged';
+  workflow_id?: string;
+  imported_as?: string;
+  missing_secrets?: string[];
+  error?: string;
+}
+
+export interface ImportWorkflowsResponse {
+  dry_run: boolean;
+  on_conflict: 'skip' | 'overwrite' | 'rename';
+  results: ImportWorkflowResult[];
+  error?: string;
+}
+
 export interface CreateWorkflowRequest {
   name: string
   description: string
@@ -40,8 +74,16 @@ export interface ExecutionLog {
   id: string;
   workflow_id: string;
   user_id: string;
-  status: 'running' | 'completed' | 'failed';
+  status: 'running' | 'suspended' | 'completed' | 'failed';
   triggered_at: string;
   completed_at?: string | null;
-  logs: any;
+  trigger_source?: string;
+  parent_execution_id?: string;
+  logs?: any; // Solo en el detalle (GET /executions/:id)
+  steps?: any[];
+}
+
+export interface ExecutionPage {
+  items: ExecutionLog[];
+  next_cursor: string;
 }
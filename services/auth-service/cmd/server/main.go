@@ -4,6 +4,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -16,6 +17,11 @@ import (
 
 func main() {
 	config.LoadConfig()
+
+	// `auth-service migrate ...` gestiona el esquema sin levantar el servidor.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrateCommand(os.Args[2:]))
+	}
 	dbPool := database.ConnectDB()
 	defer dbPool.Close()
 	database.RunMigrations(dbPool)
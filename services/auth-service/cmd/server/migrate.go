@@ -0,0 +1,86 @@
+// services/auth-service/cmd/server/migrate.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/guildmember145/auth-service/pkg/database"
+)
+
+const migrateUsage = `Usage: auth-service migrate <command>
+
+Commands:
+  status        list migrations and whether they are applied
+  up            apply all pending migrations
+  down [n]      revert the last n applied migrations (default 1)
+  to <version>  migrate up or down to the given version (0 reverts everything)
+`
+
+// runMigrateCommand implementa el subcomando `migrate`; devuelve el código de salida.
+func runMigrateCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	pool := database.ConnectDB()
+	defer pool.Close()
+	migrator, err := database.NewMigrator(pool)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	var count int
+	switch command := args[0]; {
+	case command == "status" && len(args) == 1:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.UTC().Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		w.Flush()
+		return 0
+	case command == "up" && len(args) == 1:
+		count, err = migrator.Up(ctx)
+	case command == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintf(os.Stderr, "migrate: invalid number of steps '%s'\n", args[1])
+				return 2
+			}
+		}
+		count, err = migrator.Down(ctx, steps)
+	case command == "to" && len(args) == 2:
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil || version < 0 {
+			fmt.Fprintf(os.Stderr, "migrate: invalid version '%s'\n", args[1])
+			return 2
+		}
+		count, err = migrator.To(ctx, version)
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate: %v (%d migration(s) completed before the error)\n", err, count)
+		return 1
+	}
+	fmt.Printf("%d migration(s) completed.\n", count)
+	return 0
+}
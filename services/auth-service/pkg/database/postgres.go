@@ -23,21 +23,16 @@ func ConnectDB() *pgxpool.Pool {
     return pool
 }
 
+// RunMigrations aplica al arrancar las migraciones pendientes (ver migrate.go).
 func RunMigrations(pool *pgxpool.Pool) {
     log.Println("Running database migrations for auth-service...")
-    createTableSQL := `
-    CREATE TABLE IF NOT EXISTS users (
-        id UUID PRIMARY KEY,
-        username VARCHAR(50) UNIQUE NOT NULL,
-        email VARCHAR(255) UNIQUE NOT NULL,
-        password_hash VARCHAR(255) NOT NULL,
-        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-    );`
-    _, err := pool.Exec(context.Background(), createTableSQL)
+    migrator, err := NewMigrator(pool)
     if err != nil {
-        log.Fatalf("Failed to create 'users' table: %v\n", err)
-        os.Exit(1)
+        log.Fatalf("Failed to load migrations: %v\n", err)
+    }
+    applied, err := migrator.Up(context.Background())
+    if err != nil {
+        log.Fatalf("Database migration failed: %v\n", err)
     }
-    log.Println("Auth-service database migrations completed successfully.")
-}
\ No newline at end of file
+    log.Printf("Auth-service database migrations completed successfully (%d applied, schema version %d).", applied, migrator.Latest())
+}
@@ -8,56 +8,132 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/guildmember145/task-orchestrator-service/internal/engine"
 	"github.com/guildmember145/task-orchestrator-service/internal/handlers"
 	"github.com/guildmember145/task-orchestrator-service/internal/middleware"
+	"github.com/guildmember145/task-orchestrator-service/internal/retention"
 	"github.com/guildmember145/task-orchestrator-service/internal/scheduler"
+	"github.com/guildmember145/task-orchestrator-service/internal/secret"
 	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
 	"github.com/guildmember145/task-orchestrator-service/pkg/config"
 	"github.com/guildmember145/task-orchestrator-service/pkg/database"
-
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
+	// `task-orchestrator-service sync ...` es un cliente de la API: no necesita la configuración del servidor.
+	if len(os.Args) > 1 && os.Args[1] == "sync" {
+		os.Exit(runSyncCommand(os.Args[2:]))
+	}
+
+	// `task-orchestrator-service script-runner` es el proceso hijo de la acción script (ver engine.RunScriptProcess).
+	if len(os.Args) > 1 && os.Args[1] == engine.ScriptRunnerCommand {
+		os.Exit(engine.RunScriptProcess(os.Stdin, os.Stdout))
+	}
+
 	config.LoadConfig()
-	dbPool := database.ConnectDB()
-	defer dbPool.Close()
-	database.RunMigrations(dbPool)
-
-	// --- INICIO: PUNTO DE CHEQUEO 1 ---
-	log.Println("--- POOL CHECK 1 (después de migraciones) ---")
-	var one int
-	errCheck1 := dbPool.QueryRow(context.Background(), "SELECT 1").Scan(&one)
-	if errCheck1 != nil {
-		log.Fatalf("Pool check 1 FALLÓ: %v", errCheck1)
+
+	// `task-orchestrator-service migrate ...` gestiona el esquema sin levantar el servidor.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrateCommand(os.Args[2:]))
 	}
-	log.Println("Pool check 1 PASÓ con éxito.")
-	// --- FIN: PUNTO DE CHEQUEO 1 ---
-
-	workflowStore := workflow.NewPostgresWorkflowStore(dbPool)
-	appScheduler := scheduler.New(workflowStore, engine.ExecuteWorkflow)
-	workflowHandler := handlers.NewWorkflowHandler(workflowStore, appScheduler)
-
-	// --- INICIO: PUNTO DE CHEQUEO 2 ---
-	log.Println("--- POOL CHECK 2 (antes de iniciar scheduler) ---")
-	errCheck2 := dbPool.QueryRow(context.Background(), "SELECT 1").Scan(&one)
-	if errCheck2 != nil {
-		log.Fatalf("Pool check 2 FALLÓ: %v", errCheck2)
+
+	secretCipher, err := secret.NewCipher(config.AppConfig.SecretsKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize secret cipher: %v", err)
+	}
+	if secretCipher == nil {
+		log.Println("WARNING: SECRETS_KEY is not set; secrets cannot be stored or used by actions.")
+	}
+
+	var (
+		dbPool            *pgxpool.Pool
+		workflowStore     workflow.Store
+		secretStore       secret.Store
+		executionNotifier workflow.ExecutionNotifier
+	)
+	notifierCtx, stopNotifier := context.WithCancel(context.Background())
+
+	switch config.AppConfig.StoreBackend {
+	case "memory":
+		log.Println("WARNING: STORE_BACKEND=memory; workflows, executions and secrets are lost on restart.")
+		memoryStore := workflow.NewInMemoryWorkflowStore()
+		workflowStore, executionNotifier = memoryStore, memoryStore
+		secretStore = secret.NewInMemorySecretStore(secretCipher)
+	case "postgres":
+		dbPool = database.ConnectDB()
+		defer dbPool.Close()
+		database.RunMigrations(dbPool)
+
+		// --- INICIO: PUNTO DE CHEQUEO 1 ---
+		log.Println("--- POOL CHECK 1 (después de migraciones) ---")
+		var one int
+		errCheck1 := dbPool.QueryRow(context.Background(), "SELECT 1").Scan(&one)
+		if errCheck1 != nil {
+			log.Fatalf("Pool check 1 FALLÓ: %v", errCheck1)
+		}
+		log.Println("Pool check 1 PASÓ con éxito.")
+		// --- FIN: PUNTO DE CHEQUEO 1 ---
+
+		workflowStore = workflow.NewPostgresWorkflowStore(dbPool)
+		secretStore = secret.NewPostgresSecretStore(dbPool, secretCipher)
+		postgresNotifier := workflow.NewPostgresExecutionNotifier(dbPool)
+		go postgresNotifier.Run(notifierCtx)
+		executionNotifier = postgresNotifier
+	case "sqlite":
+		sqliteDB := database.ConnectSQLite(config.AppConfig.DatabaseURL)
+		defer sqliteDB.Close()
+		database.RunSQLiteMigrations(sqliteDB)
+
+		sqliteStore := workflow.NewSQLiteWorkflowStore(sqliteDB)
+		workflowStore, executionNotifier = sqliteStore, sqliteStore
+		secretStore = secret.NewSQLiteSecretStore(sqliteDB, secretCipher)
+	default:
+		log.Fatalf("Unknown STORE_BACKEND '%s' (expected 'postgres', 'sqlite' or 'memory')", config.AppConfig.StoreBackend)
+	}
+
+	engine.SetSecretStore(secretStore)
+	secretHandler := handlers.NewSecretHandler(secretStore)
+
+	appScheduler := scheduler.New(workflowStore, engine.ExecuteWorkflow, engine.ResumeExecution, config.AppConfig.ResumePollInterval)
+	workflowHandler := handlers.NewWorkflowHandler(workflowStore, appScheduler, secretStore)
+	approvalHandler := handlers.NewApprovalHandler(workflowStore)
+	eventHandler := handlers.NewEventHandler(workflowStore)
+	executionHandler := handlers.NewExecutionHandler(workflowStore, executionNotifier)
+
+	if dbPool != nil {
+		// --- INICIO: PUNTO DE CHEQUEO 2 ---
+		log.Println("--- POOL CHECK 2 (antes de iniciar scheduler) ---")
+		var one int
+		errCheck2 := dbPool.QueryRow(context.Background(), "SELECT 1").Scan(&one)
+		if errCheck2 != nil {
+			log.Fatalf("Pool check 2 FALLÓ: %v", errCheck2)
+		}
+		log.Println("Pool check 2 PASÓ con éxito.")
+		// --- FIN: PUNTO DE CHEQUEO 2 ---
 	}
-	log.Println("Pool check 2 PASÓ con éxito.")
-    // --- FIN: PUNTO DE CHEQUEO 2 ---
 
 	go appScheduler.Start()
 
+	janitor := retention.New(workflowStore, workflow.RetentionLimits{
+		MaxAgeDays: config.AppConfig.RetentionMaxAgeDays,
+		MaxCount:   config.AppConfig.RetentionMaxCount,
+	}, config.AppConfig.RetentionInterval, config.AppConfig.RetentionBatchSize, config.AppConfig.RetentionArchiveDir,
+		time.Duration(config.AppConfig.TrashRetentionDays)*24*time.Hour)
+	go janitor.Start()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-quit
 		log.Println("Shutdown signal received, stopping scheduler...")
 		appScheduler.Stop()
+		janitor.Stop()
+		stopNotifier()
 	}()
 
 	router := gin.Default()
@@ -65,18 +141,55 @@ func main() {
     corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"http://localhost:3003"}
 	corsConfig.AllowCredentials = true
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "If-Match"}
+	// El frontend necesita leer el ETag para enviarlo después en If-Match.
+	corsConfig.ExposeHeaders = []string{"ETag"}
 	router.Use(cors.New(corsConfig))
 
+	// Enlaces firmados de aprobación: no requieren JWT, la firma hace de credencial.
+	approvalRoutes := router.Group("/api/tasks/v1/approvals")
+	{
+		approvalRoutes.GET("/:token", approvalHandler.ApprovalLinkPageHandler)
+		approvalRoutes.POST("/:token", approvalHandler.ApprovalLinkDecisionHandler)
+	}
+
+	// Eventos externos: el token firmado de cada workflow (X-Event-Token) hace de credencial.
+	router.POST("/api/tasks/v1/events/:name", eventHandler.DeliverEventHandler)
+
 	taskApiRoutes := router.Group("/api/tasks/v1")
 	taskApiRoutes.Use(middleware.AuthMiddleware())
 	{
 		taskApiRoutes.POST("/workflows", workflowHandler.CreateWorkflowHandler)
 		taskApiRoutes.GET("/workflows", workflowHandler.GetWorkflowsHandler)
+		taskApiRoutes.GET("/workflows/trash", workflowHandler.ListTrashHandler)
+		taskApiRoutes.GET("/workflows/export", workflowHandler.ExportWorkflowsHandler)
+		taskApiRoutes.POST("/workflows/import", workflowHandler.ImportWorkflowsHandler)
+		taskApiRoutes.POST("/workflows/sync", workflowHandler.SyncWorkflowsHandler)
 		taskApiRoutes.GET("/workflows/:workflow_id", workflowHandler.GetWorkflowByIDHandler)
 		taskApiRoutes.PUT("/workflows/:workflow_id", workflowHandler.UpdateWorkflowHandler)
+		taskApiRoutes.PATCH("/workflows/:workflow_id", workflowHandler.PatchWorkflowHandler)
+		taskApiRoutes.POST("/workflows/:workflow_id/enable", workflowHandler.EnableWorkflowHandler)
+		taskApiRoutes.POST("/workflows/:workflow_id/disable", workflowHandler.DisableWorkflowHandler)
+		taskApiRoutes.POST("/workflows/:workflow_id/duplicate", workflowHandler.DuplicateWorkflowHandler)
+		taskApiRoutes.POST("/workflows/:workflow_id/restore", workflowHandler.RestoreWorkflowHandler)
 		taskApiRoutes.DELETE("/workflows/:workflow_id", workflowHandler.DeleteWorkflowHandler)
 		taskApiRoutes.GET("/workflows/:workflow_id/executions", workflowHandler.GetWorkflowExecutionsHandler)
+		taskApiRoutes.GET("/workflows/:workflow_id/events-token", workflowHandler.EventsTokenHandler)
+		taskApiRoutes.GET("/workflows/:workflow_id/versions", workflowHandler.ListWorkflowVersionsHandler)
+		taskApiRoutes.GET("/workflows/:workflow_id/versions/:version", workflowHandler.GetWorkflowVersionHandler)
+		taskApiRoutes.GET("/workflows/:workflow_id/versions/:version/diff", workflowHandler.DiffWorkflowVersionsHandler)
+		taskApiRoutes.POST("/workflows/:workflow_id/versions/:version/rollback", workflowHandler.RollbackWorkflowHandler)
+
+		taskApiRoutes.GET("/executions", executionHandler.ListExecutionsHandler)
+		taskApiRoutes.GET("/executions/:execution_id", executionHandler.GetExecutionHandler)
+		taskApiRoutes.POST("/executions/:execution_id/rerun", executionHandler.RerunExecutionHandler)
+		taskApiRoutes.POST("/executions/:execution_id/resume", executionHandler.ResumeExecutionHandler)
+		taskApiRoutes.GET("/executions/:execution_id/logs/stream", executionHandler.StreamExecutionLogsHandler)
+		taskApiRoutes.POST("/executions/:execution_id/approval", approvalHandler.ExecutionApprovalHandler)
+
+		taskApiRoutes.POST("/secrets", secretHandler.SaveSecretHandler)
+		taskApiRoutes.GET("/secrets", secretHandler.GetSecretsHandler)
+		taskApiRoutes.DELETE("/secrets/:name", secretHandler.DeleteSecretHandler)
 	}
 
 	addr := fmt.Sprintf(":%s", config.AppConfig.Port)
@@ -0,0 +1,237 @@
+// services/task-orchestrator-service/cmd/server/sync.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/bundle"
+	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
+)
+
+const syncUsage = `Usage: task-orchestrator-service sync <plan|apply> [flags] <dir>
+
+Reconciles the workflows of a running service with the bundles (*.yaml, *.yml,
+*.json) found under <dir>. Workflows created this way are read-only in the API.
+
+Commands:
+  plan   show what would change without applying it
+  apply  apply the changes
+
+Flags:
+`
+
+// runSyncCommand implementa el subcomando `sync`; devuelve el código de salida.
+// Lee todos los bundles del directorio, los une en uno y lo envía a POST
+// /workflows/sync del servicio indicado, que es quien calcula y aplica el plan.
+func runSyncCommand(args []string) int {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, syncUsage)
+		flags.PrintDefaults()
+	}
+	baseURL := flags.String("url", envOrDefault("ORCHESTRATOR_URL", "http://localhost:9090"), "service base URL ($ORCHESTRATOR_URL)")
+	token := flags.String("token", os.Getenv("ORCHESTRATOR_TOKEN"), "bearer token ($ORCHESTRATOR_TOKEN)")
+	source := flags.String("source", "", "identifier of this source (default \"dir:\" + the directory name)")
+	prune := flags.Bool("prune", false, "move to the trash managed workflows that are no longer in the directory")
+	adopt := flags.Bool("adopt", false, "take over existing unmanaged workflows with the same name")
+
+	if len(args) == 0 || (args[0] != "plan" && args[0] != "apply") {
+		flags.Usage()
+		return 2
+	}
+	command := args[0]
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	dir := flags.Arg(0)
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "sync: a token is required (-token or $ORCHESTRATOR_TOKEN)")
+		return 2
+	}
+	if *source == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "sync: %v\n", err)
+			return 1
+		}
+		*source = "dir:" + filepath.Base(abs)
+	}
+
+	b, err := readBundleDir(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sync: %v\n", err)
+		return 1
+	}
+
+	query := url.Values{}
+	query.Set("source", *source)
+	query.Set("prune", strconv.FormatBool(*prune))
+	query.Set("adopt", strconv.FormatBool(*adopt))
+	query.Set("dry_run", strconv.FormatBool(command == "plan"))
+	resp, status, err := postSync(strings.TrimRight(*baseURL, "/")+"/api/tasks/v1/workflows/sync?"+query.Encode(), *token, b)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sync: %v\n", err)
+		return 1
+	}
+
+	if len(resp.Results) > 0 {
+		printSyncPlan(os.Stdout, resp)
+	}
+	if status != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "sync: %s (HTTP %d)\n", resp.Error, status)
+		return 1
+	}
+	if command == "plan" {
+		fmt.Println("Plan only; run `sync apply` to apply it.")
+	}
+	return 0
+}
+
+// readBundleDir lee recursivamente los bundles de dir y los une en uno. Los
+// errores indican el fichero que los produce.
+func readBundleDir(dir string) (*bundle.Bundle, error) {
+	merged := bundle.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		b, err := bundle.Decode(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		merged.Workflows = append(merged.Workflows, b.Workflows...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(merged.Workflows) == 0 {
+		return nil, fmt.Errorf("no workflows found in '%s'", dir)
+	}
+	return merged, nil
+}
+
+// postSync envía el bundle al servicio. Devuelve la respuesta también cuando el
+// código no es 200, porque el plan explica qué workflows fallaron.
+func postSync(endpoint, token string, b *bundle.Bundle) (*transport.SyncWorkflowsResponse, int, error) {
+	body, err := json.Marshal(b)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	var resp transport.SyncWorkflowsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, res.StatusCode, fmt.Errorf("unexpected response (HTTP %d): %s", res.StatusCode, bytes.TrimSpace(data))
+	}
+	if resp.Error == "" && res.StatusCode != http.StatusOK {
+		// Errores que no son un plan, p. ej. {"error": "..."} del middleware de autenticación.
+		var plain struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &plain)
+		resp.Error = plain.Error
+	}
+	return &resp, res.StatusCode, nil
+}
+
+// printSyncPlan escribe el plan en el formato de un diff: + crear, ~ actualizar,
+// - eliminar.
+func printSyncPlan(w io.Writer, resp *transport.SyncWorkflowsResponse) {
+	counts := map[string]int{}
+	for _, r := range resp.Results {
+		switch {
+		case r.Error != "":
+			fmt.Fprintf(w, "! %s: %s\n", r.Name, r.Error)
+		case r.Action == "create":
+			fmt.Fprintf(w, "+ %s\n", r.Name)
+		case r.Action == "update" || r.Action == "adopt":
+			label := ""
+			if r.Action == "adopt" {
+				label = " (adopt)"
+			}
+			fmt.Fprintf(w, "~ %s%s\n", r.Name, label)
+			for _, change := range r.Changes {
+				fmt.Fprintf(w, "    %s %s: %s -> %s\n", change.Op, change.Path, syncValue(change.From), syncValue(change.To))
+			}
+		case r.Action == "prune":
+			fmt.Fprintf(w, "- %s\n", r.Name)
+		case r.Action == "orphan":
+			fmt.Fprintf(w, "? %s (no longer in source; use -prune to remove it)\n", r.Name)
+		}
+		for _, name := range r.MissingSecrets {
+			fmt.Fprintf(w, "    warning: secret '%s' does not exist\n", name)
+		}
+		if r.Action != "" {
+			counts[r.Action]++
+		}
+	}
+	fmt.Fprintf(w, "%d to create, %d to update, %d to adopt, %d to prune, %d unchanged, %d orphaned.\n",
+		counts["create"], counts["update"], counts["adopt"], counts["prune"], counts["unchanged"], counts["orphan"])
+}
+
+// syncValue muestra un valor del diff en una línea.
+func syncValue(v interface{}) string {
+	if v == nil {
+		return "(none)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// envOrDefault devuelve la variable de entorno key o fallback si no está definida.
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,131 @@
+// services/task-orchestrator-service/internal/bundle/bundle.go
+
+// Package bundle define el formato portable con el que se exportan e importan
+// workflows entre entornos (p. ej. de staging a producción) y se guardan en git.
+// Un bundle no lleva ids, propietarios ni valores de secretos: solo la definición
+// editable de cada workflow y los nombres de los secretos que referencia.
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
+	"gopkg.in/yaml.v3"
+)
+
+// APIVersion es la versión del formato que genera este servicio. Si el formato
+// cambia de forma incompatible se publicará una nueva y Decode aceptará ambas.
+const (
+	APIVersion = "task-orchestrator/v1"
+	Kind       = "WorkflowBundle"
+)
+
+// Formatos de serialización admitidos.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported bundle api_version")
+	ErrInvalidBundle      = errors.New("invalid bundle")
+)
+
+// Bundle es el documento completo. No incluye la fecha de exportación para que
+// exportar dos veces lo mismo produzca el mismo fichero (y ningún diff en git).
+type Bundle struct {
+	APIVersion string     `json:"api_version"`
+	Kind       string     `json:"kind"`
+	Workflows  []Workflow `json:"workflows"`
+}
+
+// Workflow es la definición de un workflow dentro del bundle. Secrets lista los
+// secretos que referencian sus acciones; es informativo (se recalcula al importar)
+// y sirve para saber qué hay que crear en el entorno de destino.
+type Workflow struct {
+	transport.CreateWorkflowRequest
+	Secrets []string `json:"secrets,omitempty"`
+}
+
+// New crea un bundle vacío con la versión y el tipo actuales.
+func New() *Bundle {
+	return &Bundle{APIVersion: APIVersion, Kind: Kind, Workflows: []Workflow{}}
+}
+
+// Encode serializa el bundle en JSON indentado o en YAML.
+func Encode(b *Bundle, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	switch format {
+	case FormatJSON:
+		return append(data, '\n'), nil
+	case FormatYAML:
+		return jsonToYAML(data)
+	}
+	return nil, fmt.Errorf("unknown bundle format '%s'", format)
+}
+
+// Decode interpreta un bundle en JSON o YAML (JSON es YAML válido). Los campos
+// desconocidos son un error, para que una errata no se ignore en silencio.
+func Decode(data []byte) (*Bundle, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%w: expected an object with api_version, kind and workflows", ErrInvalidBundle)
+	}
+	normalized, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+
+	var b Bundle
+	dec := json.NewDecoder(bytes.NewReader(normalized))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	switch {
+	case b.APIVersion == "":
+		return nil, fmt.Errorf("%w: api_version is required", ErrInvalidBundle)
+	case b.APIVersion != APIVersion:
+		return nil, fmt.Errorf("%w '%s' (supported: %s)", ErrUnsupportedVersion, b.APIVersion, APIVersion)
+	case b.Kind != Kind:
+		return nil, fmt.Errorf("%w: kind must be '%s'", ErrInvalidBundle, Kind)
+	}
+	return &b, nil
+}
+
+// jsonToYAML convierte JSON en YAML de bloque conservando el orden de los campos.
+func jsonToYAML(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to convert bundle to yaml: %w", err)
+	}
+	clearStyle(&node)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, fmt.Errorf("failed to convert bundle to yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to convert bundle to yaml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// clearStyle quita el estilo de flujo heredado del JSON para que el encoder use
+// bloques y solo entrecomille los valores que lo necesitan.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
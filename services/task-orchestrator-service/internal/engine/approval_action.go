@@ -0,0 +1,357 @@
+// services/task-orchestrator-service/internal/engine/approval_action.go
+package engine
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/config"
+)
+
+const waitKindApproval = "approval"
+
+const (
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+var (
+	ErrInvalidApprovalToken = errors.New("invalid or tampered approval token")
+	ErrApprovalNotPending   = errors.New("approval is no longer pending")
+	ErrApprovalLinksOff     = errors.New("signed approval links are not configured (APPROVAL_SIGNING_KEY)")
+)
+
+// ApprovalDecision es la respuesta de un aprobador, por enlace firmado o por API.
+type ApprovalDecision struct {
+	Decision  string // ApprovalApproved o ApprovalRejected
+	DecidedBy string
+	Comment   string
+	Via       string // "link", "api" o "timeout"
+}
+
+// ApprovalClaims es el contenido firmado de un enlace de aprobación.
+type ApprovalClaims struct {
+	ExecutionID uuid.UUID `json:"e"`
+	Step        int       `json:"s"`
+	Nonce       string    `json:"n"`
+	Approver    string    `json:"a"`
+}
+
+// executeApproval suspende la ejecución hasta que alguien apruebe o rechace y
+// notifica a los aprobadores con enlaces firmados de un solo uso.
+// Config: {"approvers": ["ops@example.com"], "message": "...", "notify": {"email": true, "webhook": "https://..."},
+// "timeout": "24h", "on_timeout": "reject"|"approve"|"fail"}
+func (r *runner) executeApproval(action workflow.ActionDefinition) (interface{}, error) {
+	approvers, err := r.addressList(action, "approvers", r.templateData())
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': %w", action.Name, err)
+	}
+
+	onTimeout, _ := action.Config["on_timeout"].(string)
+	switch onTimeout {
+	case "":
+		onTimeout = "reject"
+	case "reject", "approve", "fail":
+	default:
+		return nil, fmt.Errorf("action '%s': 'on_timeout' must be 'reject', 'approve' or 'fail'", action.Name)
+	}
+
+	var until time.Time
+	if raw, _ := action.Config["timeout"].(string); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("action '%s': invalid timeout %q", action.Name, raw)
+		}
+		until = time.Now().UTC().Add(timeout)
+	}
+
+	message, _ := action.Config["message"].(string)
+	if message, err = renderText("message", message, r.templateData()); err != nil {
+		return nil, fmt.Errorf("action '%s': invalid 'message' template: %w", action.Name, err)
+	}
+
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return nil, fmt.Errorf("action '%s': failed to generate approval nonce: %w", action.Name, err)
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+
+	if err := r.notifyApprovers(action, r.next, nonce, approvers, message, until); err != nil {
+		return nil, fmt.Errorf("action '%s': failed to notify approvers: %w", action.Name, err)
+	}
+
+	// El nonce es también la clave de espera: la reclamación de una decisión solo
+	// prospera si la ejecución sigue esperando esta aprobación.
+	return nil, &suspension{
+		kind:  waitKindApproval,
+		until: until,
+		key:   nonce,
+		data: map[string]interface{}{
+			"nonce":      nonce,
+			"approvers":  approvers,
+			"on_timeout": onTimeout,
+			"message":    message,
+		},
+	}
+}
+
+// approvalLink es el par de enlaces de un aprobador.
+type approvalLink struct {
+	Approver   string `json:"approver"`
+	ApproveURL string `json:"approve_url"`
+	RejectURL  string `json:"reject_url"`
+}
+
+// notifyApprovers envía la solicitud por email y/o webhook según la configuración.
+func (r *runner) notifyApprovers(action workflow.ActionDefinition, step int, nonce string, approvers []string, message string, until time.Time) error {
+	notify, _ := action.Config["notify"].(map[string]interface{})
+	webhookURL, _ := notify["webhook"].(string)
+	sendEmail, _ := notify["email"].(bool)
+	if notify == nil {
+		sendEmail = len(approvers) > 0
+	}
+
+	apiURL := fmt.Sprintf("%s/api/tasks/v1/executions/%s/approval", strings.TrimRight(config.AppConfig.PublicBaseURL, "/"), r.execution.ID)
+	linkOwners := approvers
+	if len(linkOwners) == 0 {
+		linkOwners = []string{"link"}
+	}
+	var links []approvalLink
+	if config.AppConfig.ApprovalSigningKey != "" {
+		for _, approver := range linkOwners {
+			token, err := SignApprovalToken(ApprovalClaims{ExecutionID: r.execution.ID, Step: step, Nonce: nonce, Approver: approver})
+			if err != nil {
+				return err
+			}
+			base := fmt.Sprintf("%s/api/tasks/v1/approvals/%s", strings.TrimRight(config.AppConfig.PublicBaseURL, "/"), token)
+			links = append(links, approvalLink{Approver: approver, ApproveURL: base + "?decision=approve", RejectURL: base + "?decision=reject"})
+		}
+	} else {
+		r.addLog("APPROVAL_SIGNING_KEY is not set; approvers must decide through the API", "WARNING")
+	}
+
+	if webhookURL != "" {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"execution_id":  r.execution.ID,
+			"workflow_id":   r.wf.ID,
+			"workflow_name": r.wf.Name,
+			"step":          action.Name,
+			"message":       message,
+			"links":         links,
+			"api_url":       apiURL,
+			"expires_at":    nullableTime(until),
+		})
+		req, err := http.NewRequestWithContext(r.ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("approval webhook returned non-2xx status: %s", resp.Status)
+		}
+		r.addLog(fmt.Sprintf("Approval request for '%s' sent to webhook", action.Name), "ACTION_OUTPUT")
+	}
+
+	if sendEmail {
+		if config.AppConfig.SMTPFrom == "" {
+			return errors.New("SMTP_FROM is not configured")
+		}
+		for i, approver := range approvers {
+			var body strings.Builder
+			fmt.Fprintf(&body, "Workflow '%s' is waiting for your approval at step '%s'.\n\n", r.wf.Name, action.Name)
+			if message != "" {
+				fmt.Fprintf(&body, "%s\n\n", message)
+			}
+			if i < len(links) {
+				fmt.Fprintf(&body, "Approve: %s\nReject: %s\n\n", links[i].ApproveURL, links[i].RejectURL)
+			}
+			fmt.Fprintf(&body, "API: POST %s\n", apiURL)
+			if !until.IsZero() {
+				fmt.Fprintf(&body, "\nThis request expires at %s.\n", until.Format(time.RFC1123))
+			}
+			msg := &emailMessage{
+				From:    config.AppConfig.SMTPFrom,
+				To:      []string{approver},
+				Subject: fmt.Sprintf("Approval required: %s / %s", r.wf.Name, action.Name),
+				Text:    body.String(),
+			}
+			if _, err := getMailer().Send(r.ctx, msg); err != nil {
+				return err
+			}
+		}
+		r.addLog(fmt.Sprintf("Approval request for '%s' emailed to %d approver(s)", action.Name, len(approvers)), "ACTION_OUTPUT")
+	}
+	return nil
+}
+
+// completeApproval resuelve el paso de aprobación al reanudar: con la decisión
+// registrada o, si venció el plazo, según on_timeout.
+func (r *runner) completeApproval(action workflow.ActionDefinition) (interface{}, error) {
+	data := r.wait.Data
+	decision, _ := data["decision"].(string)
+	if decision == "" {
+		onTimeout, _ := data["on_timeout"].(string)
+		switch onTimeout {
+		case "approve":
+			decision = ApprovalApproved
+		case "fail":
+			return map[string]interface{}{"decision": "timed_out", "decided_at": time.Now().UTC()},
+				&haltError{fmt.Errorf("approval for '%s' timed out", action.Name)}
+		default:
+			decision = ApprovalRejected
+		}
+		data["decision"] = decision
+		data["decided_by"] = "timeout"
+		data["via"] = "timeout"
+		data["decided_at"] = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	output := map[string]interface{}{
+		"decision":   decision,
+		"decided_by": data["decided_by"],
+		"decided_at": data["decided_at"],
+		"comment":    data["comment"],
+		"via":        data["via"],
+	}
+	r.addLog(fmt.Sprintf("Approval '%s' %s by %v", action.Name, decision, data["decided_by"]), "ACTION_OUTPUT")
+	if decision == ApprovalRejected {
+		// Una aprobación es una compuerta: si se rechaza, no se ejecuta nada después.
+		return output, &haltError{fmt.Errorf("approval for '%s' was rejected", action.Name)}
+	}
+	return output, nil
+}
+
+// DecideApproval registra la decisión sobre una aprobación pendiente y reanuda
+// la ejecución en segundo plano. step y nonce vienen del enlace firmado; en la API
+// autenticada se pasan -1 y "" para aceptar la aprobación pendiente que haya.
+// userID vacío desactiva la comprobación de propietario (enlaces firmados).
+// La ejecución solo se reclama si sigue esperando ese nonce, así que un enlace
+// usado o caducado no la modifica.
+func DecideApproval(ctx context.Context, store workflow.Store, executionID uuid.UUID, userID string, step int, nonce string, decision ApprovalDecision) error {
+	if nonce == "" {
+		current, err := store.GetExecutionByID(ctx, userID, executionID)
+		if err != nil {
+			if errors.Is(err, workflow.ErrExecutionNotFound) {
+				return ErrApprovalNotPending
+			}
+			return err
+		}
+		if nonce = pendingApprovalNonce(current, step); nonce == "" {
+			return ErrApprovalNotPending
+		}
+	}
+
+	execution, err := store.ClaimSuspendedExecution(ctx, executionID, userID, nonce)
+	if err != nil {
+		if errors.Is(err, workflow.ErrExecutionNotSuspended) {
+			return ErrApprovalNotPending
+		}
+		return err
+	}
+
+	r, err := restoreRunner(execution)
+	if err != nil || r.wait == nil || r.wait.Kind != waitKindApproval || (step >= 0 && r.wait.Step != step) {
+		// El nonce coincide pero el estado no (solo si está dañado): devolvemos la
+		// ejecución a su estado anterior, aunque la petición se haya cancelado, para
+		// que no quede reclamada.
+		execution.Status = "suspended"
+		if updErr := store.UpdateExecution(context.WithoutCancel(ctx), execution); updErr != nil {
+			log.Printf("ERROR: Failed to release execution %s: %v", executionID, updErr)
+		}
+		return ErrApprovalNotPending
+	}
+
+	r.wait.Data["decision"] = decision.Decision
+	r.wait.Data["decided_by"] = decision.DecidedBy
+	r.wait.Data["comment"] = decision.Comment
+	r.wait.Data["via"] = decision.Via
+	r.wait.Data["decided_at"] = time.Now().UTC().Format(time.RFC3339)
+	execution.Status = "running"
+
+	log.Printf("ENGINE: >>> Approval %s for execution %s by %s, resuming <<<", decision.Decision, executionID, decision.DecidedBy)
+	go r.run(store, true)
+	return nil
+}
+
+// pendingApprovalNonce devuelve el nonce de la aprobación que espera execution
+// (en el paso step si no es -1), o "" si no espera ninguna.
+func pendingApprovalNonce(execution *workflow.ExecutionLog, step int) string {
+	if execution.Status != "suspended" {
+		return ""
+	}
+	r, err := restoreRunner(execution)
+	if err != nil || r.wait == nil || r.wait.Kind != waitKindApproval || (step >= 0 && r.wait.Step != step) {
+		return ""
+	}
+	nonce, _ := r.wait.Data["nonce"].(string)
+	return nonce
+}
+
+// SignApprovalToken devuelve base64url(claims) + "." + base64url(HMAC-SHA256).
+func SignApprovalToken(claims ApprovalClaims) (string, error) {
+	if config.AppConfig.ApprovalSigningKey == "" {
+		return "", ErrApprovalLinksOff
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(approvalMAC(encoded)), nil
+}
+
+// ParseApprovalToken verifica la firma y devuelve el contenido del enlace.
+func ParseApprovalToken(token string) (*ApprovalClaims, error) {
+	if config.AppConfig.ApprovalSigningKey == "" {
+		return nil, ErrApprovalLinksOff
+	}
+	encoded, sig, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrInvalidApprovalToken
+	}
+	given, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(given, approvalMAC(encoded)) {
+		return nil, ErrInvalidApprovalToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidApprovalToken
+	}
+	var claims ApprovalClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidApprovalToken
+	}
+	return &claims, nil
+}
+
+func approvalMAC(data string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.ApprovalSigningKey))
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,72 @@
+// services/task-orchestrator-service/internal/engine/approval_action_test.go
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// updateCountingStore cuenta las escrituras de UpdateExecution.
+type updateCountingStore struct {
+	*workflow.InMemoryWorkflowStore
+	updates atomic.Int32
+}
+
+func (s *updateCountingStore) UpdateExecution(ctx context.Context, exec *workflow.ExecutionLog) error {
+	s.updates.Add(1)
+	return s.InMemoryWorkflowStore.UpdateExecution(ctx, exec)
+}
+
+// suspendOnApproval deja la ejecución de r suspendida en una aprobación del paso 0.
+func suspendOnApproval(t *testing.T, r *runner, store workflow.Store, nonce string) {
+	t.Helper()
+	r.suspend(0, time.Now().UTC(), &suspension{kind: waitKindApproval, key: nonce,
+		data: map[string]interface{}{"nonce": nonce, "on_timeout": "reject"}})
+	r.snapshot()
+	if err := store.UpdateExecution(context.Background(), r.execution); err != nil {
+		t.Fatalf("UpdateExecution: %v", err)
+	}
+}
+
+func TestDecideApprovalWithStaleLinkDoesNotTouchTheExecution(t *testing.T) {
+	wf := workflow.Workflow{ID: uuid.New(), UserID: "user-1", Name: "approval",
+		Actions: []workflow.ActionDefinition{{Name: "gate", Type: workflow.ActionTypeApproval}, logAction("after")}}
+	r, counting := newCountingRunner(t, wf)
+	store := &updateCountingStore{InMemoryWorkflowStore: counting.InMemoryWorkflowStore}
+	suspendOnApproval(t, r, store, "nonce-2")
+	store.updates.Store(0)
+
+	decision := ApprovalDecision{Decision: ApprovalApproved, DecidedBy: "ops@example.com", Via: "link"}
+	// Enlace de una aprobación anterior del mismo paso.
+	if err := DecideApproval(context.Background(), store, r.execution.ID, "", 0, "nonce-1", decision); !errors.Is(err, ErrApprovalNotPending) {
+		t.Fatalf("replayed link: err = %v, want ErrApprovalNotPending", err)
+	}
+	if got := store.updates.Load(); got != 0 {
+		t.Errorf("a stale decision wrote the execution %d times", got)
+	}
+	stored, _ := store.GetExecutionByID(context.Background(), wf.UserID, r.execution.ID)
+	if stored.Status != "suspended" {
+		t.Fatalf("status = %q, want suspended", stored.Status)
+	}
+
+	if err := DecideApproval(context.Background(), store, r.execution.ID, wf.UserID, -1, "", decision); err != nil {
+		t.Fatalf("DecideApproval: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for stored.Status != "completed" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		stored, _ = store.GetExecutionByID(context.Background(), wf.UserID, r.execution.ID)
+	}
+	if stored.Status != "completed" {
+		t.Fatalf("status = %q after approving, want completed", stored.Status)
+	}
+	if err := DecideApproval(context.Background(), store, r.execution.ID, "", 0, "nonce-2", decision); !errors.Is(err, ErrApprovalNotPending) {
+		t.Errorf("used link: err = %v, want ErrApprovalNotPending", err)
+	}
+}
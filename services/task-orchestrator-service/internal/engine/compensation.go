@@ -0,0 +1,110 @@
+// services/task-orchestrator-service/internal/engine/compensation.go
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// failureInfo describe el primer paso que falló; se expone como `.Error`
+// a las acciones de compensación y de on_failure.
+type failureInfo struct {
+	Step    string              `json:"step"`
+	Type    workflow.ActionType `json:"type"`
+	Message string              `json:"message"`
+}
+
+// templateValue devuelve el fallo con las claves que usan las plantillas.
+func (f *failureInfo) templateValue() map[string]interface{} {
+	if f == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"Step":    f.Step,
+		"Type":    string(f.Type),
+		"Message": f.Message,
+	}
+}
+
+// recordFailure guarda el primer fallo de la ejecución.
+func (r *runner) recordFailure(action workflow.ActionDefinition, err error) {
+	r.failed = true
+	if r.failure == nil {
+		r.failure = &failureInfo{Step: action.Name, Type: action.Type, Message: err.Error()}
+	}
+}
+
+// suspendingActions no pueden usarse como compensación ni en on_failure:
+// esas acciones se ejecutan al final de una ejecución fallida, sin posibilidad de reanudarla.
+var suspendingActions = map[workflow.ActionType]bool{
+	workflow.ActionTypeDelay:        true,
+	workflow.ActionTypeWaitUntil:    true,
+	workflow.ActionTypeApproval:     true,
+	workflow.ActionTypeWaitForEvent: true,
+}
+
+// handleFailure ejecuta, tras un fallo, las compensaciones de los pasos completados
+// en orden inverso y después las acciones on_failure del workflow. Cada una se
+// registra como un paso más ("compensate:<paso>" y "on_failure:<acción>").
+// Son de mejor esfuerzo: si una falla se registra y se continúa con las demás.
+func (r *runner) handleFailure() {
+	completed := make([]StepResult, len(r.steps))
+	copy(completed, r.steps)
+
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i]
+		if step.Status != "completed" {
+			continue
+		}
+		action, ok := r.actionByName(step.Name)
+		if !ok || action.Compensate == nil {
+			continue
+		}
+		r.addLog(fmt.Sprintf("--- Compensating Action '%s' with '%s' ---", step.Name, action.Compensate.Name), "INFO")
+		r.runHandlerStep("compensate:"+step.Name, *action.Compensate)
+	}
+
+	for _, action := range r.wf.OnFailure {
+		r.addLog(fmt.Sprintf("--- Executing on_failure Action '%s', Type: '%s' ---", action.Name, action.Type), "INFO")
+		if output, ok := r.runHandlerStep("on_failure:"+action.Name, action); ok {
+			r.outputs[action.Name] = output
+		}
+	}
+}
+
+// runHandlerStep ejecuta una acción de compensación u on_failure y la añade a los resultados.
+func (r *runner) runHandlerStep(stepName string, action workflow.ActionDefinition) (interface{}, bool) {
+	defer r.flushLogs()
+	step := StepResult{Name: stepName, Type: action.Type, StartedAt: time.Now().UTC()}
+	output, err := r.executeAction(action)
+	var susp *suspension
+	if errors.As(err, &susp) {
+		output, err = nil, fmt.Errorf("action '%s' cannot suspend the execution while handling a failure", action.Name)
+	}
+	step.CompletedAt = time.Now().UTC()
+	step.Output = output
+
+	if err != nil {
+		r.addLog(fmt.Sprintf("Error processing Action '%s': %v", stepName, err), "ERROR")
+		step.Status = "failed"
+		step.Error = err.Error()
+		r.steps = append(r.steps, step)
+		return nil, false
+	}
+	r.addLog(fmt.Sprintf("Action '%s' completed successfully", stepName), "INFO")
+	step.Status = "completed"
+	r.steps = append(r.steps, step)
+	return output, true
+}
+
+func (r *runner) actionByName(name string) (workflow.ActionDefinition, bool) {
+	for _, action := range r.wf.Actions {
+		if action.Name == name {
+			return action, true
+		}
+	}
+	return workflow.ActionDefinition{}, false
+}
@@ -0,0 +1,169 @@
+// services/task-orchestrator-service/internal/engine/email_action.go
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/config"
+)
+
+// executeSendEmail envía un correo a través del relay SMTP configurado.
+// Config: {"to": [...], "cc": [...], "bcc": [...], "subject": "...", "text": "...",
+// "html": "...", "attachments": [{"filename": "...", "from_step": "...", "field": "...", "content_type": "..."}]}
+// subject, text, html y las direcciones son plantillas evaluadas con los datos de la ejecución.
+func (r *runner) executeSendEmail(action workflow.ActionDefinition) (interface{}, error) {
+	msg, err := r.buildEmail(action)
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': %w", action.Name, err)
+	}
+
+	messageID, err := getMailer().Send(r.ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': failed to send email: %w", action.Name, err)
+	}
+
+	r.addLog(fmt.Sprintf("Email '%s' sent to %d recipient(s), Message-ID: %s", msg.Subject, len(msg.recipients()), messageID), "ACTION_OUTPUT")
+	return map[string]interface{}{
+		"message_id": messageID,
+		"to":         msg.To,
+		"cc":         msg.Cc,
+		"bcc":        msg.Bcc,
+		"subject":    msg.Subject,
+	}, nil
+}
+
+// buildEmail resuelve plantillas, direcciones y adjuntos a partir de la configuración de la acción.
+func (r *runner) buildEmail(action workflow.ActionDefinition) (*emailMessage, error) {
+	if config.AppConfig.SMTPFrom == "" {
+		return nil, fmt.Errorf("SMTP_FROM is not configured")
+	}
+	data := r.templateData()
+
+	msg := &emailMessage{From: config.AppConfig.SMTPFrom}
+	var err error
+	if msg.To, err = r.addressList(action, "to", data); err != nil {
+		return nil, err
+	}
+	if msg.Cc, err = r.addressList(action, "cc", data); err != nil {
+		return nil, err
+	}
+	if msg.Bcc, err = r.addressList(action, "bcc", data); err != nil {
+		return nil, err
+	}
+	if len(msg.To)+len(msg.Cc)+len(msg.Bcc) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required in 'to', 'cc' or 'bcc'")
+	}
+
+	subject, _ := action.Config["subject"].(string)
+	if msg.Subject, err = renderText("subject", subject, data); err != nil {
+		return nil, fmt.Errorf("invalid 'subject' template: %w", err)
+	}
+	text, _ := action.Config["text"].(string)
+	if msg.Text, err = renderText("text", text, data); err != nil {
+		return nil, fmt.Errorf("invalid 'text' template: %w", err)
+	}
+	html, _ := action.Config["html"].(string)
+	if msg.HTML, err = renderHTML("html", html, data); err != nil {
+		return nil, fmt.Errorf("invalid 'html' template: %w", err)
+	}
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, fmt.Errorf("either 'text' or 'html' body is required")
+	}
+
+	if msg.Attachments, err = r.emailAttachments(action); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// addressList acepta una dirección o una lista de direcciones, cada una como plantilla.
+func (r *runner) addressList(action workflow.ActionDefinition, key string, data interface{}) ([]string, error) {
+	var raw []string
+	switch v := action.Config[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		raw = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("'%s' must contain only strings", key)
+			}
+			raw = append(raw, s)
+		}
+	default:
+		return nil, fmt.Errorf("'%s' must be a string or a list of strings", key)
+	}
+
+	addresses := make([]string, 0, len(raw))
+	for _, item := range raw {
+		rendered, err := renderText(key, item, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s' template: %w", key, err)
+		}
+		if rendered == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(rendered); err != nil {
+			return nil, fmt.Errorf("invalid address %q in '%s': %w", rendered, key, err)
+		}
+		addresses = append(addresses, rendered)
+	}
+	return addresses, nil
+}
+
+// emailAttachments toma el contenido de cada adjunto de la salida de un paso previo.
+// Si la salida (o el campo indicado) es texto se adjunta tal cual; si no, como JSON.
+func (r *runner) emailAttachments(action workflow.ActionDefinition) ([]emailAttachment, error) {
+	rawList, ok := action.Config["attachments"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	attachments := make([]emailAttachment, 0, len(rawList))
+	for i, raw := range rawList {
+		spec, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("attachment %d must be an object", i)
+		}
+		filename, _ := spec["filename"].(string)
+		fromStep, _ := spec["from_step"].(string)
+		if filename == "" || fromStep == "" {
+			return nil, fmt.Errorf("attachment %d requires 'filename' and 'from_step'", i)
+		}
+
+		value, found := r.outputs[fromStep]
+		if !found {
+			return nil, fmt.Errorf("attachment %d: step '%s' has no output (not executed or failed)", i, fromStep)
+		}
+		if field, _ := spec["field"].(string); field != "" {
+			obj, isMap := value.(map[string]interface{})
+			if !isMap {
+				return nil, fmt.Errorf("attachment %d: output of step '%s' is not an object", i, fromStep)
+			}
+			value = obj[field]
+		}
+
+		att := emailAttachment{Filename: filename}
+		if s, isStr := value.(string); isStr {
+			att.Data = []byte(s)
+			att.ContentType = "text/plain; charset=utf-8"
+		} else {
+			encoded, err := json.MarshalIndent(value, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("attachment %d: failed to encode output as JSON: %w", i, err)
+			}
+			att.Data = encoded
+			att.ContentType = "application/json"
+		}
+		if contentType, _ := spec["content_type"].(string); contentType != "" {
+			att.ContentType = contentType
+		}
+		attachments = append(attachments, att)
+	}
+	return attachments, nil
+}
@@ -0,0 +1,195 @@
+// services/task-orchestrator-service/internal/engine/event_action.go
+package engine
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/config"
+)
+
+const waitKindEvent = "event"
+
+var (
+	ErrInvalidEventToken = errors.New("invalid or tampered event token")
+	ErrEventsOff         = errors.New("event delivery is not configured (EVENTS_SIGNING_KEY)")
+)
+
+// EventClaims es el contenido firmado de la URL de eventos de un workflow: un
+// evento entregado en ella solo reanuda ejecuciones de ese workflow.
+type EventClaims struct {
+	UserID     string    `json:"u"`
+	WorkflowID uuid.UUID `json:"w"`
+}
+
+// executeWaitForEvent suspende la ejecución hasta que llegue a POST /events/:name,
+// con el token de eventos del workflow, un evento con la misma clave de
+// correlación, o hasta que venza el timeout.
+// Config: {"event": "payment.completed", "correlation_key": "{{ .Steps.charge.body.id }}",
+// "timeout": "1h", "on_timeout": "fail"|"continue"}
+// El payload del evento se convierte en la salida del paso.
+func (r *runner) executeWaitForEvent(action workflow.ActionDefinition) (interface{}, error) {
+	event, _ := action.Config["event"].(string)
+	rawKey, _ := action.Config["correlation_key"].(string)
+	if event == "" || rawKey == "" {
+		return nil, fmt.Errorf("action '%s': 'event' and 'correlation_key' are required for wait_for_event", action.Name)
+	}
+	key, err := renderText("correlation_key", rawKey, r.templateData())
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': invalid 'correlation_key' template: %w", action.Name, err)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("action '%s': 'correlation_key' rendered to an empty value", action.Name)
+	}
+
+	onTimeout, _ := action.Config["on_timeout"].(string)
+	switch onTimeout {
+	case "":
+		onTimeout = "fail"
+	case "fail", "continue":
+	default:
+		return nil, fmt.Errorf("action '%s': 'on_timeout' must be 'fail' or 'continue'", action.Name)
+	}
+
+	var until time.Time
+	if raw, _ := action.Config["timeout"].(string); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("action '%s': invalid timeout %q", action.Name, raw)
+		}
+		until = time.Now().UTC().Add(timeout)
+	}
+
+	return nil, &suspension{
+		kind:  waitKindEvent,
+		until: until,
+		event: event,
+		key:   key,
+		data: map[string]interface{}{
+			"event":           event,
+			"correlation_key": key,
+			"on_timeout":      onTimeout,
+		},
+	}
+}
+
+// completeEventWait termina la espera: con el payload recibido o, si venció el
+// plazo, según on_timeout.
+func (r *runner) completeEventWait(action workflow.ActionDefinition) (interface{}, error) {
+	if received, _ := r.wait.Data["received"].(bool); received {
+		r.addLog(fmt.Sprintf("Event '%v' received for action '%s'", r.wait.Data["event"], action.Name), "ACTION_OUTPUT")
+		return r.wait.Data["payload"], nil
+	}
+	if onTimeout, _ := r.wait.Data["on_timeout"].(string); onTimeout == "continue" {
+		r.addLog(fmt.Sprintf("Timed out waiting for event '%v'; continuing", r.wait.Data["event"]), "WARNING")
+		return nil, nil
+	}
+	return nil, &haltError{fmt.Errorf("timed out waiting for event '%v' with key '%v'", r.wait.Data["event"], r.wait.Data["correlation_key"])}
+}
+
+// DeliverEvent reanuda las ejecuciones del workflow de claims que esperan el
+// evento con esa clave de correlación, usando payload como salida del paso.
+// Devuelve cuántas reanudó. ctx solo limita la reclamación: las ejecuciones
+// continúan en segundo plano.
+func DeliverEvent(ctx context.Context, store workflow.Store, claims EventClaims, eventName, correlationKey string, payload interface{}) (int, error) {
+	executions, err := store.ClaimExecutionsWaitingForEvent(ctx, claims.UserID, claims.WorkflowID, eventName, correlationKey)
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for _, execution := range executions {
+		r, err := restoreRunner(execution)
+		if err != nil || r.wait == nil || r.wait.Kind != waitKindEvent {
+			log.Printf("ERROR: Execution %s claimed for event '%s' has no pending event wait: %v", execution.ID, eventName, err)
+			execution.Status = "failed"
+			now := time.Now().UTC()
+			execution.CompletedAt = &now
+			execution.WaitEvent, execution.WaitKey = "", ""
+			if updErr := store.UpdateExecution(context.WithoutCancel(ctx), execution); updErr != nil {
+				log.Printf("ERROR: Failed to update execution record %s: %v", execution.ID, updErr)
+			}
+			continue
+		}
+		r.wait.Data["received"] = true
+		r.wait.Data["payload"] = payload
+		r.wait.Data["received_at"] = time.Now().UTC().Format(time.RFC3339)
+		execution.Status = "running"
+
+		log.Printf("ENGINE: >>> Event '%s' matched execution %s, resuming <<<", eventName, execution.ID)
+		go r.run(store, true)
+		resumed++
+	}
+	return resumed, nil
+}
+
+// EventTokenHeader es la cabecera que lleva el token de eventos del workflow en
+// POST /events/:name.
+const EventTokenHeader = "X-Event-Token"
+
+// EventsURL devuelve la URL a la que los sistemas externos envían los eventos
+// (se le añade el nombre del evento).
+func EventsURL() string {
+	return strings.TrimRight(config.AppConfig.PublicBaseURL, "/") + "/api/tasks/v1/events"
+}
+
+// EventsToken devuelve el token firmado que identifica al workflow al entregar
+// eventos: solo reanuda ejecuciones de ese workflow.
+func EventsToken(wf workflow.Workflow) (string, error) {
+	return SignEventToken(EventClaims{UserID: wf.UserID, WorkflowID: wf.ID})
+}
+
+// SignEventToken devuelve base64url(claims) + "." + base64url(HMAC-SHA256), como
+// los enlaces de aprobación pero con su propia clave.
+func SignEventToken(claims EventClaims) (string, error) {
+	if config.AppConfig.EventsSigningKey == "" {
+		return "", ErrEventsOff
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(eventMAC(encoded)), nil
+}
+
+// ParseEventToken verifica la firma y devuelve el workflow al que pertenece la URL.
+func ParseEventToken(token string) (*EventClaims, error) {
+	if config.AppConfig.EventsSigningKey == "" {
+		return nil, ErrEventsOff
+	}
+	encoded, sig, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrInvalidEventToken
+	}
+	given, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(given, eventMAC(encoded)) {
+		return nil, ErrInvalidEventToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidEventToken
+	}
+	var claims EventClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.UserID == "" || claims.WorkflowID == uuid.Nil {
+		return nil, ErrInvalidEventToken
+	}
+	return &claims, nil
+}
+
+func eventMAC(data string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.EventsSigningKey))
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,69 @@
+// services/task-orchestrator-service/internal/engine/event_action_test.go
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/pkg/config"
+)
+
+func withEventsSigningKey(t *testing.T, key string) {
+	t.Helper()
+	previous := config.AppConfig.EventsSigningKey
+	config.AppConfig.EventsSigningKey = key
+	t.Cleanup(func() { config.AppConfig.EventsSigningKey = previous })
+}
+
+func TestEventTokenRoundTrip(t *testing.T) {
+	withEventsSigningKey(t, "test-key")
+	claims := EventClaims{UserID: "user-1", WorkflowID: uuid.New()}
+
+	token, err := SignEventToken(claims)
+	if err != nil {
+		t.Fatalf("SignEventToken: %v", err)
+	}
+	got, err := ParseEventToken(token)
+	if err != nil {
+		t.Fatalf("ParseEventToken: %v", err)
+	}
+	if *got != claims {
+		t.Errorf("claims = %+v, want %+v", *got, claims)
+	}
+}
+
+func TestEventTokenRejectsTampering(t *testing.T) {
+	withEventsSigningKey(t, "test-key")
+	token, _ := SignEventToken(EventClaims{UserID: "user-1", WorkflowID: uuid.New()})
+	forged, _ := SignEventToken(EventClaims{UserID: "user-2", WorkflowID: uuid.New()})
+	encoded, sig, _ := strings.Cut(token, ".")
+	forgedEncoded, _, _ := strings.Cut(forged, ".")
+
+	for name, candidate := range map[string]string{
+		"other claims":  forgedEncoded + "." + sig,
+		"no signature":  encoded,
+		"bad signature": encoded + ".AAAA",
+		"empty":         "",
+	} {
+		if _, err := ParseEventToken(candidate); !errors.Is(err, ErrInvalidEventToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidEventToken", name, err)
+		}
+	}
+
+	withEventsSigningKey(t, "rotated-key")
+	if _, err := ParseEventToken(token); !errors.Is(err, ErrInvalidEventToken) {
+		t.Errorf("token signed with a previous key: err = %v, want ErrInvalidEventToken", err)
+	}
+}
+
+func TestEventTokenWithoutKey(t *testing.T) {
+	withEventsSigningKey(t, "")
+	if _, err := SignEventToken(EventClaims{UserID: "user-1", WorkflowID: uuid.New()}); !errors.Is(err, ErrEventsOff) {
+		t.Errorf("SignEventToken: err = %v, want ErrEventsOff", err)
+	}
+	if _, err := ParseEventToken("a.b"); !errors.Is(err, ErrEventsOff) {
+		t.Errorf("ParseEventToken: err = %v, want ErrEventsOff", err)
+	}
+}
@@ -2,14 +2,13 @@
 package engine
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,6 +17,10 @@ import (
 
 var httpClient = &http.Client{Timeout: 30 * time.Second}
 
+// logFlushInterval es lo máximo que una entrada de log espera en memoria antes de
+// guardarse, para que el seguimiento en vivo no dependa de que termine el paso.
+const logFlushInterval = 500 * time.Millisecond
+
 // LogEntry define la estructura de una línea de log individual que guardaremos como JSON.
 type LogEntry struct {
 	Timestamp time.Time `json:"timestamp"`
@@ -25,173 +28,431 @@ type LogEntry struct {
 	Status    string    `json:"status"` // "INFO", "ERROR", "ACTION_OUTPUT"
 }
 
+// StepResult guarda el resultado de una acción individual dentro de una ejecución.
+type StepResult struct {
+	Name        string              `json:"name"`
+	Type        workflow.ActionType `json:"type"`
+	Status      string              `json:"status"` // "completed", "failed", "skipped"
+	Output      interface{}         `json:"output,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	StartedAt   time.Time           `json:"started_at"`
+	CompletedAt time.Time           `json:"completed_at"`
+	Reused      bool                `json:"reused,omitempty"` // Resultado copiado de la ejecución original al reanudarla
+}
+
+// RunInput describe cómo se disparó una ejecución y los datos que recibe.
+type RunInput struct {
+	Source  string                 // Origen del disparo: "schedule", "manual", ...
+	Inputs  map[string]interface{} // Parámetros de entrada de la ejecución
+	Payload map[string]interface{} // Datos del disparador (ej. cuerpo de un webhook)
+	// Ejecución de la que procede (re-run o reanudación tras un fallo).
+	ParentExecutionID *uuid.UUID
+}
+
+// runner mantiene el estado de una ejecución en curso.
+type runner struct {
+	// ctx no depende de la petición que disparó la ejecución: esta sigue en
+	// segundo plano cuando la petición ya respondió.
+	ctx       context.Context
+	store     workflow.Store // Nil hasta que el registro de ejecución existe
+	wf        workflow.Workflow
+	execution *workflow.ExecutionLog
+	logs      []LogEntry
+	steps     []StepResult
+	outputs   map[string]interface{} // Salida de cada paso, indexada por nombre de acción
+	inputs    map[string]interface{}
+	trigger   map[string]interface{}
+	next      int          // Índice de la próxima acción a ejecutar
+	failed    bool         // Alguna acción falló
+	wait      *waitState   // Espera pendiente si la ejecución está suspendida
+	failure   *failureInfo // Primer paso que falló, expuesto como .Error
+
+	// Entradas de log aún no guardadas. flushMu ordena las escrituras; logMu
+	// protege la cola, que también vacía el temporizador.
+	flushMu     sync.Mutex
+	logMu       sync.Mutex
+	pendingLogs []json.RawMessage
+	flushTimer  *time.Timer
+}
+
+// addLog registra una entrada y, si la ejecución ya existe en el store, la encola
+// para guardarla al terminar el paso o, como tarde, tras logFlushInterval, de modo
+// que pueda seguirse en vivo (GET /executions/:id/logs/stream) sin una escritura
+// y un aviso por línea.
+func (r *runner) addLog(message string, status string) {
+	entry := LogEntry{Timestamp: time.Now().UTC(), Message: message, Status: status}
+	r.logs = append(r.logs, entry)
+	log.Printf("[%s] %s", status, message)
+
+	if r.store == nil {
+		return
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	r.logMu.Lock()
+	r.pendingLogs = append(r.pendingLogs, encoded)
+	if r.flushTimer == nil {
+		r.flushTimer = time.AfterFunc(logFlushInterval, r.flushLogs)
+	}
+	r.logMu.Unlock()
+}
+
+// flushLogs guarda las entradas pendientes en una sola escritura.
+func (r *runner) flushLogs() {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+	entries := r.takePendingLogs()
+	if len(entries) == 0 {
+		return
+	}
+	if err := r.store.AppendExecutionLogs(r.ctx, r.execution.ID, entries); err != nil {
+		log.Printf("ERROR: Failed to append logs for execution %s: %v", r.execution.ID, err)
+	}
+}
+
+// discardPendingLogs descarta las entradas pendientes antes de guardar el registro
+// completo, que ya las incluye. Espera a que termine una escritura en curso para
+// que no llegue después y las duplique.
+func (r *runner) discardPendingLogs() {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+	r.takePendingLogs()
+}
+
+func (r *runner) takePendingLogs() []json.RawMessage {
+	r.logMu.Lock()
+	defer r.logMu.Unlock()
+	if r.flushTimer != nil {
+		r.flushTimer.Stop()
+		r.flushTimer = nil
+	}
+	entries := r.pendingLogs
+	r.pendingLogs = nil
+	return entries
+}
+
+// templateData expone el contexto de la ejecución a las plantillas de configuración.
+// Workflow.EventsToken queda vacío si no hay EVENTS_SIGNING_KEY.
+func (r *runner) templateData() map[string]interface{} {
+	eventsToken, _ := EventsToken(r.wf)
+	return map[string]interface{}{
+		"Workflow": map[string]interface{}{
+			"ID":          r.wf.ID.String(),
+			"Name":        r.wf.Name,
+			"Description": r.wf.Description,
+			"EventsURL":   EventsURL(),
+			"EventsToken": eventsToken,
+		},
+		"Execution": map[string]interface{}{
+			"ID":          r.execution.ID.String(),
+			"TriggeredAt": r.execution.TriggeredAt,
+		},
+		"Inputs":  r.inputs,
+		"Trigger": r.trigger,
+		"Steps":   r.outputs,
+		"Results": r.steps,
+		"Error":   r.failure.templateValue(),
+	}
+}
+
 // ExecuteWorkflow ahora acepta el 'Store' para poder guardar los resultados.
+// Es la firma que usa el scheduler para los disparos programados.
 func ExecuteWorkflow(wf workflow.Workflow, store workflow.Store) {
+	ExecuteWorkflowWithInput(wf, store, RunInput{Source: string(workflow.TriggerTypeSchedule)})
+}
+
+// ExecuteWorkflowWithInput ejecuta el workflow con los datos de entrada y del disparador indicados.
+func ExecuteWorkflowWithInput(wf workflow.Workflow, store workflow.Store, input RunInput) {
 	log.Printf("ENGINE: >>> Starting execution for Workflow ID %s, Name: '%s' <<<", wf.ID, wf.Name)
 
-	executionLogs := []LogEntry{}
-	addLog := func(message string, status string) {
-		entry := LogEntry{Timestamp: time.Now().UTC(), Message: message, Status: status}
-		executionLogs = append(executionLogs, entry)
-		log.Printf("[%s] %s", status, message)
-	}
+	r := newRunner(wf, input)
 
-	// 1. Crear el registro de ejecución inicial en la base de datos
-	initialLog, _ := json.Marshal([]LogEntry{
-		{Timestamp: time.Now().UTC(), Message: fmt.Sprintf("Starting execution for Workflow '%s'", wf.Name), Status: "INFO"},
-	})
-	execution := &workflow.ExecutionLog{
-		ID:          uuid.New(),
-		WorkflowID:  wf.ID,
-		UserID:      wf.UserID,
-		Status:      "running",
-		TriggeredAt: time.Now().UTC(),
-		Logs:        initialLog,
-	}
-	
 	// Variable para trackear si la ejecución fue creada exitosamente
 	executionCreated := false
-	
-	if err := store.CreateExecution(execution); err != nil {
+
+	// 1. Crear el registro de ejecución inicial en la base de datos
+	if err := r.create(r.ctx, store); err != nil {
 		log.Printf("ERROR: Failed to create execution record for workflow %s: %v", wf.ID, err)
-		addLog(fmt.Sprintf("Failed to create execution record: %v", err), "ERROR")
+		r.addLog(fmt.Sprintf("Failed to create execution record: %v", err), "ERROR")
 		// Si no podemos crear el registro, aún podemos ejecutar pero no guardar el resultado
 	} else {
 		executionCreated = true
-		log.Printf("SUCCESS: Execution record created for workflow %s with ID %s", wf.ID, execution.ID)
+		log.Printf("SUCCESS: Execution record created for workflow %s with ID %s", wf.ID, r.execution.ID)
+	}
+
+	r.run(store, executionCreated)
+}
+
+// newRunner prepara una ejecución nueva, todavía sin registro en el store.
+func newRunner(wf workflow.Workflow, input RunInput) *runner {
+	execution := &workflow.ExecutionLog{
+		ID:                uuid.New(),
+		WorkflowID:        wf.ID,
+		UserID:            wf.UserID,
+		Status:            "running",
+		TriggeredAt:       time.Now().UTC(),
+		TriggerSource:     input.Source,
+		ParentExecutionID: input.ParentExecutionID,
+		WorkflowVersion:   wf.Version,
+	}
+
+	r := &runner{
+		ctx:       context.Background(),
+		wf:        wf,
+		execution: execution,
+		logs: []LogEntry{
+			{Timestamp: time.Now().UTC(), Message: fmt.Sprintf("Starting execution for Workflow '%s'", wf.Name), Status: "INFO"},
+		},
+		outputs: map[string]interface{}{},
+		inputs:  input.Inputs,
+		trigger: map[string]interface{}{
+			"type":    string(wf.Trigger.Type),
+			"source":  input.Source,
+			"payload": input.Payload,
+		},
+	}
+	if r.inputs == nil {
+		r.inputs = map[string]interface{}{}
+	}
+	return r
+}
+
+// create guarda el registro inicial de la ejecución con los logs acumulados hasta ahora.
+// ctx es el de quien crea la ejecución (la petición HTTP en un re-run).
+func (r *runner) create(ctx context.Context, store workflow.Store) error {
+	initialLog, err := json.Marshal(r.logs)
+	if err != nil {
+		return err
+	}
+	r.execution.Logs = initialLog
+	return store.CreateExecution(ctx, r.execution)
+}
+
+// ResumeExecution continúa una ejecución suspendida (delay, wait_until...) a partir
+// del estado guardado. El scheduler la llama cuando vence su resume_at.
+func ResumeExecution(execution *workflow.ExecutionLog, store workflow.Store) {
+	log.Printf("ENGINE: >>> Resuming execution %s for Workflow ID %s <<<", execution.ID, execution.WorkflowID)
+
+	r, err := restoreRunner(execution)
+	if err != nil {
+		log.Printf("ERROR: Failed to restore execution %s: %v", execution.ID, err)
+		execution.Status = "failed"
+		now := time.Now().UTC()
+		execution.CompletedAt = &now
+		if err := store.UpdateExecution(context.Background(), execution); err != nil {
+			log.Printf("ERROR: Failed to update execution record %s: %v", execution.ID, err)
+		}
+		return
+	}
+	execution.Status = "running"
+
+	// La reclamación se renueva mientras corre; si se pierde (otra réplica la
+	// reclamó al caducar), se cancela r.ctx para no pisar su trabajo.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.ctx = ctx
+	stopLease := keepLease(ctx, cancel, store, execution.ID)
+	defer stopLease()
+
+	r.run(store, true)
+}
+
+// leaseRenewInterval es cada cuánto se renueva la reclamación de una ejecución
+// reanudada; deja margen para varios fallos seguidos antes de que caduque.
+var leaseRenewInterval = workflow.ExecutionLease / 4
+
+// keepLease renueva la reclamación de executionID hasta que se llama a stop. Si
+// el store responde que se perdió, llama a lost.
+func keepLease(ctx context.Context, lost context.CancelFunc, store workflow.Store, executionID uuid.UUID) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(leaseRenewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := store.RenewExecutionLease(ctx, executionID, time.Now().UTC())
+				if errors.Is(err, workflow.ErrExecutionLeaseLost) {
+					log.Printf("ERROR: Lost the lease on execution %s; another replica resumed it", executionID)
+					lost()
+					return
+				}
+				if err != nil {
+					log.Printf("ERROR: Failed to renew the lease on execution %s: %v", executionID, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// run ejecuta las acciones pendientes desde r.next. Si una acción pide suspender
+// la ejecución, guarda el estado y devuelve sin marcarla como terminada.
+func (r *runner) run(store workflow.Store, persist bool) {
+	wf, execution := r.wf, r.execution
+	if persist {
+		r.store = store
 	}
 
 	// 2. Defer se asegura de que el estado final se guarde siempre (solo si la ejecución fue creada)
 	defer func() {
-		if !executionCreated {
+		if !persist {
 			log.Printf("SKIP: Not updating execution record because it was not created successfully")
 			return
 		}
 
-		if r := recover(); r != nil {
-			addLog(fmt.Sprintf("Panic recovered during execution: %v", r), "ERROR")
+		if rec := recover(); rec != nil {
+			r.addLog(fmt.Sprintf("Panic recovered during execution: %v", rec), "ERROR")
 			execution.Status = "failed"
 		}
-		
-		now := time.Now().UTC()
-		execution.CompletedAt = &now
-		logsJSON, err := json.Marshal(executionLogs)
-		if err != nil {
-			log.Printf("CRITICAL: Failed to marshal execution logs for workflow %s: %v", wf.ID, err)
-			// Usar logs vacíos si falla el marshal
-			execution.Logs = json.RawMessage("[]")
+
+		if execution.Status == "suspended" {
+			execution.CompletedAt = nil
 		} else {
-			execution.Logs = logsJSON
+			now := time.Now().UTC()
+			execution.CompletedAt = &now
+			execution.ResumeAt = nil
+			execution.WaitEvent = ""
+			execution.WaitKey = ""
 		}
-		
-		if err := store.UpdateExecution(execution); err != nil {
+		r.discardPendingLogs()
+		r.snapshot()
+
+		if err := store.UpdateExecution(r.ctx, execution); err != nil {
 			log.Printf("ERROR: Failed to update execution record for workflow %s: %v", wf.ID, err)
 		} else {
 			log.Printf("SUCCESS: Execution record updated for workflow %s, Status: %s", wf.ID, execution.Status)
 		}
-		
+
 		log.Printf("ENGINE: >>> Finished execution for Workflow ID %s, Status: %s <<<", wf.ID, execution.Status)
 	}()
 
 	if len(wf.Actions) == 0 {
-		addLog("Workflow has no actions to execute.", "WARNING")
+		r.addLog("Workflow has no actions to execute.", "WARNING")
 		execution.Status = "completed"
 		return
 	}
 
-	overallSuccess := true
-	for i, action := range wf.Actions {
-		addLog(fmt.Sprintf("--- Executing Action %d/%d: Name: '%s', Type: '%s' ---", i+1, len(wf.Actions), action.Name, action.Type), "INFO")
-		var actionErr error
-
-		switch action.Type {
-		case workflow.ActionTypeLogMessage:
-			msg, ok := action.Config["message"].(string)
-			if !ok {
-				actionErr = fmt.Errorf("action '%s': 'message' not found in config or is not a string", action.Name)
-			} else {
-				addLog(msg, "ACTION_OUTPUT")
-			}
-
-		case workflow.ActionTypeHTTPEndpoint:
-			// --- INICIO DE LA LÓGICA COMPLETA PARA HTTP ---
-			url, urlOk := action.Config["url"].(string)
-			if !urlOk || url == "" {
-				actionErr = fmt.Errorf("action '%s': 'url' is required for http_endpoint", action.Name)
-				break
-			}
-
-			method, _ := action.Config["method"].(string)
-			if method == "" {
-				method = http.MethodGet
-			}
-			method = strings.ToUpper(method)
-
-			var reqBody io.Reader
-			if bodyData, exists := action.Config["body"]; exists && bodyData != nil {
-				if bodyStr, isStr := bodyData.(string); isStr {
-					reqBody = strings.NewReader(bodyStr)
-				} else {
-					jsonBody, err := json.Marshal(bodyData)
-					if err != nil {
-						actionErr = fmt.Errorf("failed to marshal 'body' to JSON for action '%s': %w", action.Name, err)
-						break
-					}
-					reqBody = bytes.NewBuffer(jsonBody)
-				}
-			}
+	for r.next < len(wf.Actions) {
+		i, action := r.next, wf.Actions[r.next]
 
-			req, err := http.NewRequestWithContext(context.Background(), method, url, reqBody)
-			if err != nil {
-				actionErr = fmt.Errorf("failed to create HTTP request for action '%s': %w", action.Name, err)
-				break
-			}
-
-			if headersData, exists := action.Config["headers"].(map[string]interface{}); exists {
-				for key, val := range headersData {
-					if valStr, isStr := val.(string); isStr {
-						req.Header.Set(key, valStr)
-					}
-				}
-			}
-			if reqBody != nil && req.Header.Get("Content-Type") == "" {
-				if _, isMap := action.Config["body"].(map[string]interface{}); isMap {
-					req.Header.Set("Content-Type", "application/json")
-				}
-			}
+		var step StepResult
+		var output interface{}
+		var actionErr error
+		if r.wait != nil && r.wait.Step == i {
+			// La acción ya se ejecutó y suspendió la ejecución; ahora se completa la espera.
+			step = StepResult{Name: action.Name, Type: action.Type, StartedAt: r.wait.SuspendedAt}
+			output, actionErr = r.completeWait(action)
+			r.wait = nil
+		} else {
+			r.addLog(fmt.Sprintf("--- Executing Action %d/%d: Name: '%s', Type: '%s' ---", i+1, len(wf.Actions), action.Name, action.Type), "INFO")
+			step = StepResult{Name: action.Name, Type: action.Type, StartedAt: time.Now().UTC()}
+			output, actionErr = r.executeAction(action)
+		}
 
-			resp, err := httpClient.Do(req)
-			if err != nil {
-				actionErr = fmt.Errorf("failed to execute HTTP request for action '%s': %w", action.Name, err)
-				break
-			}
-			defer resp.Body.Close()
+		var susp *suspension
+		if errors.As(actionErr, &susp) {
+			r.suspend(i, step.StartedAt, susp)
+			return
+		}
 
-			respBodyBytes, _ := io.ReadAll(resp.Body)
-			addLog(fmt.Sprintf("HTTP Status: %s, Response Body: %.500s", resp.Status, string(respBodyBytes)), "ACTION_OUTPUT")
+		step.CompletedAt = time.Now().UTC()
+		step.Output = output
 
-			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-				actionErr = fmt.Errorf("HTTP request for action '%s' returned non-2xx status: %s", action.Name, resp.Status)
+		var halt *haltError
+		stop := false
+		if actionErr != nil {
+			r.addLog(fmt.Sprintf("Error processing Action '%s': %v", action.Name, actionErr), "ERROR")
+			step.Status = "failed"
+			step.Error = actionErr.Error()
+			switch {
+			case errors.As(actionErr, &halt):
+				r.recordFailure(action, actionErr)
+				r.addLog(fmt.Sprintf("Action '%s' halted the execution; remaining actions will not run", action.Name), "ERROR")
+				stop = true
+			case action.ContinueOnError:
+				r.addLog(fmt.Sprintf("Action '%s' has continue_on_error; continuing", action.Name), "WARNING")
+			default:
+				r.recordFailure(action, actionErr)
+				stop = wf.FailFast
 			}
-			// --- FIN DE LA LÓGICA COMPLETA PARA HTTP ---
-
-		default:
-			actionErr = fmt.Errorf("unknown action type '%s'", action.Type)
+		} else {
+			r.addLog(fmt.Sprintf("Action '%s' completed successfully", action.Name), "INFO")
+			step.Status = "completed"
+			r.outputs[action.Name] = output
 		}
+		r.steps = append(r.steps, step)
+		r.next++
+		r.flushLogs()
 
-		if actionErr != nil {
-			addLog(fmt.Sprintf("Error processing Action '%s': %v", action.Name, actionErr), "ERROR")
-			overallSuccess = false
-		} else {
-			addLog(fmt.Sprintf("Action '%s' completed successfully", action.Name), "INFO")
+		if stop {
+			r.skipRemaining()
+			break
 		}
 	}
 
-	if overallSuccess {
+	if !r.failed {
 		execution.Status = "completed"
-		addLog("Workflow execution completed successfully", "INFO")
+		r.addLog("Workflow execution completed successfully", "INFO")
 	} else {
+		r.handleFailure()
 		execution.Status = "failed"
-		addLog("Workflow execution failed", "ERROR")
+		r.addLog("Workflow execution failed", "ERROR")
 	}
-}
\ No newline at end of file
+}
+
+// skipRemaining marca como "skipped" las acciones que no llegan a ejecutarse
+// porque la ejecución se detuvo tras un fallo.
+func (r *runner) skipRemaining() {
+	now := time.Now().UTC()
+	for ; r.next < len(r.wf.Actions); r.next++ {
+		action := r.wf.Actions[r.next]
+		r.addLog(fmt.Sprintf("Skipping Action '%s' due to an earlier failure", action.Name), "INFO")
+		r.steps = append(r.steps, StepResult{Name: action.Name, Type: action.Type, Status: "skipped", StartedAt: now, CompletedAt: now})
+	}
+}
+
+// executeAction despacha la acción a su implementación según el tipo.
+// El valor devuelto se guarda como salida del paso y queda disponible
+// para las acciones posteriores.
+func (r *runner) executeAction(action workflow.ActionDefinition) (interface{}, error) {
+	switch action.Type {
+	case workflow.ActionTypeLogMessage:
+		return r.executeLogMessage(action)
+	case workflow.ActionTypeHTTPEndpoint:
+		return r.executeHTTPEndpoint(action)
+	case workflow.ActionTypeSendEmail:
+		return r.executeSendEmail(action)
+	case workflow.ActionTypeSQLQuery:
+		return r.executeSQLQuery(action)
+	case workflow.ActionTypeScript:
+		return r.executeScript(action)
+	case workflow.ActionTypeTransform:
+		return r.executeTransform(action)
+	case workflow.ActionTypeDelay:
+		return r.executeDelay(action)
+	case workflow.ActionTypeWaitUntil:
+		return r.executeWaitUntil(action)
+	case workflow.ActionTypeApproval:
+		return r.executeApproval(action)
+	case workflow.ActionTypeWaitForEvent:
+		return r.executeWaitForEvent(action)
+	default:
+		return nil, fmt.Errorf("unknown action type '%s'", action.Type)
+	}
+}
+
+func (r *runner) executeLogMessage(action workflow.ActionDefinition) (interface{}, error) {
+	msg, ok := action.Config["message"].(string)
+	if !ok {
+		return nil, fmt.Errorf("action '%s': 'message' not found in config or is not a string", action.Name)
+	}
+	r.addLog(msg, "ACTION_OUTPUT")
+	return map[string]interface{}{"message": msg}, nil
+}
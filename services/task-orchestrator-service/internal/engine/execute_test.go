@@ -0,0 +1,142 @@
+// services/task-orchestrator-service/internal/engine/execute_test.go
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// countingStore cuenta las tandas de log que recibe el store.
+type countingStore struct {
+	*workflow.InMemoryWorkflowStore
+	mu      sync.Mutex
+	batches [][]json.RawMessage
+}
+
+func (s *countingStore) AppendExecutionLogs(ctx context.Context, executionID uuid.UUID, entries []json.RawMessage) error {
+	s.mu.Lock()
+	s.batches = append(s.batches, entries)
+	s.mu.Unlock()
+	return s.InMemoryWorkflowStore.AppendExecutionLogs(ctx, executionID, entries)
+}
+
+func (s *countingStore) batchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.batches)
+}
+
+// newCountingRunner guarda wf en un store en memoria y crea su ejecución.
+func newCountingRunner(t *testing.T, wf workflow.Workflow) (*runner, *countingStore) {
+	t.Helper()
+	store := &countingStore{InMemoryWorkflowStore: workflow.NewInMemoryWorkflowStore()}
+	if err := store.SaveWorkflow(context.Background(), &wf); err != nil {
+		t.Fatalf("SaveWorkflow: %v", err)
+	}
+	r := newRunner(wf, RunInput{Source: "manual"})
+	if err := r.create(context.Background(), store); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return r, store
+}
+
+func logAction(name string) workflow.ActionDefinition {
+	return workflow.ActionDefinition{Name: name, Type: workflow.ActionTypeLogMessage, Config: map[string]interface{}{"message": "hello from " + name}}
+}
+
+func TestRunWritesLogsOncePerStep(t *testing.T) {
+	wf := workflow.Workflow{ID: uuid.New(), UserID: "user-1", Name: "logs",
+		Actions: []workflow.ActionDefinition{logAction("a"), logAction("b"), logAction("c")}}
+	r, store := newCountingRunner(t, wf)
+	r.run(store, true)
+
+	if got := store.batchCount(); got != len(wf.Actions) {
+		t.Errorf("got %d log writes, want one per step (%d)", got, len(wf.Actions))
+	}
+	for i, batch := range store.batches {
+		if len(batch) < 2 {
+			t.Errorf("write %d has %d entries, want the whole step", i, len(batch))
+		}
+	}
+
+	stored, err := store.GetExecutionByID(context.Background(), wf.UserID, r.execution.ID)
+	if err != nil {
+		t.Fatalf("GetExecutionByID: %v", err)
+	}
+	var logs []LogEntry
+	if err := json.Unmarshal(stored.Logs, &logs); err != nil {
+		t.Fatalf("stored logs: %v", err)
+	}
+	if len(logs) != len(r.logs) {
+		t.Errorf("stored %d log entries, want %d without duplicates", len(logs), len(r.logs))
+	}
+}
+
+func TestAddLogFlushesAfterInterval(t *testing.T) {
+	r, store := newCountingRunner(t, workflow.Workflow{ID: uuid.New(), UserID: "user-1", Name: "slow"})
+	r.store = store
+
+	r.addLog("waiting on a slow step", "INFO")
+	r.addLog("still waiting", "INFO")
+	if got := store.batchCount(); got != 0 {
+		t.Fatalf("logs written before the flush interval: %d writes", got)
+	}
+	deadline := time.Now().Add(5 * logFlushInterval)
+	for store.batchCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := store.batchCount(); got != 1 || len(store.batches[0]) != 2 {
+		t.Fatalf("got %d writes, want a single write with both entries", got)
+	}
+
+	r.addLog("discarded before the final update", "INFO")
+	r.discardPendingLogs()
+	time.Sleep(2 * logFlushInterval)
+	if got := store.batchCount(); got != 1 {
+		t.Errorf("discarded entries were written: %d writes", got)
+	}
+}
+
+func TestKeepLeaseCancelsWhenTheLeaseIsLost(t *testing.T) {
+	interval := leaseRenewInterval
+	leaseRenewInterval = 10 * time.Millisecond
+	t.Cleanup(func() { leaseRenewInterval = interval })
+
+	wf := workflow.Workflow{ID: uuid.New(), UserID: "user-1", Name: "lease", Actions: []workflow.ActionDefinition{logAction("a")}}
+	r, store := newCountingRunner(t, wf)
+	r.execution.Status = "suspended"
+	if err := store.UpdateExecution(context.Background(), r.execution); err != nil {
+		t.Fatalf("UpdateExecution: %v", err)
+	}
+	execution, err := store.ClaimSuspendedExecution(context.Background(), r.execution.ID, "", "")
+	if err != nil {
+		t.Fatalf("ClaimSuspendedExecution: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := keepLease(ctx, cancel, store, execution.ID)
+	defer stop()
+
+	time.Sleep(5 * leaseRenewInterval)
+	if ctx.Err() != nil {
+		t.Fatal("lease was given up while the execution was still claimed")
+	}
+
+	// Otra réplica la termina (o la reclama): la siguiente renovación falla.
+	execution.Status = "completed"
+	if err := store.UpdateExecution(context.Background(), execution); err != nil {
+		t.Fatalf("UpdateExecution: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("runner context was not cancelled after losing the lease")
+	}
+}
@@ -0,0 +1,87 @@
+// services/task-orchestrator-service/internal/engine/http_action.go
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// executeHTTPEndpoint llama a una URL externa. La salida del paso contiene
+// el código de estado y el cuerpo de la respuesta (decodificado si es JSON).
+func (r *runner) executeHTTPEndpoint(action workflow.ActionDefinition) (interface{}, error) {
+	url, urlOk := action.Config["url"].(string)
+	if !urlOk || url == "" {
+		return nil, fmt.Errorf("action '%s': 'url' is required for http_endpoint", action.Name)
+	}
+
+	method, _ := action.Config["method"].(string)
+	if method == "" {
+		method = http.MethodGet
+	}
+	method = strings.ToUpper(method)
+
+	var reqBody io.Reader
+	if bodyData, exists := action.Config["body"]; exists && bodyData != nil {
+		if bodyStr, isStr := bodyData.(string); isStr {
+			reqBody = strings.NewReader(bodyStr)
+		} else {
+			jsonBody, err := json.Marshal(bodyData)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal 'body' to JSON for action '%s': %w", action.Name, err)
+			}
+			reqBody = bytes.NewBuffer(jsonBody)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(r.ctx, method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request for action '%s': %w", action.Name, err)
+	}
+
+	if headersData, exists := action.Config["headers"].(map[string]interface{}); exists {
+		for key, val := range headersData {
+			if valStr, isStr := val.(string); isStr {
+				req.Header.Set(key, valStr)
+			}
+		}
+	}
+	if reqBody != nil && req.Header.Get("Content-Type") == "" {
+		if _, isMap := action.Config["body"].(map[string]interface{}); isMap {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute HTTP request for action '%s': %w", action.Name, err)
+	}
+	defer resp.Body.Close()
+
+	respBodyBytes, _ := io.ReadAll(resp.Body)
+	r.addLog(fmt.Sprintf("HTTP Status: %s, Response Body: %.500s", resp.Status, string(respBodyBytes)), "ACTION_OUTPUT")
+
+	output := map[string]interface{}{
+		"status_code": resp.StatusCode,
+		"body":        decodeBody(respBodyBytes),
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return output, fmt.Errorf("HTTP request for action '%s' returned non-2xx status: %s", action.Name, resp.Status)
+	}
+	return output, nil
+}
+
+// decodeBody intenta interpretar el cuerpo como JSON; si no lo es, lo devuelve como texto.
+func decodeBody(body []byte) interface{} {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		return decoded
+	}
+	return string(body)
+}
@@ -0,0 +1,412 @@
+// services/task-orchestrator-service/internal/engine/mailer.go
+package engine
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/pkg/config"
+)
+
+const (
+	smtpDialTimeout = 30 * time.Second
+	smtpRetryBase   = 5 * time.Second
+	smtpRetryMax    = 5 * time.Minute
+	mailQueueSize   = 100
+)
+
+// emailAttachment es un adjunto ya resuelto a partir de la salida de un paso previo.
+type emailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// emailMessage es un correo listo para ser entregado al relay SMTP.
+type emailMessage struct {
+	From        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []emailAttachment
+}
+
+// recipients devuelve todos los destinatarios del sobre SMTP (incluye Bcc).
+func (m *emailMessage) recipients() []string {
+	all := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, rcpt := range list {
+			all = append(all, envelopeAddress(rcpt))
+		}
+	}
+	return all
+}
+
+// envelopeAddress extrae la dirección desnuda ("a@b.com") de "Nombre <a@b.com>".
+func envelopeAddress(addr string) string {
+	if parsed, err := mail.ParseAddress(addr); err == nil {
+		return parsed.Address
+	}
+	return addr
+}
+
+// mailJob es un mensaje en la cola de envío junto con su canal de resultado.
+type mailJob struct {
+	msg       *emailMessage
+	messageID string
+	data      []byte
+	attempts  int
+	done      chan error
+}
+
+// smtpMailer encola los mensajes y los entrega a través del relay configurado,
+// reintentando con backoff exponencial cuando el fallo es temporal (códigos 4xx,
+// errores de red).
+type smtpMailer struct {
+	host        string
+	port        string
+	username    string
+	password    string
+	startTLS    bool
+	maxAttempts int
+	retryBase   time.Duration // Espera antes del primer reintento; se duplica en cada uno
+	jobs        chan *mailJob
+	startOnce   sync.Once
+}
+
+var (
+	mailer     *smtpMailer
+	mailerOnce sync.Once
+)
+
+// getMailer crea el mailer a partir de la configuración la primera vez que se usa.
+func getMailer() *smtpMailer {
+	mailerOnce.Do(func() {
+		maxAttempts := config.AppConfig.SMTPMaxAttempts
+		if maxAttempts < 1 {
+			maxAttempts = 1
+		}
+		mailer = &smtpMailer{
+			host:        config.AppConfig.SMTPHost,
+			port:        config.AppConfig.SMTPPort,
+			username:    config.AppConfig.SMTPUsername,
+			password:    config.AppConfig.SMTPPassword,
+			startTLS:    config.AppConfig.SMTPStartTLS,
+			maxAttempts: maxAttempts,
+			retryBase:   smtpRetryBase,
+			jobs:        make(chan *mailJob, mailQueueSize),
+		}
+	})
+	return mailer
+}
+
+// Send encola el mensaje y espera a que se entregue o falle definitivamente.
+// Devuelve el Message-ID asignado.
+func (m *smtpMailer) Send(ctx context.Context, msg *emailMessage) (string, error) {
+	if m.host == "" {
+		return "", errors.New("SMTP relay is not configured (SMTP_HOST is empty)")
+	}
+	m.startOnce.Do(func() { go m.worker() })
+
+	messageID := newMessageID(msg.From)
+	data, err := buildMIMEMessage(msg, messageID)
+	if err != nil {
+		return "", fmt.Errorf("failed to build email message: %w", err)
+	}
+
+	job := &mailJob{msg: msg, messageID: messageID, data: data, done: make(chan error, 1)}
+	select {
+	case m.jobs <- job:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	select {
+	case err := <-job.done:
+		return messageID, err
+	case <-ctx.Done():
+		return messageID, ctx.Err()
+	}
+}
+
+// worker procesa la cola. Los fallos temporales se vuelven a encolar tras una espera
+// sin bloquear al resto de mensajes.
+func (m *smtpMailer) worker() {
+	for job := range m.jobs {
+		job.attempts++
+		err := m.deliver(job)
+		if err == nil {
+			job.done <- nil
+			continue
+		}
+		if isTemporarySMTPError(err) && job.attempts < m.maxAttempts {
+			delay := retryDelay(m.retryBase, job.attempts)
+			log.Printf("MAILER: Temporary failure delivering %s (attempt %d/%d): %v. Retrying in %s", job.messageID, job.attempts, m.maxAttempts, err, delay)
+			retry, lastErr := job, err
+			time.AfterFunc(delay, func() { m.requeue(retry, lastErr) })
+			continue
+		}
+		job.done <- fmt.Errorf("delivery failed after %d attempt(s): %w", job.attempts, err)
+	}
+}
+
+// requeue vuelve a poner en cola un mensaje tras un fallo temporal. Si la cola
+// está llena no espera (bloquearía el timer indefinidamente): el envío falla con
+// el último error.
+func (m *smtpMailer) requeue(job *mailJob, lastErr error) {
+	select {
+	case m.jobs <- job:
+	default:
+		job.done <- fmt.Errorf("delivery failed after %d attempt(s), mail queue is full: %w", job.attempts, lastErr)
+	}
+}
+
+// deliver abre una conexión con el relay y entrega un único mensaje.
+func (m *smtpMailer) deliver(job *mailJob) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(m.host, m.port), smtpDialTimeout)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if m.startTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP relay does not support STARTTLS")
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("SMTP relay does not support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(envelopeAddress(job.msg.From)); err != nil {
+		return err
+	}
+	for _, rcpt := range job.msg.recipients() {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(job.data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	// El relay ya ha aceptado el mensaje: un fallo en QUIT no debe provocar un
+	// reintento que lo entregue dos veces.
+	if err := c.Quit(); err != nil {
+		log.Printf("MAILER: QUIT failed after delivering %s: %v", job.messageID, err)
+	}
+	return nil
+}
+
+// isTemporarySMTPError distingue los fallos que merecen reintento (4xx, red)
+// de los permanentes (5xx, configuración).
+func isTemporarySMTPError(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	delay := base << (attempt - 1)
+	if delay > smtpRetryMax || delay <= 0 {
+		return smtpRetryMax
+	}
+	return delay
+}
+
+// newMessageID genera un Message-ID usando el dominio del remitente.
+func newMessageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+	return fmt.Sprintf("<%s@%s>", uuid.New().String(), domain)
+}
+
+// buildMIMEMessage construye el mensaje completo: texto y/o HTML como
+// multipart/alternative y, si hay adjuntos, todo envuelto en multipart/mixed.
+func buildMIMEMessage(msg *emailMessage, messageID string) ([]byte, error) {
+	var buf bytes.Buffer
+	writeHeader := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+
+	writeHeader("From", msg.From)
+	if len(msg.To) > 0 {
+		writeHeader("To", strings.Join(msg.To, ", "))
+	}
+	if len(msg.Cc) > 0 {
+		writeHeader("Cc", strings.Join(msg.Cc, ", "))
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader("Date", time.Now().UTC().Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageID)
+	writeHeader("MIME-Version", "1.0")
+
+	if len(msg.Attachments) == 0 {
+		if err := writeBody(&buf, msg); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	writeHeader("Content-Type", fmt.Sprintf("multipart/mixed; boundary=%q", mixed.Boundary()))
+	buf.WriteString("\r\n")
+
+	bodyHeader, bodyContent, err := bodyPart(msg)
+	if err != nil {
+		return nil, err
+	}
+	part, err := mixed.CreatePart(bodyHeader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(bodyContent); err != nil {
+		return nil, err
+	}
+
+	for _, att := range msg.Attachments {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", att.ContentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": att.Filename}))
+		part, err := mixed.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64Lines(part, att.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBody escribe el cuerpo del mensaje directamente tras las cabeceras principales.
+func writeBody(buf *bytes.Buffer, msg *emailMessage) error {
+	header, content, err := bodyPart(msg)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		for _, v := range values {
+			fmt.Fprintf(buf, "%s: %s\r\n", key, v)
+		}
+	}
+	buf.WriteString("\r\n")
+	buf.Write(content)
+	return nil
+}
+
+// bodyPart devuelve las cabeceras y el contenido de la parte de cuerpo (texto, HTML o ambos).
+func bodyPart(msg *emailMessage) (textproto.MIMEHeader, []byte, error) {
+	if msg.Text != "" && msg.HTML != "" {
+		var buf bytes.Buffer
+		alt := multipart.NewWriter(&buf)
+		for _, p := range []struct{ contentType, content string }{
+			{"text/plain; charset=utf-8", msg.Text},
+			{"text/html; charset=utf-8", msg.HTML},
+		} {
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Type", p.contentType)
+			header.Set("Content-Transfer-Encoding", "quoted-printable")
+			part, err := alt.CreatePart(header)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := writeQuotedPrintable(part, p.content); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := alt.Close(); err != nil {
+			return nil, nil, err
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", alt.Boundary()))
+		return header, buf.Bytes(), nil
+	}
+
+	contentType, content := "text/plain; charset=utf-8", msg.Text
+	if msg.HTML != "" {
+		contentType, content = "text/html; charset=utf-8", msg.HTML
+	}
+	var buf bytes.Buffer
+	if err := writeQuotedPrintable(&buf, content); err != nil {
+		return nil, nil, err
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return header, buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64Lines codifica en base64 partiendo en líneas de 76 caracteres (RFC 2045).
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
@@ -0,0 +1,279 @@
+// services/task-orchestrator-service/internal/engine/mailer_test.go
+package engine
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpSink es un servidor SMTP mínimo en proceso que guarda lo que recibe.
+// dataReplies fija la respuesta al final de cada DATA, en orden; cuando se
+// agota responde 250. Con dropOnQuit cierra la conexión al recibir QUIT sin responder.
+type smtpSink struct {
+	ln          net.Listener
+	mu          sync.Mutex
+	dataReplies []string
+	rcptReply   string
+	dropOnQuit  bool
+	messages    []sinkMessage
+}
+
+type sinkMessage struct {
+	from       string
+	recipients []string
+	data       string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	sink := &smtpSink{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	return sink
+}
+
+func (s *smtpSink) mailer() *smtpMailer {
+	_, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	return &smtpMailer{
+		host:        "127.0.0.1",
+		port:        port,
+		maxAttempts: 3,
+		retryBase:   10 * time.Millisecond,
+		jobs:        make(chan *mailJob, mailQueueSize),
+	}
+}
+
+func (s *smtpSink) received() []sinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sinkMessage(nil), s.messages...)
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 sink ESMTP")
+	var current sinkMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-sink")
+			tp.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			current = sinkMessage{from: line[len("MAIL FROM:"):]}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			reply := s.rcptReply
+			s.mu.Unlock()
+			if reply != "" {
+				tp.PrintfLine("%s", reply)
+				continue
+			}
+			current.recipients = append(current.recipients, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			current.data = string(data)
+			s.mu.Lock()
+			reply := "250 OK"
+			if len(s.dataReplies) > 0 {
+				reply, s.dataReplies = s.dataReplies[0], s.dataReplies[1:]
+			}
+			if strings.HasPrefix(reply, "250") {
+				s.messages = append(s.messages, current)
+			}
+			s.mu.Unlock()
+			tp.PrintfLine("%s", reply)
+		case "QUIT":
+			if !s.dropOnQuit {
+				tp.PrintfLine("221 bye")
+			}
+			return
+		default:
+			tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+func sendTestEmail(t *testing.T, m *smtpMailer, msg *emailMessage) (string, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return m.Send(ctx, msg)
+}
+
+func TestMailerMIMEStructure(t *testing.T) {
+	sink := newSMTPSink(t)
+	msg := &emailMessage{
+		From:    "Orchestrator <bot@example.com>",
+		To:      []string{"Ana <ana@example.com>"},
+		Cc:      []string{"cc@example.com"},
+		Bcc:     []string{"hidden@example.com"},
+		Subject: "Informe diario",
+		Text:    "Hola en texto",
+		HTML:    "<p>Hola en HTML</p>",
+		Attachments: []emailAttachment{
+			{Filename: "report.json", ContentType: "application/json", Data: []byte(`{"rows": 3}`)},
+		},
+	}
+	messageID, err := sendTestEmail(t, sink.mailer(), msg)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := sink.received()
+	if len(got) != 1 {
+		t.Fatalf("sink received %d messages, want 1", len(got))
+	}
+	envelope := strings.Join(got[0].recipients, ",")
+	if envelope != "ana@example.com,cc@example.com,hidden@example.com" {
+		t.Errorf("envelope recipients = %s", envelope)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(got[0].data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if parsed.Header.Get("Bcc") != "" || strings.Contains(got[0].data, "hidden@example.com") {
+		t.Errorf("Bcc recipient leaked into the message headers")
+	}
+	if parsed.Header.Get("Message-ID") != messageID {
+		t.Errorf("Message-ID = %q, want %q", parsed.Header.Get("Message-ID"), messageID)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q (%v), want multipart/mixed", parsed.Header.Get("Content-Type"), err)
+	}
+	mixed := multipart.NewReader(parsed.Body, params["boundary"])
+
+	body, err := mixed.NextPart()
+	if err != nil {
+		t.Fatalf("body part: %v", err)
+	}
+	mediaType, params, _ = mime.ParseMediaType(body.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("body Content-Type = %q, want multipart/alternative", mediaType)
+	}
+	alt := multipart.NewReader(body, params["boundary"])
+	for _, want := range []struct{ contentType, content string }{
+		{"text/plain", "Hola en texto"},
+		{"text/html", "<p>Hola en HTML</p>"},
+	} {
+		part, err := alt.NextPart() // Decodifica quoted-printable
+		if err != nil {
+			t.Fatalf("alternative part %s: %v", want.contentType, err)
+		}
+		if mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type")); mediaType != want.contentType {
+			t.Errorf("alternative part Content-Type = %q, want %q", mediaType, want.contentType)
+		}
+		if content, _ := io.ReadAll(part); string(content) != want.content {
+			t.Errorf("%s content = %q, want %q", want.contentType, content, want.content)
+		}
+	}
+
+	attachment, err := mixed.NextPart()
+	if err != nil {
+		t.Fatalf("attachment part: %v", err)
+	}
+	if attachment.FileName() != "report.json" || attachment.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("attachment = %q (%s)", attachment.FileName(), attachment.Header.Get("Content-Type"))
+	}
+	encoded, _ := io.ReadAll(attachment)
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil || string(decoded) != `{"rows": 3}` {
+		t.Errorf("attachment content = %q (%v)", decoded, err)
+	}
+	if _, err := mixed.NextPart(); err != io.EOF {
+		t.Errorf("expected exactly two parts in multipart/mixed, got err=%v", err)
+	}
+}
+
+func TestMailerRetriesTemporaryFailure(t *testing.T) {
+	sink := newSMTPSink(t)
+	sink.dataReplies = []string{"451 4.3.0 try again later"}
+
+	msg := &emailMessage{From: "bot@example.com", To: []string{"ana@example.com"}, Subject: "Retry", Text: "x"}
+	if _, err := sendTestEmail(t, sink.mailer(), msg); err != nil {
+		t.Fatalf("Send after a 4xx reply: %v", err)
+	}
+	if got := len(sink.received()); got != 1 {
+		t.Errorf("sink accepted %d messages, want 1", got)
+	}
+}
+
+func TestMailerDoesNotResendWhenQuitFails(t *testing.T) {
+	sink := newSMTPSink(t)
+	sink.dropOnQuit = true
+
+	msg := &emailMessage{From: "bot@example.com", To: []string{"ana@example.com"}, Subject: "Once", Text: "x"}
+	if _, err := sendTestEmail(t, sink.mailer(), msg); err != nil {
+		t.Fatalf("Send after the relay accepted DATA: %v", err)
+	}
+	if got := len(sink.received()); got != 1 {
+		t.Errorf("sink accepted %d messages, want 1", got)
+	}
+}
+
+func TestMailerPermanentFailureFailsStep(t *testing.T) {
+	sink := newSMTPSink(t)
+	sink.rcptReply = "550 5.1.1 no such user"
+
+	msg := &emailMessage{From: "bot@example.com", To: []string{"nobody@example.com"}, Subject: "Fail", Text: "x"}
+	_, err := sendTestEmail(t, sink.mailer(), msg)
+	var protoErr *textproto.Error
+	if !errors.As(err, &protoErr) || protoErr.Code != 550 {
+		t.Fatalf("Send error = %v, want a 550 reply", err)
+	}
+	if !strings.Contains(err.Error(), "after 1 attempt(s)") {
+		t.Errorf("a 5xx reply must not be retried: %v", err)
+	}
+}
+
+func TestMailerRequeueWithFullQueueFails(t *testing.T) {
+	m := &smtpMailer{jobs: make(chan *mailJob)} // Sin hueco: cualquier envío bloquearía
+	job := &mailJob{attempts: 2, done: make(chan error, 1)}
+
+	m.requeue(job, errors.New("451 busy"))
+	select {
+	case err := <-job.done:
+		if err == nil || !strings.Contains(err.Error(), "queue is full") {
+			t.Errorf("requeue error = %v", err)
+		}
+	default:
+		t.Fatal("requeue blocked or dropped the job instead of failing it")
+	}
+}
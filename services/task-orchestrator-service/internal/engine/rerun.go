@@ -0,0 +1,114 @@
+// services/task-orchestrator-service/internal/engine/rerun.go
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+const (
+	sourceRerun  = "rerun"
+	sourceResume = "resume"
+)
+
+var (
+	ErrNoExecutionState     = errors.New("execution has no stored inputs to replay")
+	ErrExecutionNotFailed   = errors.New("only failed executions can be resumed")
+	ErrExecutionCompensated = errors.New("execution was compensated; re-run it instead")
+	ErrNothingToResume      = errors.New("execution has no failed step to resume from")
+)
+
+// RerunExecution lanza una ejecución nueva del workflow (en su definición actual)
+// con las mismas entradas y el mismo payload de disparo que la original.
+// Devuelve el registro nuevo; la ejecución continúa en segundo plano. ctx solo se
+// usa para crear el registro.
+func RerunExecution(ctx context.Context, original *workflow.ExecutionLog, wf workflow.Workflow, store workflow.Store) (*workflow.ExecutionLog, error) {
+	state, err := storedState(original)
+	if err != nil {
+		return nil, err
+	}
+	payload, _ := state.Trigger["payload"].(map[string]interface{})
+
+	r := newRunner(wf, RunInput{Source: sourceRerun, Inputs: state.Inputs, Payload: payload, ParentExecutionID: &original.ID})
+	r.addLog(fmt.Sprintf("Re-run of execution %s", original.ID), "INFO")
+	return r.start(ctx, store)
+}
+
+// ResumeFailedExecution lanza una ejecución nueva que parte del primer paso que no
+// terminó bien en la original. Los pasos anteriores no se repiten: sus resultados
+// y salidas se copian (marcados como reused) para que los siguientes pasos los vean.
+// Usa la definición del workflow con la que corrió la original.
+func ResumeFailedExecution(ctx context.Context, original *workflow.ExecutionLog, store workflow.Store) (*workflow.ExecutionLog, error) {
+	if original.Status != "failed" {
+		return nil, ErrExecutionNotFailed
+	}
+	state, err := storedState(original)
+	if err != nil {
+		return nil, err
+	}
+	var steps []StepResult
+	if len(original.Steps) > 0 {
+		if err := json.Unmarshal(original.Steps, &steps); err != nil {
+			return nil, fmt.Errorf("invalid execution steps: %w", err)
+		}
+	}
+	for _, step := range steps {
+		if strings.HasPrefix(step.Name, "compensate:") && step.Status == "completed" {
+			return nil, ErrExecutionCompensated
+		}
+	}
+
+	actions := state.Workflow.Actions
+	from := 0
+	for from < len(actions) && from < len(steps) &&
+		steps[from].Name == actions[from].Name && steps[from].Status == "completed" {
+		from++
+	}
+	if from >= len(actions) {
+		return nil, ErrNothingToResume
+	}
+
+	r := newRunner(state.Workflow, RunInput{Source: sourceResume, Inputs: state.Inputs, ParentExecutionID: &original.ID})
+	if state.Trigger != nil {
+		r.trigger = state.Trigger
+	}
+	for _, step := range steps[:from] {
+		step.Reused = true
+		r.steps = append(r.steps, step)
+		if output, ok := state.Outputs[step.Name]; ok {
+			r.outputs[step.Name] = output
+		}
+	}
+	r.next = from
+	r.addLog(fmt.Sprintf("Resuming failed execution %s from action '%s'; reusing %d completed step(s)", original.ID, actions[from].Name, from), "INFO")
+	return r.start(ctx, store)
+}
+
+// start crea el registro de la ejecución y la ejecuta en segundo plano.
+func (r *runner) start(ctx context.Context, store workflow.Store) (*workflow.ExecutionLog, error) {
+	if err := r.create(ctx, store); err != nil {
+		return nil, fmt.Errorf("failed to create execution record: %w", err)
+	}
+	log.Printf("ENGINE: >>> Starting %s execution %s for Workflow ID %s <<<", r.execution.TriggerSource, r.execution.ID, r.wf.ID)
+	created := *r.execution
+	go r.run(store, true)
+	return &created, nil
+}
+
+// storedState lee las entradas y el disparo guardados de una ejecución.
+func storedState(execution *workflow.ExecutionLog) (*executionState, error) {
+	if len(execution.State) == 0 {
+		return nil, ErrNoExecutionState
+	}
+	var state executionState
+	if err := json.Unmarshal(execution.State, &state); err != nil {
+		return nil, fmt.Errorf("invalid execution state: %w", err)
+	}
+	return &state, nil
+}
@@ -0,0 +1,184 @@
+// services/task-orchestrator-service/internal/engine/rerun_test.go
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// runToEnd ejecuta wf hasta el final con input y devuelve el registro guardado.
+func runToEnd(t *testing.T, store workflow.Store, wf workflow.Workflow, input RunInput) *workflow.ExecutionLog {
+	t.Helper()
+	r := newRunner(wf, input)
+	if err := r.create(context.Background(), store); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	r.run(store, true)
+	stored, err := store.GetExecutionByID(context.Background(), wf.UserID, r.execution.ID)
+	if err != nil {
+		t.Fatalf("GetExecutionByID: %v", err)
+	}
+	return stored
+}
+
+// waitForExecution espera a que la ejecución lanzada en segundo plano termine.
+func waitForExecution(t *testing.T, store workflow.Store, userID string, id uuid.UUID) *workflow.ExecutionLog {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stored, err := store.GetExecutionByID(context.Background(), userID, id)
+		if err != nil {
+			t.Fatalf("GetExecutionByID: %v", err)
+		}
+		if stored.Status != "running" || time.Now().After(deadline) {
+			return stored
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func executionSteps(t *testing.T, execution *workflow.ExecutionLog) []StepResult {
+	t.Helper()
+	var steps []StepResult
+	if err := json.Unmarshal(execution.Steps, &steps); err != nil {
+		t.Fatalf("steps: %v", err)
+	}
+	return steps
+}
+
+func httpAction(name, url string) workflow.ActionDefinition {
+	return workflow.ActionDefinition{Name: name, Type: workflow.ActionTypeHTTPEndpoint, Config: map[string]interface{}{"url": url}}
+}
+
+// failedExecution ejecuta fetch → charge → order con charge fallando la primera
+// vez. Devuelve la ejecución fallida y cuántas veces se llamó a fetch.
+func failedExecution(t *testing.T, store workflow.Store) (*workflow.ExecutionLog, *atomic.Int32) {
+	t.Helper()
+	var fetches, charges atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/fetch":
+			fetches.Add(1)
+			w.Write([]byte(`{"id": 7}`))
+		case "/charge":
+			if charges.Add(1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"charged": true}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	wf := workflow.Workflow{ID: uuid.New(), UserID: "user-1", Name: "checkout", Actions: []workflow.ActionDefinition{
+		httpAction("fetch", server.URL+"/fetch"),
+		httpAction("charge", server.URL+"/charge"),
+		{Name: "order", Type: workflow.ActionTypeTransform, Config: map[string]interface{}{"from_step": "fetch", "expression": ".body.id"}},
+	}}
+	if err := store.SaveWorkflow(context.Background(), &wf); err != nil {
+		t.Fatalf("SaveWorkflow: %v", err)
+	}
+	original := runToEnd(t, store, wf, RunInput{Source: "manual"})
+	if original.Status != "failed" {
+		t.Fatalf("original status = %q, want failed", original.Status)
+	}
+	return original, &fetches
+}
+
+func TestResumeFailedExecutionReusesCompletedSteps(t *testing.T) {
+	store := workflow.NewInMemoryWorkflowStore()
+	original, fetches := failedExecution(t, store)
+
+	created, err := ResumeFailedExecution(context.Background(), original, store)
+	if err != nil {
+		t.Fatalf("ResumeFailedExecution: %v", err)
+	}
+	if created.TriggerSource != sourceResume || created.ParentExecutionID == nil || *created.ParentExecutionID != original.ID {
+		t.Errorf("created source %q, parent %v; want resume of %s", created.TriggerSource, created.ParentExecutionID, original.ID)
+	}
+	resumed := waitForExecution(t, store, original.UserID, created.ID)
+	if resumed.Status != "completed" {
+		t.Fatalf("resumed status = %q, want completed", resumed.Status)
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("fetch was called %d times, want it reused instead of repeated", got)
+	}
+
+	steps := executionSteps(t, resumed)
+	if len(steps) != 3 {
+		t.Fatalf("got %d steps, want 3", len(steps))
+	}
+	if steps[0].Name != "fetch" || !steps[0].Reused || steps[0].Status != "completed" {
+		t.Errorf("step 0 = %+v, want fetch reused", steps[0])
+	}
+	for _, step := range steps[1:] {
+		if step.Reused || step.Status != "completed" {
+			t.Errorf("step %s = %+v, want it run again", step.Name, step)
+		}
+	}
+	// La salida reutilizada sigue disponible para los pasos siguientes.
+	if steps[2].Output != float64(7) {
+		t.Errorf("order output = %v, want the id from the reused fetch output", steps[2].Output)
+	}
+}
+
+func TestResumeFailedExecutionRefusesCompensatedRuns(t *testing.T) {
+	store := workflow.NewInMemoryWorkflowStore()
+	original, _ := failedExecution(t, store)
+
+	steps := append(executionSteps(t, original), StepResult{Name: "compensate:fetch", Status: "completed"})
+	original.Steps, _ = json.Marshal(steps)
+	if _, err := ResumeFailedExecution(context.Background(), original, store); !errors.Is(err, ErrExecutionCompensated) {
+		t.Errorf("err = %v, want ErrExecutionCompensated", err)
+	}
+}
+
+func TestResumeFailedExecutionRefusesRunsThatDidNotFail(t *testing.T) {
+	store := workflow.NewInMemoryWorkflowStore()
+	wf := workflow.Workflow{ID: uuid.New(), UserID: "user-1", Name: "ok", Actions: []workflow.ActionDefinition{logAction("a")}}
+	if err := store.SaveWorkflow(context.Background(), &wf); err != nil {
+		t.Fatalf("SaveWorkflow: %v", err)
+	}
+	original := runToEnd(t, store, wf, RunInput{Source: "manual"})
+
+	if _, err := ResumeFailedExecution(context.Background(), original, store); !errors.Is(err, ErrExecutionNotFailed) {
+		t.Errorf("err = %v, want ErrExecutionNotFailed", err)
+	}
+}
+
+func TestRerunExecutionCarriesOverInputsAndPayload(t *testing.T) {
+	store := workflow.NewInMemoryWorkflowStore()
+	wf := workflow.Workflow{ID: uuid.New(), UserID: "user-1", Name: "rerun", Actions: []workflow.ActionDefinition{
+		{Name: "greet", Type: workflow.ActionTypeTransform, Config: map[string]interface{}{"expression": `.inputs.name + " " + .trigger.payload.order`}},
+	}}
+	if err := store.SaveWorkflow(context.Background(), &wf); err != nil {
+		t.Fatalf("SaveWorkflow: %v", err)
+	}
+	original := runToEnd(t, store, wf, RunInput{Source: "webhook",
+		Inputs: map[string]interface{}{"name": "ada"}, Payload: map[string]interface{}{"order": "A-1"}})
+
+	created, err := RerunExecution(context.Background(), original, wf, store)
+	if err != nil {
+		t.Fatalf("RerunExecution: %v", err)
+	}
+	if created.TriggerSource != sourceRerun || created.ParentExecutionID == nil || *created.ParentExecutionID != original.ID {
+		t.Errorf("created source %q, parent %v; want rerun of %s", created.TriggerSource, created.ParentExecutionID, original.ID)
+	}
+	rerun := waitForExecution(t, store, wf.UserID, created.ID)
+	if rerun.Status != "completed" {
+		t.Fatalf("rerun status = %q, want completed", rerun.Status)
+	}
+	steps := executionSteps(t, rerun)
+	if steps[0].Output != "ada A-1" {
+		t.Errorf("greet output = %v, want the original inputs and payload", steps[0].Output)
+	}
+}
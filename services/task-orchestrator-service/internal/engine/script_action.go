@@ -0,0 +1,167 @@
+// services/task-orchestrator-service/internal/engine/script_action.go
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/config"
+	starlarkmath "go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkjson"
+	"go.starlark.net/syntax"
+)
+
+const (
+	scriptDefaultTimeout  = 5 * time.Second
+	scriptDefaultMaxSteps = 10_000_000
+	scriptDefaultMemoryMB = 64
+	scriptMaxSourceBytes  = 64 * 1024
+	scriptMaxOutputBytes  = 1024 * 1024
+
+	// Máximos si la configuración del servidor no los fija
+	// (SCRIPT_MAX_TIMEOUT_SECONDS, SCRIPT_MAX_STEPS, SCRIPT_MAX_MEMORY_MB).
+	scriptFallbackMaxTimeoutSeconds = 30
+	scriptFallbackMaxSteps          = 100_000_000
+	scriptFallbackMaxMemoryMB       = 256
+)
+
+// executeScript ejecuta un script Starlark en un entorno aislado: no hay acceso
+// a ficheros, red ni reloj, solo a los módulos json y math. El script recibe
+// `inputs`, `trigger`, `steps`, `workflow` y `error` (None salvo en compensaciones
+// y on_failure) y debe asignar su resultado a `output`. Corre en un proceso hijo
+// (runScriptProcess) para que su memoria tenga un límite propio.
+// Config: {"source": "...", "timeout_seconds": 5, "max_steps": 10000000,
+// "max_memory_mb": 64}; los límites se acotan a los máximos del servidor (scriptLimits).
+func (r *runner) executeScript(action workflow.ActionDefinition) (interface{}, error) {
+	if language, _ := action.Config["language"].(string); language != "" && language != "starlark" {
+		return nil, fmt.Errorf("action '%s': unsupported script language '%s'", action.Name, language)
+	}
+	source, _ := action.Config["source"].(string)
+	if source == "" {
+		return nil, fmt.Errorf("action '%s': 'source' is required for script", action.Name)
+	}
+	if len(source) > scriptMaxSourceBytes {
+		return nil, fmt.Errorf("action '%s': script exceeds %d bytes", action.Name, scriptMaxSourceBytes)
+	}
+
+	maxTimeout, maxSteps, maxMemoryMB := scriptLimits()
+	job := scriptJob{
+		Name:           action.Name,
+		Source:         source,
+		TimeoutSeconds: min(configInt(action.Config, "timeout_seconds", int(scriptDefaultTimeout/time.Second)), maxTimeout),
+		MaxSteps:       uint64(min(configInt(action.Config, "max_steps", scriptDefaultMaxSteps), maxSteps)),
+		MaxMemoryMB:    min(configInt(action.Config, "max_memory_mb", scriptDefaultMemoryMB), maxMemoryMB),
+		Globals: map[string]interface{}{
+			"inputs":   r.inputs,
+			"trigger":  r.trigger,
+			"steps":    r.outputs,
+			"workflow": r.templateData()["Workflow"],
+			"error":    r.failure,
+		},
+	}
+	output, err := runScriptProcess(r.ctx, job, func(msg string) {
+		r.addLog(fmt.Sprintf("[script %s] %s", action.Name, msg), "ACTION_OUTPUT")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': %w", action.Name, err)
+	}
+	return output, nil
+}
+
+// runStarlark interpreta job en el proceso actual. Lo llama el proceso hijo
+// (RunScriptProcess), que ya tiene fijado el límite de memoria.
+func runStarlark(job scriptJob, print func(string)) (interface{}, error) {
+	thread := &starlark.Thread{
+		Name:  "script:" + job.Name,
+		Print: func(_ *starlark.Thread, msg string) { print(msg) },
+		// Sin Load: el script no puede importar otros módulos ni ficheros.
+	}
+	thread.SetMaxExecutionSteps(job.MaxSteps)
+
+	predeclared := starlark.StringDict{
+		"json": starlarkjson.Module,
+		"math": starlarkmath.Module,
+	}
+	for name, value := range job.Globals {
+		sv, err := toStarlark(thread, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expose '%s' to script: %w", name, err)
+		}
+		sv.Freeze()
+		predeclared[name] = sv
+	}
+
+	timeout := time.Duration(job.TimeoutSeconds) * time.Second
+	deadline := time.AfterFunc(timeout, func() { thread.Cancel(fmt.Sprintf("timeout after %s", timeout)) })
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, job.Name+".star", job.Source, predeclared)
+	deadline.Stop()
+	if err != nil {
+		if evalErr, ok := err.(*starlark.EvalError); ok {
+			return nil, fmt.Errorf("script failed: %s", evalErr.Backtrace())
+		}
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+
+	result, ok := globals["output"]
+	if !ok {
+		return nil, fmt.Errorf("script must assign its result to 'output'")
+	}
+	output, err := fromStarlark(thread, result)
+	if err != nil {
+		return nil, fmt.Errorf("'output' is not JSON-serializable: %w", err)
+	}
+	return output, nil
+}
+
+// scriptLimits devuelve los valores máximos de timeout_seconds, max_steps y
+// max_memory_mb que admite el servidor.
+func scriptLimits() (maxTimeoutSeconds, maxSteps, maxMemoryMB int) {
+	maxTimeoutSeconds, maxSteps, maxMemoryMB = config.AppConfig.ScriptMaxTimeoutSeconds, config.AppConfig.ScriptMaxSteps, config.AppConfig.ScriptMaxMemoryMB
+	if maxTimeoutSeconds <= 0 {
+		maxTimeoutSeconds = scriptFallbackMaxTimeoutSeconds
+	}
+	if maxSteps <= 0 {
+		maxSteps = scriptFallbackMaxSteps
+	}
+	if maxMemoryMB <= 0 {
+		maxMemoryMB = scriptFallbackMaxMemoryMB
+	}
+	return maxTimeoutSeconds, maxSteps, maxMemoryMB
+}
+
+// validateScriptConfig comprueba al guardar que los límites pedidos son enteros
+// positivos dentro de los máximos del servidor.
+func validateScriptConfig(action workflow.ActionDefinition) error {
+	maxTimeout, maxSteps, maxMemoryMB := scriptLimits()
+	return validateIntLimits(action.Config,
+		configLimit{"timeout_seconds", maxTimeout}, configLimit{"max_steps", maxSteps}, configLimit{"max_memory_mb", maxMemoryMB})
+}
+
+// toStarlark convierte un valor Go (compatible con JSON) a Starlark pasando por json.decode.
+func toStarlark(thread *starlark.Thread, value interface{}) (starlark.Value, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Call(thread, starlarkjson.Module.Members["decode"], starlark.Tuple{starlark.String(encoded)}, nil)
+}
+
+// fromStarlark convierte el resultado del script a un valor Go mediante json.encode.
+func fromStarlark(thread *starlark.Thread, value starlark.Value) (interface{}, error) {
+	encoded, err := starlark.Call(thread, starlarkjson.Module.Members["encode"], starlark.Tuple{value}, nil)
+	if err != nil {
+		return nil, err
+	}
+	text, _ := starlark.AsString(encoded)
+	if len(text) > scriptMaxOutputBytes {
+		return nil, fmt.Errorf("output exceeds %d bytes", scriptMaxOutputBytes)
+	}
+	var output interface{}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
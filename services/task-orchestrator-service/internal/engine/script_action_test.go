@@ -0,0 +1,159 @@
+// services/task-orchestrator-service/internal/engine/script_action_test.go
+package engine
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// TestMain hace de proceso hijo de los scripts: runScriptProcess relanza el
+// binario de test con ScriptRunnerCommand, igual que hace el servidor.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == ScriptRunnerCommand {
+		os.Exit(RunScriptProcess(os.Stdin, os.Stdout))
+	}
+	os.Exit(m.Run())
+}
+
+func runTestScript(t *testing.T, source string, config map[string]interface{}) (interface{}, error) {
+	t.Helper()
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	config["source"] = source
+	r := newRunner(workflow.Workflow{Name: "script-test"}, RunInput{Source: "manual"})
+	return r.executeScript(workflow.ActionDefinition{Name: "s", Type: workflow.ActionTypeScript, Config: config})
+}
+
+func TestScriptOutput(t *testing.T) {
+	output, err := runTestScript(t, `output = {"sum": len([1, 2, 3]) + 3, "root": math.sqrt(16)}`, nil)
+	if err != nil {
+		t.Fatalf("script failed: %v", err)
+	}
+	got := output.(map[string]interface{})
+	if got["sum"] != float64(6) || got["root"] != float64(4) {
+		t.Errorf("output = %v", got)
+	}
+}
+
+func TestScriptInfiniteLoopHitsTimeout(t *testing.T) {
+	started := time.Now()
+	_, err := runTestScript(t, `
+def spin():
+    # Cada vuelta ordena una lista grande: pocos pasos, mucho tiempo.
+    big = list(range(200000))
+    n = 0
+    for _ in range(1 << 62):
+        n += len(sorted(big, reverse = True))
+    return n
+output = spin()
+`, map[string]interface{}{"timeout_seconds": float64(1), "max_steps": float64(scriptFallbackMaxSteps)})
+	if err == nil || !strings.Contains(err.Error(), "timeout after 1s") {
+		t.Fatalf("error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("script ran for %s after a 1s timeout", elapsed)
+	}
+}
+
+func TestScriptStepLimitAborts(t *testing.T) {
+	_, err := runTestScript(t, `
+def spin():
+    for _ in range(1 << 62):
+        pass
+spin()
+output = 1
+`, map[string]interface{}{"max_steps": float64(1000)})
+	if err == nil || !strings.Contains(err.Error(), "too many steps") {
+		t.Fatalf("error = %v, want the step limit to abort the script", err)
+	}
+}
+
+func TestScriptLimitsAreClampedToServerMaximum(t *testing.T) {
+	// Una configuración guardada antes de existir los máximos no puede saltárselos.
+	_, err := runTestScript(t, `
+def spin():
+    for _ in range(1 << 62):
+        pass
+spin()
+output = 1
+`, map[string]interface{}{"max_steps": float64(1 << 50), "timeout_seconds": float64(3600)})
+	if err == nil || !strings.Contains(err.Error(), "too many steps") {
+		t.Fatalf("error = %v, want max_steps to be clamped to the server maximum", err)
+	}
+}
+
+func TestScriptMemoryLimitKillsOnlyTheScript(t *testing.T) {
+	// Pocos pasos pero 512 MB de una sola reserva: solo lo para el límite de memoria.
+	_, err := runTestScript(t, `output = len("a" * (1 << 29))`, map[string]interface{}{"max_memory_mb": float64(32)})
+	if err == nil || !strings.Contains(err.Error(), "memory limit of 32 MB exceeded") {
+		t.Fatalf("error = %v, want the memory limit to stop the script", err)
+	}
+
+	output, err := runTestScript(t, `output = len("a" * (1 << 20))`, map[string]interface{}{"max_memory_mb": float64(32)})
+	if err != nil || output != float64(1<<20) {
+		t.Fatalf("script within the limit: output = %v, error = %v", output, err)
+	}
+}
+
+func TestScriptPrintIsLogged(t *testing.T) {
+	r := newRunner(workflow.Workflow{Name: "script-test"}, RunInput{Source: "manual"})
+	_, err := r.executeScript(workflow.ActionDefinition{Name: "s", Type: workflow.ActionTypeScript,
+		Config: map[string]interface{}{"source": "print('hello', inputs)\noutput = 1"}})
+	if err != nil {
+		t.Fatalf("script failed: %v", err)
+	}
+	if last := r.logs[len(r.logs)-1]; last.Message != "[script s] hello {}" || last.Status != "ACTION_OUTPUT" {
+		t.Errorf("logs = %+v, want the script's print", r.logs)
+	}
+}
+
+func TestScriptHasNoLoadOrIO(t *testing.T) {
+	for _, tc := range []struct{ name, source, wantErr string }{
+		{"load", `load("other.star", "x")` + "\noutput = x", "load not implemented"},
+		{"open", `output = open("/etc/passwd")`, "undefined: open"},
+		{"time", `output = time.now()`, "undefined: time"},
+		{"http", `output = http.get("http://example.com")`, "undefined: http"},
+		{"os", `output = os.getenv("HOME")`, "undefined: os"},
+		{"while", "n = 0\nwhile True:\n    n += 1\noutput = n", "does not support while loops"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := runTestScript(t, tc.source, nil); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateScriptConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr string
+	}{
+		{"defaults", map[string]interface{}{}, ""},
+		{"within limits", map[string]interface{}{"timeout_seconds": float64(10), "max_steps": float64(1000)}, ""},
+		{"timeout above maximum", map[string]interface{}{"timeout_seconds": float64(scriptFallbackMaxTimeoutSeconds + 1)}, "'timeout_seconds' must be an integer between 1 and 30"},
+		{"steps above maximum", map[string]interface{}{"max_steps": float64(scriptFallbackMaxSteps + 1)}, "'max_steps' must be an integer"},
+		{"zero timeout", map[string]interface{}{"timeout_seconds": float64(0)}, "'timeout_seconds' must be"},
+		{"fractional steps", map[string]interface{}{"max_steps": 10.5}, "'max_steps' must be"},
+		{"string timeout", map[string]interface{}{"timeout_seconds": "5"}, "'timeout_seconds' must be"},
+		{"memory within limits", map[string]interface{}{"max_memory_mb": float64(64)}, ""},
+		{"memory above maximum", map[string]interface{}{"max_memory_mb": float64(scriptFallbackMaxMemoryMB + 1)}, "'max_memory_mb' must be an integer between 1 and 256"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config["source"] = "output = 1"
+			err := ValidateActions([]workflow.ActionDefinition{{Name: "s", Type: workflow.ActionTypeScript, Config: tc.config}}, nil)
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// services/task-orchestrator-service/internal/engine/script_process.go
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScriptRunnerCommand es el subcomando del binario que ejecuta un script en un
+// proceso hijo: `task-orchestrator-service script-runner` lee el trabajo por stdin.
+const ScriptRunnerCommand = "script-runner"
+
+const (
+	// scriptHeapHeadroomMB es el margen sobre max_memory_mb para que el heap pueda
+	// crecer: el runtime de Go lo reserva en arenas de 64 MB.
+	scriptHeapHeadroomMB = 64
+	// scriptRuntimeFallbackMB es lo que se supone que ocupa el proceso hijo antes
+	// del script si el sistema no lo informa (/proc/self/status).
+	scriptRuntimeFallbackMB = 160
+	// scriptKillGrace es el margen tras el timeout antes de matar al hijo si no
+	// ha terminado por su cuenta.
+	scriptKillGrace   = 2 * time.Second
+	scriptMaxPrint    = 4096
+	scriptStderrBytes = 4096
+)
+
+// scriptJob es lo que el proceso padre envía al hijo.
+type scriptJob struct {
+	Name           string                 `json:"name"`
+	Source         string                 `json:"source"`
+	Globals        map[string]interface{} `json:"globals"`
+	MaxSteps       uint64                 `json:"max_steps"`
+	TimeoutSeconds int                    `json:"timeout_seconds"`
+	MaxMemoryMB    int                    `json:"max_memory_mb"`
+}
+
+// scriptEvent es cada mensaje del hijo: un print del script o, al final, el resultado.
+type scriptEvent struct {
+	Print  *string     `json:"print,omitempty"`
+	Done   bool        `json:"done,omitempty"`
+	Output interface{} `json:"output,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// RunScriptProcess es el lado hijo de runScriptProcess: fija el límite de memoria
+// del proceso, ejecuta el script y escribe sus eventos en out. Devuelve el código
+// de salida del proceso.
+func RunScriptProcess(in io.Reader, out io.Writer) int {
+	var job scriptJob
+	if err := json.NewDecoder(in).Decode(&job); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid script job: %v\n", err)
+		return 2
+	}
+	// El script es de un solo hilo; con un P el runtime crea menos hilos, y cada
+	// pila de hilo cuenta para el límite.
+	runtime.GOMAXPROCS(1)
+	base, ok := processDataBytes()
+	if !ok {
+		base = scriptRuntimeFallbackMB * 1024 * 1024
+	}
+	budget := uint64(job.MaxMemoryMB) * 1024 * 1024
+	if err := limitProcessMemory(base + budget + scriptHeapHeadroomMB*1024*1024); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to limit script memory: %v\n", err)
+		return 2
+	}
+	// Con el límite blando el GC recoge antes de pedir otra arena; el duro corta
+	// las reservas que no caben (p. ej. una cadena de cientos de MB).
+	debug.SetMemoryLimit(int64(budget))
+
+	encoder := json.NewEncoder(out)
+	output, err := runStarlark(job, func(msg string) {
+		if len(msg) > scriptMaxPrint {
+			msg = msg[:scriptMaxPrint] + "…"
+		}
+		encoder.Encode(scriptEvent{Print: &msg})
+	})
+	result := scriptEvent{Done: true, Output: output}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if err := encoder.Encode(result); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// runScriptProcess ejecuta job en un proceso hijo (el propio binario con
+// ScriptRunnerCommand) para que el límite de memoria afecte solo al script: si lo
+// supera, muere el hijo y no el servicio. print recibe los print del script.
+func runScriptProcess(ctx context.Context, job scriptJob, print func(string)) (interface{}, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the script runner: %w", err)
+	}
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode script input: %w", err)
+	}
+
+	timeout := time.Duration(job.TimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout+scriptKillGrace)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, executable, ScriptRunnerCommand)
+	cmd.Env = []string{}
+	cmd.Stdin = bytes.NewReader(payload)
+	stderr := &headBuffer{max: scriptStderrBytes}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the script runner: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the script runner: %w", err)
+	}
+
+	var result *scriptEvent
+	decoder := json.NewDecoder(stdout)
+	for {
+		var event scriptEvent
+		if err := decoder.Decode(&event); err != nil {
+			break
+		}
+		if event.Print != nil {
+			print(*event.Print)
+		}
+		if event.Done {
+			result = &event
+		}
+	}
+	io.Copy(io.Discard, stdout)
+	waitErr := cmd.Wait()
+
+	switch {
+	case result != nil && result.Error != "":
+		return nil, errors.New(result.Error)
+	case result != nil:
+		return result.Output, nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, fmt.Errorf("timeout after %s", timeout)
+	case scriptOutOfMemory(stderr.String()):
+		return nil, fmt.Errorf("memory limit of %d MB exceeded", job.MaxMemoryMB)
+	default:
+		return nil, fmt.Errorf("script runner exited without a result (%v): %s", waitErr, strings.TrimSpace(firstLine(stderr.String())))
+	}
+}
+
+// scriptOutOfMemory reconoce en el stderr del hijo que ha superado RLIMIT_DATA: el
+// runtime de Go aborta con "out of memory" o "cannot allocate memory" cuando falla
+// una reserva, o con SIGSEGV si la que falla es una interna del GC.
+func scriptOutOfMemory(stderr string) bool {
+	for _, marker := range []string{"out of memory", "cannot allocate memory", "SIGSEGV"} {
+		if strings.Contains(stderr, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// processDataBytes devuelve el segmento de datos actual del proceso (VmData), la
+// misma cuenta que limita RLIMIT_DATA. Solo está disponible en Linux.
+func processDataBytes() (uint64, bool) {
+	status, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if value, found := strings.CutPrefix(line, "VmData:"); found {
+			kb, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+			return kb * 1024, err == nil
+		}
+	}
+	return 0, false
+}
+
+// headBuffer guarda los primeros max bytes de lo que se escribe y descarta el resto.
+type headBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,13 @@
+// services/task-orchestrator-service/internal/engine/script_rlimit_other.go
+
+//go:build !unix
+
+package engine
+
+import "errors"
+
+// limitProcessMemory no tiene equivalente a RLIMIT_DATA fuera de Unix: los scripts
+// no se ejecutan sin un límite de memoria real.
+func limitProcessMemory(bytes uint64) error {
+	return errors.New("script memory limits require a Unix system")
+}
@@ -0,0 +1,13 @@
+// services/task-orchestrator-service/internal/engine/script_rlimit_unix.go
+
+//go:build unix
+
+package engine
+
+import "syscall"
+
+// limitProcessMemory fija RLIMIT_DATA: al superarlo, las reservas del runtime de
+// Go fallan y el proceso termina con "out of memory".
+func limitProcessMemory(bytes uint64) error {
+	return syscall.Setrlimit(syscall.RLIMIT_DATA, &syscall.Rlimit{Cur: bytes, Max: bytes})
+}
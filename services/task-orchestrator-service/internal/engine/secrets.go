@@ -0,0 +1,64 @@
+// services/task-orchestrator-service/internal/engine/secrets.go
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/secret"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// secretStore es la fuente de los secretos referenciados por las acciones.
+// Se configura una sola vez al arrancar el servicio con SetSecretStore.
+var secretStore secret.Store
+
+// SetSecretStore registra el store de secretos que usará el motor.
+func SetSecretStore(store secret.Store) {
+	secretStore = store
+}
+
+// secretValue resuelve un secreto del dueño del workflow por nombre.
+func (r *runner) secretValue(name string) (string, error) {
+	if secretStore == nil {
+		return "", errors.New("secret store is not configured")
+	}
+	value, err := secretStore.GetSecretValue(r.ctx, r.wf.UserID, name)
+	if err != nil {
+		if errors.Is(err, secret.ErrNotFound) {
+			return "", fmt.Errorf("secret '%s' not found", name)
+		}
+		return "", fmt.Errorf("failed to read secret '%s': %w", name, err)
+	}
+	return value, nil
+}
+
+// SecretReferences devuelve, ordenados y sin repetir, los nombres de los secretos
+// que usan las acciones (incluidas on_failure y las compensaciones). Hoy solo
+// sql_query los referencia, en config.connection.
+func SecretReferences(actions, onFailure []workflow.ActionDefinition) []string {
+	seen := map[string]bool{}
+	var visit func(action workflow.ActionDefinition)
+	visit = func(action workflow.ActionDefinition) {
+		if action.Type == workflow.ActionTypeSQLQuery {
+			if name, _ := action.Config["connection"].(string); name != "" {
+				seen[name] = true
+			}
+		}
+		if action.Compensate != nil {
+			visit(*action.Compensate)
+		}
+	}
+	for _, list := range [][]workflow.ActionDefinition{actions, onFailure} {
+		for _, action := range list {
+			visit(action)
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,217 @@
+// services/task-orchestrator-service/internal/engine/sql_action.go
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/config"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	sqlDefaultMaxRows = 1000
+	sqlDefaultTimeout = 30 * time.Second
+
+	// Máximos si la configuración del servidor no los fija (SQL_MAX_ROWS, SQL_MAX_TIMEOUT_SECONDS).
+	sqlFallbackMaxRows           = 10_000
+	sqlFallbackMaxTimeoutSeconds = 300
+)
+
+// executeSQLQuery ejecuta una consulta parametrizada contra una base de datos
+// Postgres cuya cadena de conexión está guardada como secreto.
+// Config: {"connection": "<secreto>", "query": "SELECT ... WHERE id = $1", "params": [...],
+// "mode": "read_only"|"read_write", "max_rows": 1000, "timeout_seconds": 30}; los
+// límites se acotan a los máximos del servidor (sqlLimits).
+// Los parámetros de tipo texto son plantillas evaluadas con los datos de la ejecución.
+func (r *runner) executeSQLQuery(action workflow.ActionDefinition) (interface{}, error) {
+	connName, _ := action.Config["connection"].(string)
+	query, _ := action.Config["query"].(string)
+	if connName == "" || strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("action '%s': 'connection' and 'query' are required for sql_query", action.Name)
+	}
+
+	mode, _ := action.Config["mode"].(string)
+	accessMode := pgx.ReadOnly
+	switch mode {
+	case "", "read_only":
+		mode = "read_only"
+	case "read_write":
+		accessMode = pgx.ReadWrite
+	default:
+		return nil, fmt.Errorf("action '%s': 'mode' must be 'read_only' or 'read_write'", action.Name)
+	}
+
+	maxRowsAllowed, maxTimeout := sqlLimits()
+	maxRows := min(configInt(action.Config, "max_rows", sqlDefaultMaxRows), maxRowsAllowed)
+	timeout := time.Duration(min(configInt(action.Config, "timeout_seconds", int(sqlDefaultTimeout/time.Second)), maxTimeout)) * time.Second
+
+	params, err := r.sqlParams(action)
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': %w", action.Name, err)
+	}
+
+	dsn, err := r.secretValue(connName)
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': %w", action.Name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, timeout)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': failed to connect to '%s': %w", action.Name, connName, err)
+	}
+	defer conn.Close(context.Background())
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{AccessMode: accessMode})
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': failed to begin transaction: %w", action.Name, err)
+	}
+	defer tx.Rollback(context.Background())
+
+	// statement_timeout protege también al servidor si el contexto no llega a cancelar la consulta.
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+		return nil, fmt.Errorf("action '%s': failed to set statement timeout: %w", action.Name, err)
+	}
+
+	rows, err := tx.Query(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': query failed: %w", action.Name, err)
+	}
+
+	columns := make([]string, 0, len(rows.FieldDescriptions()))
+	for _, fd := range rows.FieldDescriptions() {
+		columns = append(columns, fd.Name)
+	}
+
+	resultRows := []map[string]interface{}{}
+	truncated := false
+	for rows.Next() {
+		if len(resultRows) >= maxRows {
+			truncated = true
+			break
+		}
+		values, err := rows.Values()
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("action '%s': failed to read row: %w", action.Name, err)
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			row[col] = sqlValue(values[i])
+		}
+		resultRows = append(resultRows, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("action '%s': query failed: %w", action.Name, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("action '%s': failed to commit: %w", action.Name, err)
+	}
+
+	rowsAffected := rows.CommandTag().RowsAffected()
+	r.addLog(fmt.Sprintf("SQL query on '%s' (%s) returned %d row(s), %d affected, truncated: %t", connName, mode, len(resultRows), rowsAffected, truncated), "ACTION_OUTPUT")
+
+	return map[string]interface{}{
+		"columns":       columns,
+		"rows":          resultRows,
+		"row_count":     len(resultRows),
+		"rows_affected": rowsAffected,
+		"truncated":     truncated,
+	}, nil
+}
+
+// sqlLimits devuelve los valores máximos de max_rows y timeout_seconds que admite el servidor.
+func sqlLimits() (maxRows, maxTimeoutSeconds int) {
+	maxRows, maxTimeoutSeconds = config.AppConfig.SQLMaxRows, config.AppConfig.SQLMaxTimeoutSeconds
+	if maxRows <= 0 {
+		maxRows = sqlFallbackMaxRows
+	}
+	if maxTimeoutSeconds <= 0 {
+		maxTimeoutSeconds = sqlFallbackMaxTimeoutSeconds
+	}
+	return maxRows, maxTimeoutSeconds
+}
+
+// validateSQLConfig comprueba al guardar lo que executeSQLQuery rechazaría al
+// ejecutar: conexión y consulta, el modo, el tipo de params y que los límites
+// sean enteros positivos dentro de los máximos del servidor.
+func validateSQLConfig(action workflow.ActionDefinition) error {
+	connName, _ := action.Config["connection"].(string)
+	query, _ := action.Config["query"].(string)
+	if connName == "" || strings.TrimSpace(query) == "" {
+		return fmt.Errorf("'connection' and 'query' are required for sql_query")
+	}
+	if mode, ok := action.Config["mode"]; ok && mode != "read_only" && mode != "read_write" {
+		return fmt.Errorf("'mode' must be 'read_only' or 'read_write'")
+	}
+	if params, ok := action.Config["params"]; ok && params != nil {
+		if _, isList := params.([]interface{}); !isList {
+			return fmt.Errorf("'params' must be a list")
+		}
+	}
+	maxRows, maxTimeout := sqlLimits()
+	return validateIntLimits(action.Config, configLimit{"max_rows", maxRows}, configLimit{"timeout_seconds", maxTimeout})
+}
+
+// sqlParams evalúa los parámetros de la consulta; las cadenas son plantillas.
+func (r *runner) sqlParams(action workflow.ActionDefinition) ([]interface{}, error) {
+	raw, exists := action.Config["params"]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'params' must be a list")
+	}
+
+	data := r.templateData()
+	params := make([]interface{}, 0, len(list))
+	for i, p := range list {
+		if s, isStr := p.(string); isStr {
+			rendered, err := renderText(fmt.Sprintf("param%d", i+1), s, data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template in param %d: %w", i+1, err)
+			}
+			params = append(params, rendered)
+			continue
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// sqlValue adapta los tipos devueltos por pgx a valores serializables como JSON.
+func sqlValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case [16]byte:
+		return uuid.UUID(val).String()
+	case []byte:
+		return string(val)
+	default:
+		return val
+	}
+}
+
+// configInt lee un entero de la configuración de una acción (los números JSON llegan como float64).
+func configInt(config map[string]interface{}, key string, fallback int) int {
+	switch v := config[key].(type) {
+	case float64:
+		if v > 0 {
+			return int(v)
+		}
+	case int:
+		if v > 0 {
+			return v
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,42 @@
+// services/task-orchestrator-service/internal/engine/sql_action_test.go
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+func TestValidateSQLConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr string
+	}{
+		{"defaults", map[string]interface{}{}, ""},
+		{"within limits", map[string]interface{}{"max_rows": float64(500), "timeout_seconds": float64(60), "mode": "read_write", "params": []interface{}{"{{.Inputs.id}}", float64(1)}}, ""},
+		{"rows above maximum", map[string]interface{}{"max_rows": float64(100_000_000)}, "'max_rows' must be an integer between 1 and 10000"},
+		{"timeout above maximum", map[string]interface{}{"timeout_seconds": float64(sqlFallbackMaxTimeoutSeconds + 1)}, "'timeout_seconds' must be an integer between 1 and 300"},
+		{"zero rows", map[string]interface{}{"max_rows": float64(0)}, "'max_rows' must be"},
+		{"string timeout", map[string]interface{}{"timeout_seconds": "30"}, "'timeout_seconds' must be"},
+		{"unknown mode", map[string]interface{}{"mode": "admin"}, "'mode' must be 'read_only' or 'read_write'"},
+		{"params not a list", map[string]interface{}{"params": "1"}, "'params' must be a list"},
+		{"missing query", map[string]interface{}{"query": "  "}, "'connection' and 'query' are required"},
+		{"missing connection", map[string]interface{}{"connection": ""}, "'connection' and 'query' are required"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := map[string]interface{}{"connection": "reporting-db", "query": "SELECT 1"}
+			for key, value := range tc.config {
+				config[key] = value
+			}
+			err := ValidateActions([]workflow.ActionDefinition{{Name: "q", Type: workflow.ActionTypeSQLQuery, Config: config}}, nil)
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
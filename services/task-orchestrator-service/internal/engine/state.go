@@ -0,0 +1,165 @@
+// services/task-orchestrator-service/internal/engine/state.go
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// executionState es lo que se guarda en workflow_executions.state para poder
+// reanudar una ejecución suspendida en cualquier réplica, incluso tras un reinicio.
+// Incluye una copia del workflow para que la ejecución termine con la definición
+// con la que empezó aunque se edite mientras está suspendida.
+type executionState struct {
+	Workflow workflow.Workflow      `json:"workflow"`
+	NextStep int                    `json:"next_step"`
+	Failed   bool                   `json:"failed"`
+	Failure  *failureInfo           `json:"failure,omitempty"`
+	Outputs  map[string]interface{} `json:"outputs"`
+	Inputs   map[string]interface{} `json:"inputs"`
+	Trigger  map[string]interface{} `json:"trigger"`
+	Wait     *waitState             `json:"wait,omitempty"`
+}
+
+// waitState describe por qué está suspendida la ejecución.
+type waitState struct {
+	Kind        string                 `json:"kind"` // "delay", "wait_until", "approval", "event"
+	Step        int                    `json:"step"`
+	SuspendedAt time.Time              `json:"suspended_at"`
+	Until       time.Time              `json:"until"` // Cero si la espera no tiene vencimiento
+	Data        map[string]interface{} `json:"data,omitempty"`
+}
+
+// suspension la devuelve una acción (como error) para pedir que la ejecución
+// se suspenda hasta `until` (o indefinidamente si es cero).
+type suspension struct {
+	kind  string
+	until time.Time
+	data  map[string]interface{}
+	// Evento externo y clave de correlación que reanudan la ejecución (wait_for_event).
+	event string
+	key   string
+}
+
+func (s *suspension) Error() string {
+	if s.until.IsZero() {
+		return fmt.Sprintf("execution suspended (%s)", s.kind)
+	}
+	return fmt.Sprintf("execution suspended (%s) until %s", s.kind, s.until.Format(time.RFC3339))
+}
+
+// haltError hace que la ejecución se detenga tras marcar el paso como fallido,
+// sin ejecutar las acciones restantes (ej. una aprobación rechazada).
+type haltError struct {
+	err error
+}
+
+func (h *haltError) Error() string { return h.err.Error() }
+func (h *haltError) Unwrap() error { return h.err }
+
+// suspend deja la ejecución en estado "suspended" con la espera pendiente.
+func (r *runner) suspend(step int, startedAt time.Time, s *suspension) {
+	r.wait = &waitState{Kind: s.kind, Step: step, SuspendedAt: startedAt, Until: s.until, Data: s.data}
+	r.execution.Status = "suspended"
+	r.execution.WaitEvent = s.event
+	r.execution.WaitKey = s.key
+	r.execution.ResumeAt = nil
+	if !s.until.IsZero() {
+		until := s.until
+		r.execution.ResumeAt = &until
+	}
+	r.addLog(fmt.Sprintf("Execution suspended by action '%s': %s", r.wf.Actions[step].Name, s.Error()), "INFO")
+}
+
+// completeWait termina la acción que suspendió la ejecución, una vez reanudada.
+func (r *runner) completeWait(action workflow.ActionDefinition) (interface{}, error) {
+	now := time.Now().UTC()
+	r.addLog(fmt.Sprintf("Resuming after action '%s' (%s)", action.Name, r.wait.Kind), "INFO")
+	switch r.wait.Kind {
+	case waitKindApproval:
+		return r.completeApproval(action)
+	case waitKindEvent:
+		return r.completeEventWait(action)
+	}
+	return map[string]interface{}{
+		"suspended_at": r.wait.SuspendedAt,
+		"waited_until": r.wait.Until,
+		"resumed_at":   now,
+	}, nil
+}
+
+// snapshot vuelca logs, resultados y estado del runner al registro de ejecución.
+func (r *runner) snapshot() {
+	logsJSON, err := json.Marshal(r.logs)
+	if err != nil {
+		log.Printf("CRITICAL: Failed to marshal execution logs for workflow %s: %v", r.wf.ID, err)
+		// Usar logs vacíos si falla el marshal
+		r.execution.Logs = json.RawMessage("[]")
+	} else {
+		r.execution.Logs = logsJSON
+	}
+	stepsJSON, err := json.Marshal(r.steps)
+	if err != nil {
+		log.Printf("CRITICAL: Failed to marshal step results for workflow %s: %v", r.wf.ID, err)
+		r.execution.Steps = json.RawMessage("[]")
+	} else {
+		r.execution.Steps = stepsJSON
+	}
+	stateJSON, err := json.Marshal(executionState{
+		Workflow: r.wf,
+		NextStep: r.next,
+		Failed:   r.failed,
+		Failure:  r.failure,
+		Outputs:  r.outputs,
+		Inputs:   r.inputs,
+		Trigger:  r.trigger,
+		Wait:     r.wait,
+	})
+	if err != nil {
+		log.Printf("CRITICAL: Failed to marshal execution state for workflow %s: %v", r.wf.ID, err)
+		return
+	}
+	r.execution.State = stateJSON
+}
+
+// restoreRunner reconstruye el runner a partir de un registro de ejecución suspendido.
+func restoreRunner(execution *workflow.ExecutionLog) (*runner, error) {
+	var state executionState
+	if err := json.Unmarshal(execution.State, &state); err != nil {
+		return nil, fmt.Errorf("invalid execution state: %w", err)
+	}
+	r := &runner{
+		ctx:       context.Background(),
+		wf:        state.Workflow,
+		execution: execution,
+		outputs:   state.Outputs,
+		inputs:    state.Inputs,
+		trigger:   state.Trigger,
+		next:      state.NextStep,
+		failed:    state.Failed,
+		failure:   state.Failure,
+		wait:      state.Wait,
+	}
+	if r.outputs == nil {
+		r.outputs = map[string]interface{}{}
+	}
+	if r.inputs == nil {
+		r.inputs = map[string]interface{}{}
+	}
+	if len(execution.Logs) > 0 {
+		if err := json.Unmarshal(execution.Logs, &r.logs); err != nil {
+			return nil, fmt.Errorf("invalid execution logs: %w", err)
+		}
+	}
+	if len(execution.Steps) > 0 {
+		if err := json.Unmarshal(execution.Steps, &r.steps); err != nil {
+			return nil, fmt.Errorf("invalid execution steps: %w", err)
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,43 @@
+// services/task-orchestrator-service/internal/engine/template.go
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	htmltemplate "html/template"
+	"text/template"
+)
+
+// templateFuncs son las funciones disponibles dentro de las plantillas de configuración.
+var templateFuncs = map[string]interface{}{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// renderText evalúa una plantilla de text/template con los datos de la ejecución.
+func renderText(name, tmpl string, data interface{}) (string, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderHTML evalúa una plantilla de html/template, escapando los valores interpolados.
+func renderHTML(name, tmpl string, data interface{}) (string, error) {
+	t, err := htmltemplate.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,177 @@
+// services/task-orchestrator-service/internal/engine/transform_action.go
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/itchyny/gojq"
+)
+
+const transformTimeout = 2 * time.Second
+
+// executeTransform aplica una expresión jq (o un mapping de campo -> expresión jq)
+// a la salida de un paso previo y guarda el resultado como una nueva salida.
+// Config: {"from_step": "fetch", "expression": ".body.items | map(.id)"} o
+// {"from_step": "fetch", "mapping": {"ids": ".body.items | map(.id)", "total": ".body.total"}},
+// más "output": "<nombre>" opcional para publicar el resultado bajo otro nombre.
+// Sin from_step la entrada es {"inputs": ..., "trigger": ..., "steps": ...}.
+func (r *runner) executeTransform(action workflow.ActionDefinition) (interface{}, error) {
+	spec, err := parseTransform(action)
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': %w", action.Name, err)
+	}
+
+	var input interface{} = map[string]interface{}{
+		"inputs":  r.inputs,
+		"trigger": r.trigger,
+		"steps":   r.outputs,
+	}
+	if spec.fromStep != "" {
+		value, found := r.outputs[spec.fromStep]
+		if !found {
+			return nil, fmt.Errorf("action '%s': step '%s' has no output (not executed or failed)", action.Name, spec.fromStep)
+		}
+		input = value
+	}
+	input, err = normalizeJSON(input)
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': input is not JSON-serializable: %w", action.Name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, transformTimeout)
+	defer cancel()
+
+	var result interface{}
+	if spec.expression != nil {
+		if result, err = runJQ(ctx, spec.expression, input); err != nil {
+			return nil, fmt.Errorf("action '%s': %w", action.Name, err)
+		}
+	} else {
+		mapped := make(map[string]interface{}, len(spec.mapping))
+		for _, field := range spec.fields {
+			value, err := runJQ(ctx, spec.mapping[field], input)
+			if err != nil {
+				return nil, fmt.Errorf("action '%s': field '%s': %w", action.Name, field, err)
+			}
+			mapped[field] = value
+		}
+		result = mapped
+	}
+
+	if spec.output != "" {
+		r.outputs[spec.output] = result
+	}
+	return result, nil
+}
+
+// transformSpec es la configuración de una acción transform ya compilada.
+type transformSpec struct {
+	fromStep   string
+	output     string
+	expression *gojq.Code
+	mapping    map[string]*gojq.Code
+	fields     []string // Campos del mapping en orden estable
+}
+
+// parseTransform valida y compila la configuración. Se usa tanto al ejecutar
+// como al guardar el workflow. Que "output" no pise la salida de otro paso lo
+// comprueba ValidateActions, que ve el workflow completo.
+func parseTransform(action workflow.ActionDefinition) (*transformSpec, error) {
+	spec := &transformSpec{}
+	spec.fromStep, _ = action.Config["from_step"].(string)
+	if raw, ok := action.Config["output"]; ok {
+		if spec.output, _ = raw.(string); spec.output == "" {
+			return nil, fmt.Errorf("'output' must be a non-empty string")
+		}
+	}
+
+	expression, hasExpression := action.Config["expression"].(string)
+	mapping, hasMapping := action.Config["mapping"].(map[string]interface{})
+	if hasExpression == hasMapping {
+		return nil, fmt.Errorf("transform requires exactly one of 'expression' or 'mapping'")
+	}
+
+	if hasExpression {
+		code, err := compileJQ(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'expression': %w", err)
+		}
+		spec.expression = code
+		return spec, nil
+	}
+
+	if len(mapping) == 0 {
+		return nil, fmt.Errorf("'mapping' must not be empty")
+	}
+	spec.mapping = make(map[string]*gojq.Code, len(mapping))
+	for field, raw := range mapping {
+		expr, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("mapping field '%s' must be a jq expression string", field)
+		}
+		code, err := compileJQ(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression for mapping field '%s': %w", field, err)
+		}
+		spec.mapping[field] = code
+		spec.fields = append(spec.fields, field)
+	}
+	sort.Strings(spec.fields)
+	return spec, nil
+}
+
+// compileJQ compila una expresión jq. No se habilitan variables de entorno
+// ni módulos externos, por lo que la expresión solo ve su entrada.
+func compileJQ(expression string) (*gojq.Code, error) {
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	return gojq.Compile(query, gojq.WithEnvironLoader(func() []string { return nil }))
+}
+
+// runJQ ejecuta el código; un único resultado se devuelve tal cual y varios como lista.
+func runJQ(ctx context.Context, code *gojq.Code, input interface{}) (interface{}, error) {
+	iter := code.RunWithContext(ctx, input)
+	results := []interface{}{}
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, isErr := v.(error); isErr {
+			if haltErr, isHalt := err.(*gojq.HaltError); isHalt && haltErr.Value() == nil {
+				break
+			}
+			return nil, err
+		}
+		results = append(results, v)
+	}
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return results[0], nil
+	default:
+		return results, nil
+	}
+}
+
+// normalizeJSON convierte un valor Go a los tipos genéricos de encoding/json
+// (map[string]interface{}, []interface{}, float64...), que es lo que espera gojq.
+func normalizeJSON(value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
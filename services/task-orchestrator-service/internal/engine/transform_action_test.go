@@ -0,0 +1,112 @@
+// services/task-orchestrator-service/internal/engine/transform_action_test.go
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+func transformAction(name string, config map[string]interface{}) workflow.ActionDefinition {
+	return workflow.ActionDefinition{Name: name, Type: workflow.ActionTypeTransform, Config: config}
+}
+
+func TestParseTransform(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr string
+	}{
+		{"expression", map[string]interface{}{"expression": ".items | length"}, ""},
+		{"mapping with output", map[string]interface{}{"mapping": map[string]interface{}{"n": ".total"}, "output": "summary"}, ""},
+		{"neither", map[string]interface{}{}, "exactly one of 'expression' or 'mapping'"},
+		{"both", map[string]interface{}{"expression": ".", "mapping": map[string]interface{}{"n": "."}}, "exactly one of"},
+		{"empty mapping", map[string]interface{}{"mapping": map[string]interface{}{}}, "'mapping' must not be empty"},
+		{"non-string mapping field", map[string]interface{}{"mapping": map[string]interface{}{"n": 1.0}}, "mapping field 'n' must be a jq expression string"},
+		{"invalid jq", map[string]interface{}{"expression": ".items |"}, "invalid 'expression'"},
+		{"empty output", map[string]interface{}{"expression": ".", "output": ""}, "'output' must be a non-empty string"},
+		{"non-string output", map[string]interface{}{"expression": ".", "output": 3.0}, "'output' must be a non-empty string"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseTransform(transformAction("t", tc.config))
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTransformOutputs(t *testing.T) {
+	fetch := workflow.ActionDefinition{Name: "fetch", Type: workflow.ActionTypeLogMessage, Config: map[string]interface{}{"message": "x"}}
+	notify := workflow.ActionDefinition{Name: "notify", Type: workflow.ActionTypeLogMessage, Config: map[string]interface{}{"message": "x"}}
+	withOutput := func(name, output string) workflow.ActionDefinition {
+		return transformAction(name, map[string]interface{}{"expression": ".", "output": output})
+	}
+	compensated := fetch
+	compensated.Compensate = &workflow.ActionDefinition{Name: "undo", Type: workflow.ActionTypeLogMessage, Config: map[string]interface{}{"message": "x"}}
+
+	for _, tc := range []struct {
+		name      string
+		actions   []workflow.ActionDefinition
+		onFailure []workflow.ActionDefinition
+		wantErr   string
+	}{
+		{"distinct output", []workflow.ActionDefinition{fetch, withOutput("t", "summary")}, nil, ""},
+		{"no output", []workflow.ActionDefinition{fetch, transformAction("t", map[string]interface{}{"expression": "."})}, nil, ""},
+		{"previous step", []workflow.ActionDefinition{fetch, withOutput("t", "fetch")}, nil, "would overwrite the output of action 'fetch'"},
+		{"later step", []workflow.ActionDefinition{withOutput("t", "notify"), notify}, nil, "would overwrite the output of action 'notify'"},
+		{"its own name", []workflow.ActionDefinition{withOutput("t", "t")}, nil, "would overwrite the output of action 't'"},
+		{"another output", []workflow.ActionDefinition{withOutput("a", "summary"), withOutput("b", "summary")}, nil, "already published by action 'a'"},
+		{"compensation name", []workflow.ActionDefinition{compensated, withOutput("t", "undo")}, nil, "would overwrite the output of action 'undo'"},
+		{"on_failure name", []workflow.ActionDefinition{withOutput("t", "alert")}, []workflow.ActionDefinition{{Name: "alert", Type: workflow.ActionTypeLogMessage, Config: map[string]interface{}{"message": "x"}}}, "would overwrite the output of action 'alert'"},
+		{"on_failure transform", []workflow.ActionDefinition{fetch}, []workflow.ActionDefinition{withOutput("t", "fetch")}, "would overwrite the output of action 'fetch'"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateActions(tc.actions, tc.onFailure)
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecuteTransformPublishesOutput(t *testing.T) {
+	r := newRunner(workflow.Workflow{Name: "transform-test"}, RunInput{Source: "manual"})
+	r.outputs["fetch"] = map[string]interface{}{"items": []interface{}{map[string]interface{}{"id": 1}, map[string]interface{}{"id": 2}}}
+
+	result, err := r.executeTransform(transformAction("t", map[string]interface{}{
+		"from_step": "fetch",
+		"mapping":   map[string]interface{}{"ids": ".items | map(.id)", "count": ".items | length"},
+		"output":    "summary",
+	}))
+	if err != nil {
+		t.Fatalf("executeTransform: %v", err)
+	}
+	want := map[string]interface{}{"ids": []interface{}{1.0, 2.0}, "count": 2}
+	if !reflect.DeepEqual(normalizeForCompare(t, result), normalizeForCompare(t, want)) {
+		t.Errorf("result = %#v, want %#v", result, want)
+	}
+	if !reflect.DeepEqual(r.outputs["summary"], result) {
+		t.Errorf("output 'summary' = %#v, want the transform result", r.outputs["summary"])
+	}
+	if _, overwritten := r.outputs["fetch"].(map[string]interface{})["ids"]; overwritten {
+		t.Errorf("the source step output was modified")
+	}
+}
+
+func normalizeForCompare(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	normalized, err := normalizeJSON(v)
+	if err != nil {
+		t.Fatalf("normalizeJSON: %v", err)
+	}
+	return normalized
+}
@@ -0,0 +1,118 @@
+// services/task-orchestrator-service/internal/engine/validate.go
+package engine
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// ValidateActions revisa la configuración de las acciones que pueden comprobarse
+// sin ejecutarlas (ej. compilar expresiones jq), incluidas las compensaciones y
+// las acciones on_failure. La validación estructural (campos requeridos, tipos
+// permitidos) la hace el validator en el handler.
+func ValidateActions(actions []workflow.ActionDefinition, onFailure []workflow.ActionDefinition) error {
+	for i, action := range actions {
+		if err := validateActionConfig(action); err != nil {
+			return fmt.Errorf("action %d ('%s'): %w", i, action.Name, err)
+		}
+		if action.Compensate != nil {
+			if err := validateFailureAction(*action.Compensate); err != nil {
+				return fmt.Errorf("action %d ('%s') compensate: %w", i, action.Name, err)
+			}
+		}
+	}
+	for i, action := range onFailure {
+		if err := validateFailureAction(action); err != nil {
+			return fmt.Errorf("on_failure action %d ('%s'): %w", i, action.Name, err)
+		}
+	}
+	return validateTransformOutputs(actions, onFailure)
+}
+
+// validateTransformOutputs comprueba que el "output" de cada transform no
+// sobrescriba la salida de una acción (incluida la suya; las salidas se indexan
+// por nombre de acción) ni la publicada por otro transform.
+func validateTransformOutputs(actions []workflow.ActionDefinition, onFailure []workflow.ActionDefinition) error {
+	all := make([]workflow.ActionDefinition, 0, len(actions)+len(onFailure))
+	for _, action := range actions {
+		all = append(all, action)
+		if action.Compensate != nil {
+			all = append(all, *action.Compensate)
+		}
+	}
+	all = append(all, onFailure...)
+
+	names := make(map[string]bool, len(all))
+	for _, action := range all {
+		names[action.Name] = true
+	}
+	published := map[string]string{}
+	for _, action := range all {
+		if action.Type != workflow.ActionTypeTransform {
+			continue
+		}
+		output, _ := action.Config["output"].(string)
+		if output == "" {
+			continue
+		}
+		if names[output] {
+			return fmt.Errorf("action '%s': 'output' '%s' would overwrite the output of action '%s'", action.Name, output, output)
+		}
+		if other, taken := published[output]; taken {
+			return fmt.Errorf("action '%s': 'output' '%s' is already published by action '%s'", action.Name, output, other)
+		}
+		published[output] = action.Name
+	}
+	return nil
+}
+
+func validateActionConfig(action workflow.ActionDefinition) error {
+	switch action.Type {
+	case workflow.ActionTypeTransform:
+		if _, err := parseTransform(action); err != nil {
+			return err
+		}
+	case workflow.ActionTypeScript:
+		return validateScriptConfig(action)
+	case workflow.ActionTypeSQLQuery:
+		return validateSQLConfig(action)
+	}
+	return nil
+}
+
+// configLimit es un límite numérico de la configuración de una acción y el máximo
+// que admite el servidor.
+type configLimit struct {
+	key string
+	max int
+}
+
+// validateIntLimits comprueba que los límites presentes en config sean enteros
+// entre 1 y su máximo.
+func validateIntLimits(config map[string]interface{}, limits ...configLimit) error {
+	for _, limit := range limits {
+		raw, ok := config[limit.key]
+		if !ok {
+			continue
+		}
+		value, isNumber := raw.(float64)
+		if !isNumber || value != math.Trunc(value) || value < 1 || value > float64(limit.max) {
+			return fmt.Errorf("'%s' must be an integer between 1 and %d", limit.key, limit.max)
+		}
+	}
+	return nil
+}
+
+// validateFailureAction comprueba una compensación u acción on_failure: no puede
+// suspender la ejecución ni declarar a su vez otra compensación.
+func validateFailureAction(action workflow.ActionDefinition) error {
+	if suspendingActions[action.Type] {
+		return fmt.Errorf("action type '%s' cannot be used to handle failures", action.Type)
+	}
+	if action.Compensate != nil {
+		return fmt.Errorf("nested 'compensate' is not supported")
+	}
+	return validateActionConfig(action)
+}
@@ -0,0 +1,53 @@
+// services/task-orchestrator-service/internal/engine/wait_action.go
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// executeDelay suspende la ejecución durante la duración indicada.
+// Config: {"duration": "24h"} (formato de Go: "90s", "30m", "24h"; admite plantillas).
+// La espera no retiene ninguna goroutine: el estado se guarda y el scheduler
+// reanuda la ejecución cuando vence.
+func (r *runner) executeDelay(action workflow.ActionDefinition) (interface{}, error) {
+	raw, _ := action.Config["duration"].(string)
+	if raw == "" {
+		return nil, fmt.Errorf("action '%s': 'duration' is required for delay", action.Name)
+	}
+	rendered, err := renderText("duration", raw, r.templateData())
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': invalid 'duration' template: %w", action.Name, err)
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(rendered))
+	if err != nil || duration < 0 {
+		return nil, fmt.Errorf("action '%s': invalid duration %q", action.Name, rendered)
+	}
+	return nil, &suspension{kind: "delay", until: time.Now().UTC().Add(duration)}
+}
+
+// executeWaitUntil suspende la ejecución hasta un instante concreto.
+// Config: {"until": "2025-01-31T09:00:00Z"} o una plantilla que produzca un RFC 3339,
+// ej. "{{ .Steps.fetch.body.due_at }}". Si el instante ya pasó, continúa sin esperar.
+func (r *runner) executeWaitUntil(action workflow.ActionDefinition) (interface{}, error) {
+	raw, _ := action.Config["until"].(string)
+	if raw == "" {
+		return nil, fmt.Errorf("action '%s': 'until' is required for wait_until", action.Name)
+	}
+	rendered, err := renderText("until", raw, r.templateData())
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': invalid 'until' template: %w", action.Name, err)
+	}
+	until, err := time.Parse(time.RFC3339, strings.TrimSpace(rendered))
+	if err != nil {
+		return nil, fmt.Errorf("action '%s': 'until' must be an RFC 3339 timestamp, got %q", action.Name, rendered)
+	}
+	if !until.After(time.Now()) {
+		r.addLog(fmt.Sprintf("wait_until '%s' is already in the past, continuing", until.Format(time.RFC3339)), "INFO")
+		return map[string]interface{}{"waited_until": until.UTC(), "resumed_at": time.Now().UTC()}, nil
+	}
+	return nil, &suspension{kind: "wait_until", until: until.UTC()}
+}
@@ -0,0 +1,132 @@
+// services/task-orchestrator-service/internal/handlers/approval_handler.go
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/engine"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
+)
+
+// approvalPage es la página que abren los enlaces de los correos. El GET no
+// decide nada (los escáneres de correo siguen enlaces); la decisión se envía con POST.
+var approvalPage = template.Must(template.New("approval").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Approval</title></head>
+<body>
+{{if .Done}}<p>{{.Done}}</p>{{else}}
+<form method="POST">
+<p>Confirm decision: <strong>{{.Decision}}</strong></p>
+<input type="hidden" name="decision" value="{{.Decision}}">
+<p><textarea name="comment" rows="4" cols="50" placeholder="Comment (optional)"></textarea></p>
+<p><button type="submit">{{.Decision}}</button></p>
+</form>{{end}}
+</body></html>`))
+
+// ApprovalHandler gestiona las decisiones sobre pasos de aprobación.
+type ApprovalHandler struct {
+	Store workflow.Store
+}
+
+// NewApprovalHandler crea una nueva instancia de ApprovalHandler.
+func NewApprovalHandler(store workflow.Store) *ApprovalHandler {
+	return &ApprovalHandler{Store: store}
+}
+
+// ApprovalLinkPageHandler muestra la confirmación de un enlace firmado.
+func (h *ApprovalHandler) ApprovalLinkPageHandler(c *gin.Context) {
+	if _, err := engine.ParseApprovalToken(c.Param("token")); err != nil {
+		c.String(http.StatusForbidden, "Invalid approval link")
+		return
+	}
+	decision := c.Query("decision")
+	if decision != "approve" && decision != "reject" {
+		c.String(http.StatusBadRequest, "Invalid decision")
+		return
+	}
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	approvalPage.Execute(c.Writer, gin.H{"Decision": decision})
+}
+
+// ApprovalLinkDecisionHandler registra la decisión enviada desde un enlace firmado.
+// El enlace es de un solo uso: una vez decidida la aprobación deja de ser válido.
+func (h *ApprovalHandler) ApprovalLinkDecisionHandler(c *gin.Context) {
+	claims, err := engine.ParseApprovalToken(c.Param("token"))
+	if err != nil {
+		c.String(http.StatusForbidden, "Invalid approval link")
+		return
+	}
+	var req transport.ApprovalDecisionRequest
+	if err := c.ShouldBind(&req); err != nil || validate.Struct(req) != nil {
+		c.String(http.StatusBadRequest, "Invalid decision")
+		return
+	}
+
+	decision := engine.ApprovalDecision{Decision: decisionValue(req.Decision), DecidedBy: claims.Approver, Comment: req.Comment, Via: "link"}
+	if err := engine.DecideApproval(c.Request.Context(), h.Store, claims.ExecutionID, "", claims.Step, claims.Nonce, decision); err != nil {
+		h.writeDecisionError(c, err, true)
+		return
+	}
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	approvalPage.Execute(c.Writer, gin.H{"Done": "Decision recorded: " + decision.Decision})
+}
+
+// ExecutionApprovalHandler aprueba o rechaza la aprobación pendiente de una
+// ejecución del usuario autenticado.
+func (h *ApprovalHandler) ExecutionApprovalHandler(c *gin.Context) {
+	executionID, err := uuid.Parse(c.Param("execution_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid execution ID format"})
+		return
+	}
+	var req transport.ApprovalDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
+		return
+	}
+
+	userIDClaim, _ := c.Get("userID")
+	decidedBy := userIDClaim.(string)
+	if username, ok := c.Get("username"); ok && username.(string) != "" {
+		decidedBy = username.(string)
+	}
+
+	decision := engine.ApprovalDecision{Decision: decisionValue(req.Decision), DecidedBy: decidedBy, Comment: req.Comment, Via: "api"}
+	if err := engine.DecideApproval(c.Request.Context(), h.Store, executionID, userIDClaim.(string), -1, "", decision); err != nil {
+		h.writeDecisionError(c, err, false)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"execution_id": executionID, "decision": decision.Decision, "decided_by": decidedBy})
+}
+
+func (h *ApprovalHandler) writeDecisionError(c *gin.Context, err error, html bool) {
+	status, message := http.StatusInternalServerError, "Failed to record decision"
+	if errors.Is(err, engine.ErrApprovalNotPending) {
+		status, message = http.StatusConflict, "Approval is no longer pending"
+	} else {
+		log.Printf("ERROR: Failed to record approval decision: %v", err)
+	}
+	if html {
+		c.String(status, message)
+		return
+	}
+	c.JSON(status, gin.H{"error": message})
+}
+
+func decisionValue(decision string) string {
+	if decision == "approve" {
+		return engine.ApprovalApproved
+	}
+	return engine.ApprovalRejected
+}
@@ -0,0 +1,52 @@
+// services/task-orchestrator-service/internal/handlers/errors.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// statusClientClosedRequest (convención de nginx) se registra cuando el cliente
+// cancela la petición; no llega a nadie, pero distingue el caso en los logs de acceso.
+const statusClientClosedRequest = 499
+
+// respondStoreError traduce un error del store a la respuesta HTTP: ErrNotFound
+// a 404, ErrForbidden a 403, ErrConflict a 409, ErrPreconditionFailed a 412 y un
+// plazo vencido a 504. El resto
+// se registra (con la ruta, que identifica el recurso) y se responde con 500 y el
+// mensaje fallback.
+func respondStoreError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, workflow.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
+	case errors.Is(err, workflow.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": errorMessage(err)})
+	case errors.Is(err, workflow.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": errorMessage(err)})
+	case errors.Is(err, workflow.ErrPreconditionFailed):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": errorMessage(err)})
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("ERROR: %s %s timed out: %v", c.Request.Method, c.Request.URL.Path, err)
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+	case errors.Is(err, context.Canceled):
+		c.AbortWithStatus(statusClientClosedRequest)
+	default:
+		log.Printf("ERROR: %s %s: %s: %v", c.Request.Method, c.Request.URL.Path, fallback, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// errorMessage pone en mayúscula la primera letra, como el resto de mensajes de la API.
+func errorMessage(err error) string {
+	msg := err.Error()
+	if msg == "" {
+		return msg
+	}
+	return strings.ToUpper(msg[:1]) + msg[1:]
+}
@@ -0,0 +1,56 @@
+// services/task-orchestrator-service/internal/handlers/etag.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// workflowETag es la versión del workflow como entity tag fuerte, ej. "3".
+func workflowETag(wf *workflow.Workflow) string {
+	return strconv.Quote(strconv.Itoa(wf.Version))
+}
+
+// setWorkflowETag añade la cabecera ETag con la versión actual del workflow.
+func setWorkflowETag(c *gin.Context, wf *workflow.Workflow) {
+	c.Header("ETag", workflowETag(wf))
+}
+
+// requireIfMatch lee la versión esperada de la cabecera If-Match, obligatoria en
+// las escrituras de workflows. "*" acepta cualquier versión y devuelve 0. Si falta
+// responde 428; si no es un ETag emitido por este servicio (p. ej. uno débil,
+// W/"3", que If-Match nunca acepta) responde 412.
+func requireIfMatch(c *gin.Context) (int, bool) {
+	return ifMatchVersion(c, true)
+}
+
+// optionalIfMatch es como requireIfMatch, pero sin cabecera devuelve 0.
+func optionalIfMatch(c *gin.Context) (int, bool) {
+	return ifMatchVersion(c, false)
+}
+
+func ifMatchVersion(c *gin.Context, required bool) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		if !required {
+			return 0, true
+		}
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the workflow ETag is required"})
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+	raw, err := strconv.Unquote(header)
+	if err == nil {
+		if version, err := strconv.Atoi(raw); err == nil && version > 0 {
+			return version, true
+		}
+	}
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": errorMessage(workflow.ErrWorkflowVersionMismatch)})
+	return 0, false
+}
@@ -0,0 +1,60 @@
+// services/task-orchestrator-service/internal/handlers/event_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/guildmember145/task-orchestrator-service/internal/engine"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
+)
+
+// EventHandler recibe eventos de sistemas externos (pasarelas de pago, CI...)
+// y reanuda las ejecuciones que los esperan con wait_for_event.
+type EventHandler struct {
+	Store workflow.Store
+}
+
+// NewEventHandler crea una nueva instancia de EventHandler.
+func NewEventHandler(store workflow.Store) *EventHandler {
+	return &EventHandler{Store: store}
+}
+
+// DeliverEventHandler entrega un evento a las ejecuciones del workflow cuyo token
+// firmado llega en la cabecera X-Event-Token. Responde 404 si ninguna ejecución lo
+// esperaba, para que el emisor pueda reintentar si llegó antes de tiempo.
+func (h *EventHandler) DeliverEventHandler(c *gin.Context) {
+	claims, err := engine.ParseEventToken(c.GetHeader(engine.EventTokenHeader))
+	if errors.Is(err, engine.ErrEventsOff) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Event delivery is not configured"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid event token"})
+		return
+	}
+
+	var req transport.DeliverEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
+		return
+	}
+
+	eventName := c.Param("name")
+	resumed, err := engine.DeliverEvent(c.Request.Context(), h.Store, *claims, eventName, req.CorrelationKey, req.Payload)
+	if err != nil {
+		respondStoreError(c, err, "Failed to deliver event")
+		return
+	}
+	if resumed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No execution is waiting for this event", "resumed": 0})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"event": eventName, "resumed": resumed})
+}
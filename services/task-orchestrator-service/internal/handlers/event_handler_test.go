@@ -0,0 +1,50 @@
+// services/task-orchestrator-service/internal/handlers/event_handler_test.go
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/guildmember145/task-orchestrator-service/internal/engine"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/config"
+)
+
+func TestDeliverEventReadsTheTokenHeader(t *testing.T) {
+	previous := config.AppConfig.EventsSigningKey
+	config.AppConfig.EventsSigningKey = "test-key"
+	t.Cleanup(func() { config.AppConfig.EventsSigningKey = previous })
+
+	gin.SetMode(gin.TestMode)
+	store := workflow.NewInMemoryWorkflowStore()
+	router := gin.New()
+	router.POST("/events/:name", NewEventHandler(store).DeliverEventHandler)
+
+	wf := newTestWorkflow("events")
+	saveTestWorkflow(t, store, wf)
+	token, err := engine.EventsToken(*wf)
+	if err != nil {
+		t.Fatalf("EventsToken: %v", err)
+	}
+
+	body := `{"correlation_key": "order-42", "payload": {"paid": true}}`
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"forged token", "e30.AAAA", http.StatusUnauthorized},
+		// El token es válido pero ninguna ejecución espera el evento.
+		{"workflow token", token, http.StatusNotFound},
+	} {
+		headers := map[string]string{"Content-Type": "application/json"}
+		if tc.token != "" {
+			headers[engine.EventTokenHeader] = tc.token
+		}
+		if rec := doRequest(router, http.MethodPost, "/events/payment.settled", body, headers); rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d (body %s)", tc.name, rec.Code, tc.want, rec.Body)
+		}
+	}
+}
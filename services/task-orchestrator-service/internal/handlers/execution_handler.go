@@ -0,0 +1,276 @@
+// services/task-orchestrator-service/internal/handlers/execution_handler.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/engine"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// Intervalo de keep-alive del stream; también sirve de sondeo de respaldo si se pierde un NOTIFY.
+const logStreamKeepAlive = 15 * time.Second
+
+// ExecutionHandler contiene las dependencias para los manejadores de ejecuciones.
+type ExecutionHandler struct {
+	Store    workflow.Store
+	Notifier workflow.ExecutionNotifier
+}
+
+// NewExecutionHandler crea una nueva instancia de ExecutionHandler.
+func NewExecutionHandler(store workflow.Store, notifier workflow.ExecutionNotifier) *ExecutionHandler {
+	return &ExecutionHandler{Store: store, Notifier: notifier}
+}
+
+// executionStatuses son los estados por los que se puede filtrar.
+var executionStatuses = map[string]bool{"running": true, "suspended": true, "completed": true, "failed": true}
+
+// parseExecutionFilter lee los filtros comunes de los listados de ejecuciones:
+// status (lista separada por comas), source, from y to (RFC 3339), limit y cursor.
+func parseExecutionFilter(c *gin.Context) (workflow.ExecutionFilter, error) {
+	var filter workflow.ExecutionFilter
+
+	if raw := c.Query("status"); raw != "" {
+		for _, status := range strings.Split(raw, ",") {
+			status = strings.TrimSpace(status)
+			if !executionStatuses[status] {
+				return filter, fmt.Errorf("invalid status '%s'", status)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	filter.TriggerSource = c.Query("source")
+
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := c.Query(name); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return filter, fmt.Errorf("invalid '%s': expected RFC 3339 timestamp", name)
+			}
+			*target = &t
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, fmt.Errorf("'from' must be before 'to'")
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return filter, fmt.Errorf("invalid 'limit'")
+		}
+		filter.Limit = limit
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := workflow.DecodeExecutionCursor(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid 'cursor'")
+		}
+		filter.After = cursor
+	}
+	return filter, nil
+}
+
+// writeExecutionPage responde con una página de ejecuciones y el cursor de la
+// siguiente (vacío si no hay más). El store devuelve un elemento de más para saberlo.
+func writeExecutionPage(c *gin.Context, executions []*workflow.ExecutionLog, filter workflow.ExecutionFilter) {
+	if executions == nil {
+		executions = []*workflow.ExecutionLog{}
+	}
+	nextCursor := ""
+	if size := filter.PageSize(); len(executions) > size {
+		executions = executions[:size]
+		last := executions[size-1]
+		nextCursor = workflow.ExecutionCursor{TriggeredAt: last.TriggeredAt, ID: last.ID}.Encode()
+	}
+	c.JSON(http.StatusOK, gin.H{"items": executions, "next_cursor": nextCursor})
+}
+
+// ListExecutionsHandler lista las ejecuciones de todos los workflows del usuario.
+func (h *ExecutionHandler) ListExecutionsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	filter, err := parseExecutionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if raw := c.Query("workflow_id"); raw != "" {
+		workflowID, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID format"})
+			return
+		}
+		filter.WorkflowID = &workflowID
+	}
+
+	executions, err := h.Store.ListExecutions(c.Request.Context(), userIDClaim.(string), filter)
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve executions")
+		return
+	}
+	writeExecutionPage(c, executions, filter)
+}
+
+// GetExecutionHandler devuelve una ejecución con sus logs y resultados por paso.
+func (h *ExecutionHandler) GetExecutionHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	execution, ok := h.loadExecution(c, userIDClaim.(string))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, execution)
+}
+
+// loadExecution resuelve la ejecución del parámetro :execution_id y responde el
+// error correspondiente si no existe o no pertenece al usuario.
+func (h *ExecutionHandler) loadExecution(c *gin.Context, userID string) (*workflow.ExecutionLog, bool) {
+	executionID, err := uuid.Parse(c.Param("execution_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid execution ID format"})
+		return nil, false
+	}
+	execution, err := h.Store.GetExecutionByID(c.Request.Context(), userID, executionID)
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve execution")
+		return nil, false
+	}
+	return execution, true
+}
+
+// RerunExecutionHandler lanza una ejecución nueva con las mismas entradas y
+// payload que la indicada, usando la definición actual del workflow.
+func (h *ExecutionHandler) RerunExecutionHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	userID := userIDClaim.(string)
+	original, ok := h.loadExecution(c, userID)
+	if !ok {
+		return
+	}
+	wf, err := h.Store.GetWorkflowByID(c.Request.Context(), userID, original.WorkflowID)
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve workflow")
+		return
+	}
+
+	execution, err := engine.RerunExecution(c.Request.Context(), original, *wf, h.Store)
+	if err != nil {
+		respondRunError(c, original, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, execution)
+}
+
+// ResumeExecutionHandler continúa una ejecución fallida desde el paso que falló,
+// reutilizando las salidas de los pasos que ya terminaron bien.
+func (h *ExecutionHandler) ResumeExecutionHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	original, ok := h.loadExecution(c, userIDClaim.(string))
+	if !ok {
+		return
+	}
+
+	execution, err := engine.ResumeFailedExecution(c.Request.Context(), original, h.Store)
+	if err != nil {
+		respondRunError(c, original, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, execution)
+}
+
+func respondRunError(c *gin.Context, original *workflow.ExecutionLog, err error) {
+	switch {
+	case errors.Is(err, engine.ErrNoExecutionState), errors.Is(err, engine.ErrExecutionNotFailed),
+		errors.Is(err, engine.ErrExecutionCompensated), errors.Is(err, engine.ErrNothingToResume):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		respondStoreError(c, err, "Failed to start execution")
+	}
+}
+
+// StreamExecutionLogsHandler envía por Server-Sent Events los logs guardados de una
+// ejecución y sigue enviando los nuevos hasta que termina. Cada evento `log` lleva
+// como id su posición, de modo que el cliente puede reconectar con Last-Event-ID.
+func (h *ExecutionHandler) StreamExecutionLogsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	userID := userIDClaim.(string)
+	executionID, err := uuid.Parse(c.Param("execution_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid execution ID format"})
+		return
+	}
+
+	offset := 0
+	if lastID := c.GetHeader("Last-Event-ID"); lastID != "" {
+		if n, err := strconv.Atoi(lastID); err == nil && n >= 0 {
+			offset = n + 1
+		}
+	}
+
+	// Suscribirse antes de la primera lectura para no perder avisos intermedios.
+	var updates <-chan struct{}
+	if h.Notifier != nil {
+		var unsubscribe func()
+		updates, unsubscribe = h.Notifier.Subscribe(executionID)
+		defer unsubscribe()
+	}
+
+	entries, status, err := h.Store.GetExecutionLogsSince(c.Request.Context(), userID, executionID, offset)
+	if err != nil {
+		respondStoreError(c, err, "Failed to load execution logs")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	keepAlive := time.NewTicker(logStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		for _, entry := range entries {
+			fmt.Fprintf(c.Writer, "id: %d\nevent: log\ndata: %s\n\n", offset, entry)
+			offset++
+		}
+		if status != "running" && status != "suspended" {
+			end, _ := json.Marshal(gin.H{"status": status})
+			fmt.Fprintf(c.Writer, "event: end\ndata: %s\n\n", end)
+			c.Writer.Flush()
+			return
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-updates:
+		case <-keepAlive.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+
+		entries, status, err = h.Store.GetExecutionLogsSince(c.Request.Context(), userID, executionID, offset)
+		if err != nil {
+			if c.Request.Context().Err() != nil {
+				return
+			}
+			log.Printf("ERROR: Failed to load logs for execution %s: %v", executionID, err)
+			fmt.Fprintf(c.Writer, "event: error\ndata: %q\n\n", "failed to load execution logs")
+			c.Writer.Flush()
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// services/task-orchestrator-service/internal/handlers/secret_handler.go
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/guildmember145/task-orchestrator-service/internal/secret"
+	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
+)
+
+// SecretHandler expone la gestión de secretos. Los valores solo se escriben;
+// nunca se devuelven en las respuestas.
+type SecretHandler struct {
+	Store secret.Store
+}
+
+// NewSecretHandler crea una nueva instancia de SecretHandler.
+func NewSecretHandler(store secret.Store) *SecretHandler {
+	return &SecretHandler{Store: store}
+}
+
+// SaveSecretHandler crea o reemplaza un secreto del usuario.
+func (h *SecretHandler) SaveSecretHandler(c *gin.Context) {
+	var req transport.SaveSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
+		return
+	}
+
+	userIDClaim, _ := c.Get("userID")
+	sec, err := h.Store.SaveSecret(c.Request.Context(), userIDClaim.(string), req.Name, req.Value)
+	if err != nil {
+		if errors.Is(err, secret.ErrNoCipherKey) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Secret storage is not configured"})
+			return
+		}
+		log.Printf("ERROR: Failed to save secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save secret"})
+		return
+	}
+	c.JSON(http.StatusOK, sec)
+}
+
+// GetSecretsHandler lista los nombres de los secretos del usuario.
+func (h *SecretHandler) GetSecretsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	secrets, err := h.Store.ListSecrets(c.Request.Context(), userIDClaim.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve secrets"})
+		return
+	}
+	c.JSON(http.StatusOK, secrets)
+}
+
+// DeleteSecretHandler elimina un secreto por nombre.
+func (h *SecretHandler) DeleteSecretHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	if err := h.Store.DeleteSecret(c.Request.Context(), userIDClaim.(string), c.Param("name")); err != nil {
+		if errors.Is(err, secret.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Secret not found"})
+			return
+		}
+		log.Printf("ERROR: Failed to delete secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete secret"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
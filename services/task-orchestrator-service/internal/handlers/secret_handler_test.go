@@ -0,0 +1,50 @@
+// services/task-orchestrator-service/internal/handlers/secret_handler_test.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/guildmember145/task-orchestrator-service/internal/secret"
+)
+
+// failingSecretStore simula una base de datos caída en el borrado.
+type failingSecretStore struct {
+	secret.Store
+}
+
+func (failingSecretStore) DeleteSecret(context.Context, string, string) error {
+	return errors.New("connection refused")
+}
+
+func TestDeleteSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cipher, err := secret.NewCipher("test-key")
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	store := secret.NewInMemorySecretStore(cipher)
+	if _, err := store.SaveSecret(context.Background(), testUserID, "db", "postgres://"); err != nil {
+		t.Fatalf("SaveSecret: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		store secret.Store
+		want  int
+	}{
+		{"existing", store, http.StatusNoContent},
+		{"already deleted", store, http.StatusNotFound},
+		{"database failure", failingSecretStore{store}, http.StatusInternalServerError},
+	} {
+		router := gin.New()
+		router.Use(func(c *gin.Context) { c.Set("userID", testUserID) })
+		router.DELETE("/secrets/:name", NewSecretHandler(tc.store).DeleteSecretHandler)
+		if rec := doRequest(router, http.MethodDelete, "/secrets/db", "", nil); rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+}
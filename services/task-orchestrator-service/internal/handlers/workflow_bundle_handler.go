@@ -0,0 +1,335 @@
+// services/task-orchestrator-service/internal/handlers/workflow_bundle_handler.go
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/bundle"
+	"github.com/guildmember145/task-orchestrator-service/internal/engine"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
+)
+
+// Estrategias de POST /workflows/import cuando ya existe un workflow con el mismo nombre.
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictRename    = "rename"
+)
+
+// Acciones de cada workflow del bundle en la respuesta de la importación.
+const (
+	importCreate    = "create"
+	importOverwrite = "overwrite"
+	importRename    = "rename"
+	importSkip      = "skip"
+	importUnchanged = "unchanged"
+)
+
+// ExportWorkflowsHandler descarga un bundle con los workflows indicados en id
+// (repetible) o, si no se indica ninguno, con los que cumplen los filtros del
+// listado. format elige yaml (por defecto) o json. Los workflows se ordenan por
+// carpeta y nombre para que dos exportaciones iguales produzcan el mismo fichero.
+func (h *WorkflowHandler) ExportWorkflowsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	userID := userIDClaim.(string)
+
+	format := c.DefaultQuery("format", bundle.FormatYAML)
+	contentType := map[string]string{bundle.FormatYAML: "application/yaml", bundle.FormatJSON: "application/json"}[format]
+	if contentType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'format': expected yaml or json"})
+		return
+	}
+
+	var workflows []*workflow.Workflow
+	if rawIDs := c.QueryArray("id"); len(rawIDs) > 0 {
+		for _, raw := range rawIDs {
+			workflowID, err := uuid.Parse(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID format"})
+				return
+			}
+			wf, err := h.Store.GetWorkflowByID(c.Request.Context(), userID, workflowID)
+			if err != nil {
+				respondStoreError(c, err, "Failed to retrieve workflow")
+				return
+			}
+			workflows = append(workflows, wf)
+		}
+	} else {
+		filter, err := parseWorkflowFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if workflows, err = h.Store.ListWorkflows(c.Request.Context(), userID, filter); err != nil {
+			respondStoreError(c, err, "Failed to retrieve workflows")
+			return
+		}
+	}
+	sort.SliceStable(workflows, func(i, j int) bool {
+		if workflows[i].Folder != workflows[j].Folder {
+			return workflows[i].Folder < workflows[j].Folder
+		}
+		return workflows[i].Name < workflows[j].Name
+	})
+
+	b := bundle.New()
+	for _, wf := range workflows {
+		b.Workflows = append(b.Workflows, bundle.Workflow{
+			CreateWorkflowRequest: workflowRequestFrom(wf),
+			Secrets:               engine.SecretReferences(wf.Actions, wf.OnFailure),
+		})
+	}
+	data, err := bundle.Encode(b, format)
+	if err != nil {
+		log.Printf("ERROR: failed to encode workflow bundle: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export workflows"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="workflows.%s"`, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// ImportWorkflowsHandler importa un bundle (JSON o YAML) en la cuenta del usuario.
+// Los workflows se identifican por nombre: on_conflict decide qué hacer si ya existe
+// uno (skip, por defecto; overwrite, que guarda una versión nueva; o rename, que crea
+// otro con el sufijo " (2)", " (3)"...). Un workflow idéntico al existente queda
+// "unchanged", así que importar dos veces el mismo bundle no crea versiones.
+// Con dry_run=true solo devuelve el plan. Los cambios se aplican en una sola
+// transacción: si algún workflow no es válido o falla al guardarse no se importa ninguno.
+func (h *WorkflowHandler) ImportWorkflowsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	userID := userIDClaim.(string)
+
+	strategy := c.DefaultQuery("on_conflict", conflictSkip)
+	if strategy != conflictSkip && strategy != conflictOverwrite && strategy != conflictRename {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'on_conflict': expected skip, overwrite or rename"})
+		return
+	}
+	dryRun := false
+	if raw := c.Query("dry_run"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'dry_run': expected true or false"})
+			return
+		}
+	}
+
+	body, err := c.GetRawData()
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body must be a workflow bundle"})
+		return
+	}
+	b, err := bundle.Decode(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(err)})
+		return
+	}
+
+	existing, err := h.Store.ListWorkflows(c.Request.Context(), userID, workflow.WorkflowFilter{})
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve workflows")
+		return
+	}
+	available, err := h.secretNames(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("ERROR: failed to list secrets for import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve secrets"})
+		return
+	}
+
+	results, targets, valid := planImport(b, userID, existing, available, strategy)
+	resp := transport.ImportWorkflowsResponse{DryRun: dryRun, OnConflict: strategy, Results: results}
+	if !valid {
+		resp.Error = "Bundle contains invalid workflows; nothing was imported"
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	var changes []workflow.WorkflowChange
+	var changed []int // Índice en results de cada cambio
+	for i, wf := range targets {
+		if wf != nil {
+			changes = append(changes, workflow.WorkflowChange{Save: wf})
+			changed = append(changed, i)
+		}
+	}
+	if len(changes) == 0 {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if err := h.Store.ApplyWorkflowChanges(c.Request.Context(), userID, changes); err != nil {
+		log.Printf("ERROR: import failed: %v", err)
+		resp.Error = "Import failed; nothing was imported"
+		if i, changeErr := failedChange(err, changed); i >= 0 {
+			results[i].Error = errorMessage(changeErr)
+			resp.Error = fmt.Sprintf("Import failed at workflow '%s'; nothing was imported", results[i].Name)
+		}
+		c.JSON(batchErrorStatus(err), resp)
+		return
+	}
+	for i, wf := range targets {
+		if wf != nil {
+			results[i].WorkflowID = &wf.ID
+		}
+	}
+	h.Scheduler.ReloadAndRescheduleWorkflows()
+	c.JSON(http.StatusOK, resp)
+}
+
+// failedChange devuelve el índice en results del cambio que hizo fallar el lote
+// (resultIndex[n] es el resultado del cambio n) y su error, o -1 si el fallo no
+// se debe a un cambio concreto.
+func failedChange(err error, resultIndex []int) (int, error) {
+	var changeErr *workflow.WorkflowChangeError
+	if errors.As(err, &changeErr) && changeErr.Index < len(resultIndex) {
+		return resultIndex[changeErr.Index], changeErr.Err
+	}
+	return -1, err
+}
+
+// batchErrorStatus responde 409 si el lote falló porque otra petición cambió los
+// workflows desde que se calculó el plan, y 500 en otro caso.
+func batchErrorStatus(err error) int {
+	if errors.Is(err, workflow.ErrConflict) || errors.Is(err, workflow.ErrPreconditionFailed) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// secretNames devuelve los nombres de los secretos del usuario, o nil si el
+// servicio no tiene store de secretos (en ese caso no se avisa de los que faltan).
+func (h *WorkflowHandler) secretNames(ctx context.Context, userID string) (map[string]bool, error) {
+	if h.Secrets == nil {
+		return nil, nil
+	}
+	secrets, err := h.Secrets.ListSecrets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(secrets))
+	for _, s := range secrets {
+		names[s.Name] = true
+	}
+	return names, nil
+}
+
+// planImport decide qué hacer con cada workflow del bundle frente a los existentes.
+// Para los que hay que guardar devuelve en targets el workflow ya preparado (con la
+// versión leída, para que el guardado detecte ediciones concurrentes); nil si no
+// hay nada que guardar. valid es false si algún workflow tiene errores.
+func planImport(b *bundle.Bundle, userID string, existing []*workflow.Workflow, secrets map[string]bool, strategy string) ([]transport.ImportWorkflowResult, []*workflow.Workflow, bool) {
+	byName := map[string][]*workflow.Workflow{}
+	taken := map[string]bool{}
+	for _, wf := range existing {
+		byName[wf.Name] = append(byName[wf.Name], wf)
+		taken[wf.Name] = true
+	}
+
+	results := make([]transport.ImportWorkflowResult, len(b.Workflows))
+	targets := make([]*workflow.Workflow, len(b.Workflows))
+	seen := map[string]bool{}
+	valid := true
+	now := time.Now().UTC()
+
+	for i, item := range b.Workflows {
+		req := item.CreateWorkflowRequest
+		result := &results[i]
+		result.Name = req.Name
+
+		var err error
+		if result.MissingSecrets, err = checkBundleWorkflow(req, seen, secrets); err != nil {
+			result.Error, valid = err.Error(), false
+			continue
+		}
+
+		matches := byName[req.Name]
+		if len(matches) == 1 && sameDefinition(matches[0], req) {
+			result.Action, result.WorkflowID = importUnchanged, &matches[0].ID
+			continue
+		}
+		switch {
+		case len(matches) == 0:
+			result.Action = importCreate
+			targets[i] = &workflow.Workflow{ID: uuid.New(), UserID: userID, CreatedAt: now}
+		case strategy == conflictSkip:
+			result.Action, result.WorkflowID = importSkip, &matches[0].ID
+		case strategy == conflictRename:
+			name := req.Name
+			for n := 2; taken[name]; n++ {
+				name = nameWithSuffix(req.Name, fmt.Sprintf(" (%d)", n))
+			}
+			taken[name] = true
+			result.Action, result.ImportedAs = importRename, name
+			req.Name = name
+			targets[i] = &workflow.Workflow{ID: uuid.New(), UserID: userID, CreatedAt: now}
+		case len(matches) > 1:
+			result.Error, valid = fmt.Sprintf("%d existing workflows are named '%s'; cannot choose one to overwrite", len(matches), req.Name), false
+			continue
+		case matches[0].ManagedBy != "":
+			result.Error, valid = fmt.Sprintf("workflow '%s' is managed by '%s' and cannot be overwritten", req.Name, matches[0].ManagedBy), false
+			continue
+		default:
+			cp := *matches[0]
+			result.Action, result.WorkflowID = importOverwrite, &cp.ID
+			targets[i] = &cp
+		}
+		if targets[i] != nil {
+			applyWorkflowRequest(targets[i], req)
+		}
+	}
+	return results, targets, valid
+}
+
+// checkBundleWorkflow valida un workflow del bundle, comprueba que su nombre no se
+// repita (seen acumula los ya vistos) y devuelve los secretos que referencia y no
+// existen (ninguno si secrets es nil).
+func checkBundleWorkflow(req transport.CreateWorkflowRequest, seen, secrets map[string]bool) ([]string, error) {
+	if err := workflowRequestError(req); err != nil {
+		return nil, err
+	}
+	if seen[req.Name] {
+		return nil, fmt.Errorf("workflow '%s' appears more than once in the bundle", req.Name)
+	}
+	seen[req.Name] = true
+	var missing []string
+	if secrets != nil {
+		for _, name := range engine.SecretReferences(req.Actions, req.OnFailure) {
+			if !secrets[name] {
+				missing = append(missing, name)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// sameDefinition indica si guardar req no cambiaría nada en wf.
+func sameDefinition(wf *workflow.Workflow, req transport.CreateWorkflowRequest) bool {
+	failFast := req.FailFastOrDefault()
+	req.FailFast = &failFast
+	current, err := json.Marshal(workflowRequestFrom(wf))
+	if err != nil {
+		return false
+	}
+	incoming, err := json.Marshal(req)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(current, incoming)
+}
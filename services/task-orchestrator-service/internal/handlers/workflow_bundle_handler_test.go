@@ -0,0 +1,151 @@
+// services/task-orchestrator-service/internal/handlers/workflow_bundle_handler_test.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/bundle"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
+)
+
+func bundleOf(reqs ...transport.CreateWorkflowRequest) *bundle.Bundle {
+	b := bundle.New()
+	for _, req := range reqs {
+		b.Workflows = append(b.Workflows, bundle.Workflow{CreateWorkflowRequest: req})
+	}
+	return b
+}
+
+// changedRequest devuelve la definición de wf con otra descripción.
+func changedRequest(wf *workflow.Workflow) transport.CreateWorkflowRequest {
+	req := workflowRequestFrom(wf)
+	req.Description = "changed by the bundle"
+	return req
+}
+
+func TestPlanImport(t *testing.T) {
+	alpha := newTestWorkflow("alpha", logMessageAction("greet", "hi"))
+	alpha.Version = 3
+	alphaTaken := newTestWorkflow("alpha (2)", logMessageAction("greet", "hi"))
+	managed := newTestWorkflow("managed", logMessageAction("greet", "hi"))
+	managed.ManagedBy = "git"
+	dupA := newTestWorkflow("dup", logMessageAction("greet", "a"))
+	dupB := newTestWorkflow("dup", logMessageAction("greet", "b"))
+	existing := []*workflow.Workflow{alpha, alphaTaken, managed, dupA, dupB}
+
+	fresh := workflowRequestFrom(newTestWorkflow("fresh", logMessageAction("greet", "hi")))
+	invalid := workflowRequestFrom(newTestWorkflow("invalid"))
+
+	type want struct {
+		action, importedAs, err string
+		saves                   bool
+	}
+	for _, tc := range []struct {
+		name      string
+		strategy  string
+		reqs      []transport.CreateWorkflowRequest
+		want      []want
+		wantValid bool
+	}{
+		{"create", conflictSkip, []transport.CreateWorkflowRequest{fresh}, []want{{action: importCreate, saves: true}}, true},
+		{"unchanged", conflictOverwrite, []transport.CreateWorkflowRequest{workflowRequestFrom(alpha)}, []want{{action: importUnchanged}}, true},
+		{"skip", conflictSkip, []transport.CreateWorkflowRequest{changedRequest(alpha)}, []want{{action: importSkip}}, true},
+		{"overwrite", conflictOverwrite, []transport.CreateWorkflowRequest{changedRequest(alpha)}, []want{{action: importOverwrite, saves: true}}, true},
+		{"rename past taken names", conflictRename, []transport.CreateWorkflowRequest{changedRequest(alpha)}, []want{{action: importRename, importedAs: "alpha (3)", saves: true}}, true},
+		{"overwrite managed", conflictOverwrite, []transport.CreateWorkflowRequest{changedRequest(managed)}, []want{{err: "is managed by 'git'"}}, false},
+		{"overwrite ambiguous", conflictOverwrite, []transport.CreateWorkflowRequest{changedRequest(dupA)}, []want{{err: "2 existing workflows are named 'dup'"}}, false},
+		{"repeated in bundle", conflictSkip, []transport.CreateWorkflowRequest{fresh, fresh}, []want{{action: importCreate, saves: true}, {err: "appears more than once"}}, false},
+		{"invalid", conflictSkip, []transport.CreateWorkflowRequest{invalid, fresh}, []want{{err: "validation failed"}, {action: importCreate, saves: true}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			results, targets, valid := planImport(bundleOf(tc.reqs...), testUserID, existing, nil, tc.strategy)
+			if valid != tc.wantValid {
+				t.Errorf("valid = %v, want %v", valid, tc.wantValid)
+			}
+			if len(results) != len(tc.want) || len(targets) != len(tc.want) {
+				t.Fatalf("got %d results and %d targets, want %d", len(results), len(targets), len(tc.want))
+			}
+			for i, w := range tc.want {
+				got := results[i]
+				if got.Action != w.action || got.ImportedAs != w.importedAs {
+					t.Errorf("result %d = %s/%q, want %s/%q", i, got.Action, got.ImportedAs, w.action, w.importedAs)
+				}
+				if w.err == "" && got.Error != "" || w.err != "" && !strings.Contains(strings.ToLower(got.Error), strings.ToLower(w.err)) {
+					t.Errorf("result %d error = %q, want %q", i, got.Error, w.err)
+				}
+				if (targets[i] != nil) != w.saves {
+					t.Errorf("result %d target = %v, want saves=%v", i, targets[i], w.saves)
+				}
+			}
+		})
+	}
+
+	t.Run("overwrite keeps identity and version", func(t *testing.T) {
+		_, targets, _ := planImport(bundleOf(changedRequest(alpha)), testUserID, existing, nil, conflictOverwrite)
+		target := targets[0]
+		if target.ID != alpha.ID || target.Version != alpha.Version || target.Description != "changed by the bundle" {
+			t.Errorf("target = %+v; want alpha's id and version 3 with the new description", target)
+		}
+		if alpha.Description == "changed by the bundle" {
+			t.Errorf("planImport modified the existing workflow")
+		}
+	})
+	t.Run("created workflows belong to the user", func(t *testing.T) {
+		_, targets, _ := planImport(bundleOf(changedRequest(alpha)), testUserID, existing, nil, conflictRename)
+		if target := targets[0]; target.ID == alpha.ID || target.UserID != testUserID || target.Version != 0 || target.Name != "alpha (3)" {
+			t.Errorf("renamed target = %+v", target)
+		}
+	})
+}
+
+// staleStore simula que otra petición guarda un workflow justo después de que el
+// handler calcule el plan, para que el lote falle a mitad.
+type staleStore struct {
+	workflow.Store
+	touch *workflow.Workflow
+}
+
+func (s *staleStore) ListWorkflows(ctx context.Context, userID string, filter workflow.WorkflowFilter) ([]*workflow.Workflow, error) {
+	workflows, err := s.Store.ListWorkflows(ctx, userID, filter)
+	if err == nil {
+		cp := *s.touch
+		cp.Version = 0
+		err = s.Store.SaveWorkflow(ctx, &cp)
+	}
+	return workflows, err
+}
+
+func TestImportIsAllOrNothing(t *testing.T) {
+	h, router := newTestHandler(t)
+	router.POST("/workflows/import", h.ImportWorkflowsHandler)
+
+	alpha := newTestWorkflow("alpha", logMessageAction("greet", "hi"))
+	saveTestWorkflow(t, h.Store, alpha)
+	h.Store = &staleStore{Store: h.Store, touch: alpha}
+
+	fresh := workflowRequestFrom(newTestWorkflow("fresh", logMessageAction("greet", "hi")))
+	body, _ := json.Marshal(bundleOf(fresh, changedRequest(alpha)))
+	rec := doRequest(router, http.MethodPost, "/workflows/import?on_conflict=overwrite", string(body), nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, body %s; want 409", rec.Code, rec.Body)
+	}
+	var resp transport.ImportWorkflowsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response: %v", err)
+	}
+	if !strings.Contains(resp.Error, "'alpha'") || resp.Results[1].Error == "" {
+		t.Errorf("response does not point at the stale workflow: %+v", resp)
+	}
+
+	all, _ := h.Store.ListWorkflows(context.Background(), testUserID, workflow.WorkflowFilter{})
+	for _, wf := range all {
+		if wf.Name == "fresh" {
+			t.Fatalf("workflow created earlier in the batch was kept after the import failed")
+		}
+	}
+}
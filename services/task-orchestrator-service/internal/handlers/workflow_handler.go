@@ -4,14 +4,17 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 	"log"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/engine"
 	"github.com/guildmember145/task-orchestrator-service/internal/scheduler"
+	"github.com/guildmember145/task-orchestrator-service/internal/secret"
 	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
 	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
 )
@@ -22,11 +25,12 @@ var validate = validator.New()
 type WorkflowHandler struct {
 	Store     workflow.Store
 	Scheduler *scheduler.Scheduler
+	Secrets   secret.Store // Para avisar de secretos que faltan al importar
 }
 
 // NewWorkflowHandler crea una nueva instancia de WorkflowHandler.
-func NewWorkflowHandler(store workflow.Store, scheduler *scheduler.Scheduler) *WorkflowHandler {
-	return &WorkflowHandler{Store: store, Scheduler: scheduler}
+func NewWorkflowHandler(store workflow.Store, scheduler *scheduler.Scheduler, secrets secret.Store) *WorkflowHandler {
+	return &WorkflowHandler{Store: store, Scheduler: scheduler, Secrets: secrets}
 }
 
 // CreateWorkflowHandler crea un nuevo workflow.
@@ -37,13 +41,8 @@ func (h *WorkflowHandler) CreateWorkflowHandler(c *gin.Context) {
 		return
 	}
 
-	// Lógica de validación en etapas (ya la tenías funcionando)
-	if err := validate.Struct(req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": "Top-level validation failed: " + err.Error()}); return }
-	if err := validate.Struct(req.Trigger); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": "Trigger validation failed: " + err.Error()}); return }
-	if len(req.Actions) > 0 {
-		for i, action := range req.Actions {
-			if err := validate.Struct(action); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Action %d validation failed: %s", i, err.Error())}); return }
-		}
+	if !validateWorkflowRequest(c, req) {
+		return
 	}
 
 	userIDClaim, _ := c.Get("userID")
@@ -53,32 +52,136 @@ func (h *WorkflowHandler) CreateWorkflowHandler(c *gin.Context) {
     }
 
 	newWorkflow := &workflow.Workflow{
-		ID:          uuid.New(),
-		UserID:      userID.String(), // Convertimos UUID a string
-		Name:        req.Name,
-		Description: req.Description,
-		Trigger:     req.Trigger,
-		Actions:     req.Actions,
-		IsEnabled:   req.IsEnabled,
-		CreatedAt:   time.Now().UTC(),
-		UpdatedAt:   time.Now().UTC(),
-	}
-
-	if err := h.Store.SaveWorkflow(newWorkflow); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save workflow"})
+		ID:        uuid.New(),
+		UserID:    userID.String(), // Convertimos UUID a string
+		CreatedAt: time.Now().UTC(),
+	}
+	applyWorkflowRequest(newWorkflow, req)
+
+	if err := h.Store.SaveWorkflow(c.Request.Context(), newWorkflow); err != nil {
+		respondStoreError(c, err, "Failed to save workflow")
 		return
 	}
 
 	h.Scheduler.ReloadAndRescheduleWorkflows()
+	setWorkflowETag(c, newWorkflow)
 	c.JSON(http.StatusCreated, newWorkflow)
 }
 
-// GetWorkflowsHandler lista los workflows del usuario.
+// validateWorkflowRequest aplica las comprobaciones comunes a crear, reemplazar y
+// modificar un workflow. Si alguna falla responde 400 y devuelve false.
+func validateWorkflowRequest(c *gin.Context, req transport.CreateWorkflowRequest) bool {
+	if err := workflowRequestError(req); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}); return false }
+	return true
+}
+
+// workflowRequestError valida la definición en etapas, para que el mensaje indique
+// qué parte falló.
+func workflowRequestError(req transport.CreateWorkflowRequest) error {
+	if err := validate.Struct(req); err != nil { return fmt.Errorf("Top-level validation failed: %w", err) }
+	if err := validate.Struct(req.Trigger); err != nil { return fmt.Errorf("Trigger validation failed: %w", err) }
+	for i, action := range req.Actions {
+		if err := validate.Struct(action); err != nil { return fmt.Errorf("Action %d validation failed: %w", i, err) }
+	}
+	for i, action := range req.OnFailure {
+		if err := validate.Struct(action); err != nil { return fmt.Errorf("on_failure action %d validation failed: %w", i, err) }
+	}
+	if err := engine.ValidateActions(req.Actions, req.OnFailure); err != nil { return fmt.Errorf("Action configuration invalid: %w", err) }
+	return nil
+}
+
+// applyWorkflowRequest copia en wf los campos editables de la petición y marca la
+// fecha de actualización.
+func applyWorkflowRequest(wf *workflow.Workflow, req transport.CreateWorkflowRequest) {
+	wf.Name = req.Name
+	wf.Description = req.Description
+	wf.Tags = req.Tags
+	wf.Folder = req.Folder
+	wf.Trigger = req.Trigger
+	wf.Actions = req.Actions
+	wf.OnFailure = req.OnFailure
+	wf.FailFast = req.FailFastOrDefault()
+	wf.Retention = req.Retention
+	wf.IsEnabled = req.IsEnabled
+	wf.UpdatedAt = time.Now().UTC()
+}
+
+// workflowRequestFrom es la inversa de applyWorkflowRequest: la definición
+// editable del workflow, sobre la que se aplican los PATCH.
+func workflowRequestFrom(wf *workflow.Workflow) transport.CreateWorkflowRequest {
+	failFast := wf.FailFast
+	return transport.CreateWorkflowRequest{
+		Name:        wf.Name,
+		Description: wf.Description,
+		Tags:        wf.Tags,
+		Folder:      wf.Folder,
+		Trigger:     wf.Trigger,
+		Actions:     wf.Actions,
+		OnFailure:   wf.OnFailure,
+		FailFast:    &failFast,
+		Retention:   wf.Retention,
+		IsEnabled:   wf.IsEnabled,
+	}
+}
+
+// parseWorkflowFilter lee los filtros del listado de workflows: tag (repetible,
+// "clave" o "clave:valor"), folder, trigger_type, enabled, last_run_status (lista
+// separada por comas) y q (búsqueda de texto en nombre y descripción).
+func parseWorkflowFilter(c *gin.Context) (workflow.WorkflowFilter, error) {
+	var filter workflow.WorkflowFilter
+
+	for _, raw := range c.QueryArray("tag") {
+		key, value, hasValue := strings.Cut(raw, ":")
+		if key == "" {
+			return filter, fmt.Errorf("invalid tag '%s': expected 'key' or 'key:value'", raw)
+		}
+		selector := workflow.TagSelector{Key: key}
+		if hasValue {
+			selector.Value = &value
+		}
+		filter.Tags = append(filter.Tags, selector)
+	}
+	if folder, ok := c.GetQuery("folder"); ok {
+		filter.Folder = &folder
+	}
+	if raw := c.Query("trigger_type"); raw != "" {
+		triggerType := workflow.TriggerType(raw)
+		if triggerType != workflow.TriggerTypeSchedule && triggerType != workflow.TriggerTypeWebhook {
+			return filter, fmt.Errorf("invalid trigger_type '%s'", raw)
+		}
+		filter.TriggerType = triggerType
+	}
+	if raw := c.Query("enabled"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid 'enabled': expected true or false")
+		}
+		filter.Enabled = &enabled
+	}
+	if raw := c.Query("last_run_status"); raw != "" {
+		for _, status := range strings.Split(raw, ",") {
+			status = strings.TrimSpace(status)
+			if !executionStatuses[status] {
+				return filter, fmt.Errorf("invalid last_run_status '%s'", status)
+			}
+			filter.LastRunStatuses = append(filter.LastRunStatuses, status)
+		}
+	}
+	filter.Search = strings.TrimSpace(c.Query("q"))
+	return filter, nil
+}
+
+// GetWorkflowsHandler lista los workflows del usuario, opcionalmente filtrados.
 func (h *WorkflowHandler) GetWorkflowsHandler(c *gin.Context) {
     userIDClaim, _ := c.Get("userID")
-    userWorkflows, err := h.Store.GetWorkflowsByUserID(userIDClaim.(string))
+    filter, err := parseWorkflowFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    userWorkflows, err := h.Store.ListWorkflows(c.Request.Context(), userIDClaim.(string), filter)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve workflows"})
+        respondStoreError(c, err, "Failed to retrieve workflows")
         return
     }
     if userWorkflows == nil {
@@ -98,27 +201,23 @@ func (h *WorkflowHandler) GetWorkflowByIDHandler(c *gin.Context) {
 		return
 	}
 
-	wf, found := h.Store.GetWorkflowByID(userIDClaim.(string), workflowID)
-	if !found {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Workflow not found or access denied"})
+	wf, err := h.Store.GetWorkflowByID(c.Request.Context(), userIDClaim.(string), workflowID)
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve workflow")
 		return
 	}
+	setWorkflowETag(c, wf)
 	c.JSON(http.StatusOK, wf)
 }
 
-// UpdateWorkflowHandler actualiza un workflow existente.
+// UpdateWorkflowHandler reemplaza la definición de un workflow existente.
 func (h *WorkflowHandler) UpdateWorkflowHandler(c *gin.Context) {
-	userIDClaim, _ := c.Get("userID")
-	workflowIDParam := c.Param("workflow_id")
-	workflowID, err := uuid.Parse(workflowIDParam)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID format"})
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
 		return
 	}
-
-	existingWorkflow, found := h.Store.GetWorkflowByID(userIDClaim.(string), workflowID)
-	if !found {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Workflow not found or access denied"})
+	existingWorkflow, ok := h.loadWorkflowForWrite(c, expectedVersion)
+	if !ok {
 		return
 	}
 
@@ -127,35 +226,28 @@ func (h *WorkflowHandler) UpdateWorkflowHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
-
-	existingWorkflow.Name = req.Name
-	existingWorkflow.Description = req.Description
-	existingWorkflow.Trigger = req.Trigger
-	existingWorkflow.Actions = req.Actions
-	existingWorkflow.IsEnabled = req.IsEnabled
-	existingWorkflow.UpdatedAt = time.Now().UTC()
-
-	if err := h.Store.SaveWorkflow(existingWorkflow); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update workflow"})
+	if !validateWorkflowRequest(c, req) {
 		return
 	}
 
-	h.Scheduler.ReloadAndRescheduleWorkflows()
-	c.JSON(http.StatusOK, existingWorkflow)
+	applyWorkflowRequest(existingWorkflow, req)
+	h.saveWorkflowAndRespond(c, existingWorkflow, http.StatusOK, "Failed to update workflow")
 }
 
-// DeleteWorkflowHandler elimina un workflow.
+// DeleteWorkflowHandler mueve un workflow a la papelera; se puede restaurar hasta que se purga.
 func (h *WorkflowHandler) DeleteWorkflowHandler(c *gin.Context) {
 	userIDClaim, _ := c.Get("userID")
-	workflowIDParam := c.Param("workflow_id")
-	workflowID, err := uuid.Parse(workflowIDParam)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID format"})
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	wf, ok := h.loadWorkflowForWrite(c, expectedVersion)
+	if !ok {
 		return
 	}
 
-	if !h.Store.DeleteWorkflow(userIDClaim.(string), workflowID) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Workflow not found or access denied"})
+	if err := h.Store.DeleteWorkflow(c.Request.Context(), userIDClaim.(string), wf.ID, expectedVersion); err != nil {
+		respondStoreError(c, err, "Failed to delete workflow")
 		return
 	}
 
@@ -184,30 +276,24 @@ func (h *WorkflowHandler) GetWorkflowExecutionsHandler(c *gin.Context) {
         return
     }
 
-    executions, err := h.Store.GetExecutionsByWorkflowID(userID, workflowID)
-    if err != nil {
-        log.Printf("ERROR: Failed to get executions for workflow %s, user %s: %v", workflowID, userID, err)
-        
-        // Si el error es por acceso denegado, devolver 403
-        if strings.Contains(err.Error(), "workflow not found or access denied") {
-            c.JSON(http.StatusForbidden, gin.H{"error": "Access to workflow denied"})
-            return
-        }
-        
-        // Para otros errores de base de datos
-        c.JSON(http.StatusInternalServerError, gin.H{
-            "error": "Failed to retrieve executions",
-            "details": err.Error(), // Solo para debugging, remover en producción
-        })
+    if _, err := h.Store.GetWorkflowByID(c.Request.Context(), userID, workflowID); err != nil {
+        respondStoreError(c, err, "Failed to retrieve workflow")
         return
     }
 
-    log.Printf("SUCCESS: Retrieved %d executions for workflow %s", len(executions), workflowID)
+    filter, err := parseExecutionFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    filter.WorkflowID = &workflowID
 
-    // Si no hay ejecuciones, devolver array vacío
-    if executions == nil {
-        executions = []*workflow.ExecutionLog{}
+    executions, err := h.Store.ListExecutions(c.Request.Context(), userID, filter)
+    if err != nil {
+        respondStoreError(c, err, "Failed to retrieve executions")
+        return
     }
 
-    c.JSON(http.StatusOK, executions)
+    log.Printf("SUCCESS: Retrieved %d executions for workflow %s", len(executions), workflowID)
+    writeExecutionPage(c, executions, filter)
 }
\ No newline at end of file
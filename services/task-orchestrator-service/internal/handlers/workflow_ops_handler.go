@@ -0,0 +1,267 @@
+// services/task-orchestrator-service/internal/handlers/workflow_ops_handler.go
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/engine"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
+)
+
+// duplicateSuffix se añade al nombre de la copia si no se indica otro.
+const duplicateSuffix = " (copy)"
+
+// PatchWorkflowHandler modifica un workflow con semántica JSON Merge Patch (RFC 7386):
+// los campos presentes sustituyen a los actuales, los objetos se fusionan y null
+// elimina el campo. Las listas (acciones, on_failure) se sustituyen completas.
+func (h *WorkflowHandler) PatchWorkflowHandler(c *gin.Context) {
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	var patch interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid merge patch: " + err.Error()})
+		return
+	}
+	if _, isObject := patch.(map[string]interface{}); !isObject {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Merge patch must be a JSON object"})
+		return
+	}
+
+	wf, ok := h.loadWorkflowForWrite(c, expectedVersion)
+	if !ok {
+		return
+	}
+
+	req, err := patchWorkflowRequest(workflowRequestFrom(wf), patch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+	if !validateWorkflowRequest(c, req) {
+		return
+	}
+
+	applyWorkflowRequest(wf, req)
+	h.saveWorkflowAndRespond(c, wf, http.StatusOK, "Failed to update workflow")
+}
+
+// EnableWorkflowHandler habilita el workflow para que el scheduler lo programe.
+func (h *WorkflowHandler) EnableWorkflowHandler(c *gin.Context) {
+	h.setWorkflowEnabled(c, true)
+}
+
+// DisableWorkflowHandler deshabilita el workflow sin modificar su definición.
+func (h *WorkflowHandler) DisableWorkflowHandler(c *gin.Context) {
+	h.setWorkflowEnabled(c, false)
+}
+
+// setWorkflowEnabled cambia is_enabled. Si ya tenía ese valor no guarda nada (no
+// crea una versión nueva) y responde con el workflow tal cual. If-Match es opcional.
+func (h *WorkflowHandler) setWorkflowEnabled(c *gin.Context, enabled bool) {
+	expectedVersion, ok := optionalIfMatch(c)
+	if !ok {
+		return
+	}
+	wf, ok := h.loadWorkflowForWrite(c, expectedVersion)
+	if !ok {
+		return
+	}
+	if wf.IsEnabled == enabled {
+		setWorkflowETag(c, wf)
+		c.JSON(http.StatusOK, wf)
+		return
+	}
+
+	req := workflowRequestFrom(wf)
+	req.IsEnabled = enabled
+	if !validateWorkflowRequest(c, req) {
+		return
+	}
+	applyWorkflowRequest(wf, req)
+	h.saveWorkflowAndRespond(c, wf, http.StatusOK, "Failed to update workflow")
+}
+
+// DuplicateWorkflowHandler crea una copia del workflow con un id nuevo y su propio
+// historial de versiones. La copia se crea deshabilitada para que no se dispare a
+// la vez que el original; el cuerpo opcional {"name": "..."} elige su nombre.
+func (h *WorkflowHandler) DuplicateWorkflowHandler(c *gin.Context) {
+	var dupReq transport.DuplicateWorkflowRequest
+	if err := c.ShouldBindJSON(&dupReq); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(dupReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
+		return
+	}
+
+	// La copia no la gestiona ningún origen, así que se puede duplicar un workflow gestionado.
+	original, ok := h.loadWorkflow(c)
+	if !ok {
+		return
+	}
+
+	req := workflowRequestFrom(original)
+	req.Name = dupReq.Name
+	if req.Name == "" {
+		req.Name = duplicateName(original.Name)
+	}
+	req.IsEnabled = false
+	if !validateWorkflowRequest(c, req) {
+		return
+	}
+
+	duplicate := &workflow.Workflow{
+		ID:        uuid.New(),
+		UserID:    original.UserID,
+		CreatedAt: time.Now().UTC(),
+	}
+	applyWorkflowRequest(duplicate, req)
+	h.saveWorkflowAndRespond(c, duplicate, http.StatusCreated, "Failed to duplicate workflow")
+}
+
+// EventsTokenHandler devuelve la URL de eventos y el token firmado con el que los
+// sistemas externos deben enviar (en X-Event-Token) los eventos de un workflow.
+func (h *WorkflowHandler) EventsTokenHandler(c *gin.Context) {
+	wf, ok := h.loadWorkflow(c)
+	if !ok {
+		return
+	}
+	token, err := engine.EventsToken(*wf)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Event delivery is not configured"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"workflow_id": wf.ID, "events_url": engine.EventsURL(), "token_header": engine.EventTokenHeader, "token": token})
+}
+
+// loadWorkflowForWrite carga el workflow de la ruta comprobando, si expectedVersion
+// es mayor que 0, que siga en esa versión. SaveWorkflow vuelve a comprobarlo al
+// escribir, por si otra petición guarda entre medias. Los workflows gestionados por
+// un origen declarativo son de solo lectura. Si algo falla ya ha respondido.
+func (h *WorkflowHandler) loadWorkflowForWrite(c *gin.Context, expectedVersion int) (*workflow.Workflow, bool) {
+	wf, ok := h.loadWorkflow(c)
+	if !ok {
+		return nil, false
+	}
+	if expectedVersion > 0 && wf.Version != expectedVersion {
+		respondStoreError(c, workflow.ErrWorkflowVersionMismatch, "Failed to update workflow")
+		return nil, false
+	}
+	if rejectManagedWorkflow(c, wf) {
+		return nil, false
+	}
+	return wf, true
+}
+
+// loadWorkflow carga el workflow de la ruta. Si algo falla ya ha respondido.
+func (h *WorkflowHandler) loadWorkflow(c *gin.Context) (*workflow.Workflow, bool) {
+	userIDClaim, _ := c.Get("userID")
+	workflowID, err := uuid.Parse(c.Param("workflow_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID format"})
+		return nil, false
+	}
+	wf, err := h.Store.GetWorkflowByID(c.Request.Context(), userIDClaim.(string), workflowID)
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve workflow")
+		return nil, false
+	}
+	return wf, true
+}
+
+// rejectManagedWorkflow responde 409 si el workflow lo gestiona un origen
+// declarativo: solo se cambia desde ese origen (POST /workflows/sync).
+func rejectManagedWorkflow(c *gin.Context, wf *workflow.Workflow) bool {
+	if wf.ManagedBy == "" {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": errorMessage(workflow.ErrWorkflowManaged), "managed_by": wf.ManagedBy})
+	return true
+}
+
+// saveWorkflowAndRespond guarda el workflow (condicionado a la versión con la que
+// se leyó), reprograma el scheduler y responde con el workflow y su ETag.
+func (h *WorkflowHandler) saveWorkflowAndRespond(c *gin.Context, wf *workflow.Workflow, status int, fallback string) {
+	if err := h.Store.SaveWorkflow(c.Request.Context(), wf); err != nil {
+		respondStoreError(c, err, fallback)
+		return
+	}
+	h.Scheduler.ReloadAndRescheduleWorkflows()
+	setWorkflowETag(c, wf)
+	c.JSON(status, wf)
+}
+
+// patchWorkflowRequest aplica el merge patch sobre la definición actual. Los campos
+// desconocidos son un error, para que una errata no se ignore en silencio.
+func patchWorkflowRequest(current transport.CreateWorkflowRequest, patch interface{}) (transport.CreateWorkflowRequest, error) {
+	var req transport.CreateWorkflowRequest
+	data, err := json.Marshal(current)
+	if err != nil {
+		return req, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return req, err
+	}
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return req, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&req)
+	return req, err
+}
+
+// mergePatch implementa el algoritmo MergePatch del RFC 7386.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// duplicateName añade duplicateSuffix al nombre.
+func duplicateName(name string) string {
+	return nameWithSuffix(name, duplicateSuffix)
+}
+
+// nameWithSuffix añade suffix recortando el nombre si hace falta para no superar
+// los 100 caracteres que admite la validación.
+func nameWithSuffix(name, suffix string) string {
+	const maxLen = 100
+	runes := []rune(name)
+	if keep := maxLen - len([]rune(suffix)); len(runes) > keep {
+		runes = runes[:keep]
+	}
+	return string(runes) + suffix
+}
@@ -0,0 +1,217 @@
+// services/task-orchestrator-service/internal/handlers/workflow_sync_handler.go
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/bundle"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
+)
+
+// Acciones de la sincronización declarativa.
+const (
+	syncCreate    = "create"
+	syncUpdate    = "update"
+	syncAdopt     = "adopt"
+	syncUnchanged = "unchanged"
+	syncPrune     = "prune"
+	syncOrphan    = "orphan" // Gestionado pero ausente del origen, sin prune: se deja como está
+)
+
+// syncSourcePattern limita el identificador del origen, ej. "git:platform/workflows".
+var syncSourcePattern = regexp.MustCompile(`^[A-Za-z0-9._:/@-]{1,100}$`)
+
+// syncStep es lo que hay que guardar o borrar para un resultado del plan.
+type syncStep struct {
+	save   *workflow.Workflow // Crear o actualizar
+	delete *workflow.Workflow // Mover a la papelera
+}
+
+// SyncWorkflowsHandler reconcilia los workflows del usuario con el bundle completo
+// de un origen declarativo (p. ej. un directorio de YAML revisado en pull requests).
+// Los workflows que crea quedan marcados con managed_by=source y la API los trata
+// como de solo lectura. Se emparejan por nombre entre los gestionados por ese origen:
+// los que cambian se actualizan (con su diff), los nuevos se crean y los que ya no
+// están se mueven a la papelera si prune=true. Un workflow no gestionado con el mismo
+// nombre es un error salvo con adopt=true, que lo pasa a estar gestionado.
+// Con dry_run=true solo devuelve el plan. Los cambios se aplican en una sola
+// transacción: si algún workflow tiene errores o falla al guardarse no se aplica nada.
+func (h *WorkflowHandler) SyncWorkflowsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	userID := userIDClaim.(string)
+
+	source := c.Query("source")
+	if !syncSourcePattern.MatchString(source) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'source': 1-100 letters, digits or . _ : / @ -"})
+		return
+	}
+	flags := map[string]bool{}
+	for _, name := range []string{"dry_run", "prune", "adopt"} {
+		if raw := c.Query(name); raw != "" {
+			value, err := strconv.ParseBool(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid '%s': expected true or false", name)})
+				return
+			}
+			flags[name] = value
+		}
+	}
+
+	body, err := c.GetRawData()
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body must be a workflow bundle"})
+		return
+	}
+	b, err := bundle.Decode(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(err)})
+		return
+	}
+
+	existing, err := h.Store.ListWorkflows(c.Request.Context(), userID, workflow.WorkflowFilter{})
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve workflows")
+		return
+	}
+	available, err := h.secretNames(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("ERROR: failed to list secrets for sync: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve secrets"})
+		return
+	}
+
+	results, steps, valid := planSync(b, userID, source, existing, available, flags["prune"], flags["adopt"])
+	resp := transport.SyncWorkflowsResponse{Source: source, DryRun: flags["dry_run"], Prune: flags["prune"], Results: results}
+	if !valid {
+		resp.Error = "Source contains invalid workflows; nothing was synced"
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if resp.DryRun {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	var changes []workflow.WorkflowChange
+	var changed []int // Índice en results de cada cambio
+	for i, step := range steps {
+		if step.save != nil || step.delete != nil {
+			changes = append(changes, workflow.WorkflowChange{Save: step.save, Delete: step.delete})
+			changed = append(changed, i)
+		}
+	}
+	if len(changes) == 0 {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if err := h.Store.ApplyWorkflowChanges(c.Request.Context(), userID, changes); err != nil {
+		log.Printf("ERROR: sync from '%s' failed: %v", source, err)
+		resp.Error = "Sync failed; no changes were applied"
+		if i, changeErr := failedChange(err, changed); i >= 0 {
+			results[i].Error = errorMessage(changeErr)
+			resp.Error = fmt.Sprintf("Sync failed at workflow '%s'; no changes were applied", results[i].Name)
+		}
+		c.JSON(batchErrorStatus(err), resp)
+		return
+	}
+	for i, step := range steps {
+		if step.save != nil {
+			results[i].WorkflowID = &step.save.ID
+		}
+	}
+	h.Scheduler.ReloadAndRescheduleWorkflows()
+	c.JSON(http.StatusOK, resp)
+}
+
+// planSync calcula el plan de la sincronización: un resultado por workflow del
+// bundle y, después, uno por cada workflow gestionado por source que ya no está
+// en él (prune u orphan). steps va en paralelo a results.
+func planSync(b *bundle.Bundle, userID, source string, existing []*workflow.Workflow, secrets map[string]bool, prune, adopt bool) ([]transport.SyncWorkflowResult, []syncStep, bool) {
+	byName := map[string][]*workflow.Workflow{}
+	for _, wf := range existing {
+		byName[wf.Name] = append(byName[wf.Name], wf)
+	}
+
+	var results []transport.SyncWorkflowResult
+	var steps []syncStep
+	seen := map[string]bool{}
+	valid := true
+	now := time.Now().UTC()
+
+	for _, item := range b.Workflows {
+		req := item.CreateWorkflowRequest
+		result := transport.SyncWorkflowResult{Name: req.Name}
+		var step syncStep
+
+		missing, err := checkBundleWorkflow(req, seen, secrets)
+		result.MissingSecrets = missing
+		matches := byName[req.Name]
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+		case len(matches) > 1:
+			result.Error = fmt.Sprintf("%d existing workflows are named '%s'; rename them so the source can match one", len(matches), req.Name)
+		case len(matches) == 1 && matches[0].ManagedBy != source && matches[0].ManagedBy != "":
+			result.Error = fmt.Sprintf("workflow '%s' is managed by another source ('%s')", req.Name, matches[0].ManagedBy)
+		case len(matches) == 1 && matches[0].ManagedBy == "" && !adopt:
+			result.Error = fmt.Sprintf("workflow '%s' already exists and is not managed by '%s'; use adopt=true to take it over", req.Name, source)
+		case len(matches) == 0:
+			result.Action = syncCreate
+			step.save = &workflow.Workflow{ID: uuid.New(), UserID: userID, CreatedAt: now, ManagedBy: source}
+			applyWorkflowRequest(step.save, req)
+		default:
+			current := matches[0]
+			result.WorkflowID = &current.ID
+			if current.ManagedBy == source && sameDefinition(current, req) {
+				result.Action = syncUnchanged
+				break
+			}
+			result.Action = syncUpdate
+			if current.ManagedBy == "" {
+				result.Action = syncAdopt
+			}
+			target := *current
+			target.ManagedBy = source
+			applyWorkflowRequest(&target, req)
+			if result.Changes, err = workflow.DiffVersions(&workflow.WorkflowVersion{Definition: current}, &workflow.WorkflowVersion{Definition: &target}); err != nil {
+				result.Action, result.Error = "", err.Error()
+				break
+			}
+			step.save = &target
+		}
+		if result.Error != "" {
+			valid = false
+		}
+		results = append(results, result)
+		steps = append(steps, step)
+	}
+
+	var gone []*workflow.Workflow
+	for _, wf := range existing {
+		if wf.ManagedBy == source && !seen[wf.Name] {
+			gone = append(gone, wf)
+		}
+	}
+	sort.Slice(gone, func(i, j int) bool { return gone[i].Name < gone[j].Name })
+	for _, wf := range gone {
+		id := wf.ID
+		result := transport.SyncWorkflowResult{Name: wf.Name, Action: syncOrphan, WorkflowID: &id}
+		var step syncStep
+		if prune {
+			result.Action, step.delete = syncPrune, wf
+		}
+		results = append(results, result)
+		steps = append(steps, step)
+	}
+	return results, steps, valid
+}
@@ -0,0 +1,169 @@
+// services/task-orchestrator-service/internal/handlers/workflow_sync_handler_test.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/pkg/transport"
+)
+
+const testSource = "git:platform/workflows"
+
+func managedTestWorkflow(name, source string) *workflow.Workflow {
+	wf := newTestWorkflow(name, logMessageAction("greet", "hi"))
+	wf.ManagedBy = source
+	wf.Version = 2
+	return wf
+}
+
+func TestPlanSync(t *testing.T) {
+	managedA := managedTestWorkflow("managed-a", testSource)
+	managedGone := managedTestWorkflow("managed-gone", testSource)
+	otherSource := managedTestWorkflow("other-src", "git:other")
+	plain := managedTestWorkflow("plain", "")
+	existing := []*workflow.Workflow{managedA, managedGone, otherSource, plain}
+
+	fresh := workflowRequestFrom(newTestWorkflow("fresh", logMessageAction("greet", "hi")))
+
+	type want struct {
+		action, err     string
+		saves, deletes  bool
+		keepsIdentityOf *workflow.Workflow
+	}
+	for _, tc := range []struct {
+		name         string
+		reqs         []transport.CreateWorkflowRequest
+		prune, adopt bool
+		want         map[string]want
+		wantValid    bool
+	}{
+		{
+			name: "create, unchanged and orphan",
+			reqs: []transport.CreateWorkflowRequest{fresh, workflowRequestFrom(managedA)},
+			want: map[string]want{
+				"fresh":        {action: syncCreate, saves: true},
+				"managed-a":    {action: syncUnchanged},
+				"managed-gone": {action: syncOrphan},
+			},
+			wantValid: true,
+		},
+		{
+			name:  "update and prune",
+			reqs:  []transport.CreateWorkflowRequest{changedRequest(managedA)},
+			prune: true,
+			want: map[string]want{
+				"managed-a":    {action: syncUpdate, saves: true, keepsIdentityOf: managedA},
+				"managed-gone": {action: syncPrune, deletes: true},
+			},
+			wantValid: true,
+		},
+		{
+			name:  "adopt",
+			reqs:  []transport.CreateWorkflowRequest{workflowRequestFrom(managedA), workflowRequestFrom(managedGone), changedRequest(plain)},
+			adopt: true,
+			want: map[string]want{
+				"managed-a":    {action: syncUnchanged},
+				"managed-gone": {action: syncUnchanged},
+				"plain":        {action: syncAdopt, saves: true, keepsIdentityOf: plain},
+			},
+			wantValid: true,
+		},
+		{
+			name: "unmanaged without adopt",
+			reqs: []transport.CreateWorkflowRequest{workflowRequestFrom(managedA), workflowRequestFrom(managedGone), workflowRequestFrom(plain)},
+			want: map[string]want{
+				"managed-a":    {action: syncUnchanged},
+				"managed-gone": {action: syncUnchanged},
+				"plain":        {err: "use adopt=true"},
+			},
+		},
+		{
+			name:  "managed by another source",
+			reqs:  []transport.CreateWorkflowRequest{workflowRequestFrom(managedA), workflowRequestFrom(managedGone), workflowRequestFrom(otherSource)},
+			adopt: true,
+			want: map[string]want{
+				"managed-a":    {action: syncUnchanged},
+				"managed-gone": {action: syncUnchanged},
+				"other-src":    {err: "managed by another source"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			results, steps, valid := planSync(bundleOf(tc.reqs...), testUserID, testSource, existing, nil, tc.prune, tc.adopt)
+			if valid != tc.wantValid {
+				t.Errorf("valid = %v, want %v", valid, tc.wantValid)
+			}
+			if len(results) != len(tc.want) || len(steps) != len(results) {
+				t.Fatalf("got %d results and %d steps, want %d: %+v", len(results), len(steps), len(tc.want), results)
+			}
+			for i, got := range results {
+				w, ok := tc.want[got.Name]
+				if !ok {
+					t.Errorf("unexpected result for %q: %+v", got.Name, got)
+					continue
+				}
+				step := steps[i]
+				if got.Action != w.action {
+					t.Errorf("%s: action = %q, want %q", got.Name, got.Action, w.action)
+				}
+				if w.err == "" && got.Error != "" || w.err != "" && !strings.Contains(got.Error, w.err) {
+					t.Errorf("%s: error = %q, want %q", got.Name, got.Error, w.err)
+				}
+				if (step.save != nil) != w.saves || (step.delete != nil) != w.deletes {
+					t.Errorf("%s: step = %+v, want saves=%v deletes=%v", got.Name, step, w.saves, w.deletes)
+				}
+				if step.save != nil && (step.save.ManagedBy != testSource || step.save.UserID != testUserID) {
+					t.Errorf("%s: saved workflow is not managed by the source: %+v", got.Name, step.save)
+				}
+				if prev := w.keepsIdentityOf; prev != nil {
+					if step.save.ID != prev.ID || step.save.Version != prev.Version {
+						t.Errorf("%s: saved %s v%d, want %s v%d", got.Name, step.save.ID, step.save.Version, prev.ID, prev.Version)
+					}
+					if len(got.Changes) == 0 {
+						t.Errorf("%s: no changes reported", got.Name)
+					}
+				}
+			}
+		})
+	}
+
+	if managedA.Description == "changed by the bundle" || plain.ManagedBy != "" {
+		t.Errorf("planSync modified the existing workflows")
+	}
+}
+
+func TestSyncIsAllOrNothing(t *testing.T) {
+	h, router := newTestHandler(t)
+	router.POST("/workflows/sync", h.SyncWorkflowsHandler)
+
+	managed := managedTestWorkflow("managed-a", testSource)
+	managed.Version = 0
+	saveTestWorkflow(t, h.Store, managed)
+	h.Store = &staleStore{Store: h.Store, touch: managed}
+
+	fresh := workflowRequestFrom(newTestWorkflow("fresh", logMessageAction("greet", "hi")))
+	body, _ := json.Marshal(bundleOf(fresh, changedRequest(managed)))
+	rec := doRequest(router, http.MethodPost, "/workflows/sync?source="+testSource, string(body), nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, body %s; want 409", rec.Code, rec.Body)
+	}
+	var resp transport.SyncWorkflowsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response: %v", err)
+	}
+	if !strings.Contains(resp.Error, "'managed-a'") || resp.Results[1].Error == "" {
+		t.Errorf("response does not point at the stale workflow: %+v", resp)
+	}
+
+	all, _ := h.Store.ListWorkflows(context.Background(), testUserID, workflow.WorkflowFilter{})
+	for _, wf := range all {
+		if wf.Name == "fresh" {
+			t.Fatalf("workflow created earlier in the sync was kept after the sync failed")
+		}
+	}
+}
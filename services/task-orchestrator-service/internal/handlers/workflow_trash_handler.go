@@ -0,0 +1,59 @@
+// services/task-orchestrator-service/internal/handlers/workflow_trash_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// ListTrashHandler lista los workflows borrados del usuario que aún no se han purgado.
+func (h *WorkflowHandler) ListTrashHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	workflows, err := h.Store.ListDeletedWorkflows(c.Request.Context(), userIDClaim.(string))
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve deleted workflows")
+		return
+	}
+	if workflows == nil {
+		workflows = []*workflow.Workflow{}
+	}
+	c.JSON(http.StatusOK, workflows)
+}
+
+// RestoreWorkflowHandler saca un workflow de la papelera. Si estaba habilitado,
+// el scheduler lo vuelve a programar. Los gestionados por un origen declarativo
+// (los que borró sync con prune) responden 409 como el resto de escrituras: solo
+// ese origen puede volver a crearlos.
+func (h *WorkflowHandler) RestoreWorkflowHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	workflowID, err := uuid.Parse(c.Param("workflow_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID format"})
+		return
+	}
+
+	// En la papelera nada cambia managed_by, así que comprobarlo antes de restaurar basta.
+	trash, err := h.Store.ListDeletedWorkflows(c.Request.Context(), userIDClaim.(string))
+	if err != nil {
+		respondStoreError(c, err, "Failed to restore workflow")
+		return
+	}
+	for _, deleted := range trash {
+		if deleted.ID == workflowID && rejectManagedWorkflow(c, deleted) {
+			return
+		}
+	}
+
+	wf, err := h.Store.RestoreWorkflow(c.Request.Context(), userIDClaim.(string), workflowID)
+	if err != nil {
+		respondStoreError(c, err, "Failed to restore workflow")
+		return
+	}
+
+	h.Scheduler.ReloadAndRescheduleWorkflows()
+	setWorkflowETag(c, wf)
+	c.JSON(http.StatusOK, wf)
+}
@@ -0,0 +1,51 @@
+// services/task-orchestrator-service/internal/handlers/workflow_trash_handler_test.go
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRestoreWorkflow(t *testing.T) {
+	h, router := newTestHandler(t)
+	router.POST("/workflows/:workflow_id/restore", h.RestoreWorkflowHandler)
+
+	wf := newTestWorkflow("restorable", logMessageAction("greet", "hi"))
+	saveTestWorkflow(t, h.Store, wf)
+	if err := h.Store.DeleteWorkflow(context.Background(), testUserID, wf.ID, wf.Version); err != nil {
+		t.Fatalf("DeleteWorkflow: %v", err)
+	}
+
+	rec := doRequest(router, http.MethodPost, fmt.Sprintf("/workflows/%s/restore", wf.ID), "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s; want 200", rec.Code, rec.Body)
+	}
+	if _, err := h.Store.GetWorkflowByID(context.Background(), testUserID, wf.ID); err != nil {
+		t.Errorf("restored workflow is not visible: %v", err)
+	}
+}
+
+func TestRestoreManagedWorkflowIsRejected(t *testing.T) {
+	h, router := newTestHandler(t)
+	router.POST("/workflows/:workflow_id/restore", h.RestoreWorkflowHandler)
+
+	// Lo que deja sync con prune: un workflow gestionado en la papelera.
+	wf := managedTestWorkflow("pruned", testSource)
+	wf.Version = 0
+	saveTestWorkflow(t, h.Store, wf)
+	if err := h.Store.DeleteWorkflow(context.Background(), testUserID, wf.ID, wf.Version); err != nil {
+		t.Fatalf("DeleteWorkflow: %v", err)
+	}
+
+	rec := doRequest(router, http.MethodPost, fmt.Sprintf("/workflows/%s/restore", wf.ID), "", nil)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), testSource) {
+		t.Fatalf("status = %d, body %s; want 409 naming the source", rec.Code, rec.Body)
+	}
+	trash, _ := h.Store.ListDeletedWorkflows(context.Background(), testUserID)
+	if len(trash) != 1 || trash[0].ID != wf.ID {
+		t.Errorf("managed workflow left the trash: %v", trash)
+	}
+}
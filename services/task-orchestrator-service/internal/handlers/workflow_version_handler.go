@@ -0,0 +1,137 @@
+// services/task-orchestrator-service/internal/handlers/workflow_version_handler.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// parseWorkflowVersionParams lee :workflow_id y :version de la ruta.
+func parseWorkflowVersionParams(c *gin.Context) (uuid.UUID, int, bool) {
+	workflowID, err := uuid.Parse(c.Param("workflow_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID format"})
+		return uuid.Nil, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow version"})
+		return uuid.Nil, 0, false
+	}
+	return workflowID, version, true
+}
+
+// ListWorkflowVersionsHandler lista el historial de versiones del workflow.
+func (h *WorkflowHandler) ListWorkflowVersionsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	workflowID, err := uuid.Parse(c.Param("workflow_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID format"})
+		return
+	}
+
+	versions, err := h.Store.ListWorkflowVersions(c.Request.Context(), userIDClaim.(string), workflowID)
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve workflow versions")
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// GetWorkflowVersionHandler devuelve la definición de una versión concreta.
+func (h *WorkflowHandler) GetWorkflowVersionHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	workflowID, version, ok := parseWorkflowVersionParams(c)
+	if !ok {
+		return
+	}
+
+	wv, err := h.Store.GetWorkflowVersion(c.Request.Context(), userIDClaim.(string), workflowID, version)
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve workflow version")
+		return
+	}
+	c.JSON(http.StatusOK, wv)
+}
+
+// DiffWorkflowVersionsHandler compara la versión :version con la indicada en ?from
+// (por defecto, la anterior). Los cambios van de from a :version.
+func (h *WorkflowHandler) DiffWorkflowVersionsHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	userID := userIDClaim.(string)
+	workflowID, version, ok := parseWorkflowVersionParams(c)
+	if !ok {
+		return
+	}
+	from := version - 1
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' version"})
+			return
+		}
+		from = parsed
+	}
+	if from < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Version 1 has no previous version; pass 'from'"})
+		return
+	}
+
+	fromVersion, err := h.Store.GetWorkflowVersion(c.Request.Context(), userID, workflowID, from)
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve workflow version")
+		return
+	}
+	toVersion, err := h.Store.GetWorkflowVersion(c.Request.Context(), userID, workflowID, version)
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve workflow version")
+		return
+	}
+	changes, err := workflow.DiffVersions(fromVersion, toVersion)
+	if err != nil {
+		respondStoreError(c, err, "Failed to compare workflow versions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"workflow_id": workflowID, "from": from, "to": version, "changes": changes})
+}
+
+// RollbackWorkflowHandler restaura la definición de una versión anterior. No
+// reescribe el historial: guarda la definición restaurada como una versión nueva.
+// La definición se valida como en PUT, porque las reglas pueden haber cambiado
+// desde que se guardó. Como el resto de escrituras exige If-Match con el ETag de
+// la versión actual y responde con el ETag de la nueva.
+func (h *WorkflowHandler) RollbackWorkflowHandler(c *gin.Context) {
+	userIDClaim, _ := c.Get("userID")
+	workflowID, version, ok := parseWorkflowVersionParams(c)
+	if !ok {
+		return
+	}
+	expectedVersion, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	target, err := h.Store.GetWorkflowVersion(c.Request.Context(), userIDClaim.(string), workflowID, version)
+	if err != nil {
+		respondStoreError(c, err, "Failed to retrieve workflow version")
+		return
+	}
+	wf, ok := h.loadWorkflowForWrite(c, expectedVersion)
+	if !ok {
+		return
+	}
+
+	wf.ApplyDefinition(target.Definition)
+	if err := workflowRequestError(workflowRequestFrom(wf)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Version %d cannot be restored: %v", version, err)})
+		return
+	}
+	wf.UpdatedAt = time.Now().UTC()
+	h.saveWorkflowAndRespond(c, wf, http.StatusOK, "Failed to roll back workflow")
+}
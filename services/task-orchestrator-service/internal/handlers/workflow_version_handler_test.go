@@ -0,0 +1,149 @@
+// services/task-orchestrator-service/internal/handlers/workflow_version_handler_test.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/scheduler"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+const testUserID = "user-1"
+
+// newTestHandler devuelve un WorkflowHandler sobre un store en memoria y un router
+// que autentica todas las peticiones como testUserID.
+func newTestHandler(t *testing.T) (*WorkflowHandler, *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store := workflow.NewInMemoryWorkflowStore()
+	h := NewWorkflowHandler(store, scheduler.New(store, nil, nil, time.Minute), nil)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("userID", testUserID) })
+	return h, router
+}
+
+func logMessageAction(name, message string) workflow.ActionDefinition {
+	return workflow.ActionDefinition{Name: name, Type: workflow.ActionTypeLogMessage, Config: map[string]interface{}{"message": message}}
+}
+
+// saveTestWorkflow guarda wf y devuelve la versión resultante.
+func saveTestWorkflow(t *testing.T, store workflow.Store, wf *workflow.Workflow) int {
+	t.Helper()
+	if err := store.SaveWorkflow(context.Background(), wf); err != nil {
+		t.Fatalf("SaveWorkflow: %v", err)
+	}
+	return wf.Version
+}
+
+func newTestWorkflow(name string, actions ...workflow.ActionDefinition) *workflow.Workflow {
+	now := time.Now().UTC()
+	return &workflow.Workflow{
+		ID:        uuid.New(),
+		UserID:    testUserID,
+		Name:      name,
+		Trigger:   workflow.TriggerDefinition{Type: workflow.TriggerTypeWebhook, Config: map[string]interface{}{}},
+		Actions:   actions,
+		IsEnabled: true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func doRequest(router *gin.Engine, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRollbackWorkflow(t *testing.T) {
+	h, router := newTestHandler(t)
+	router.POST("/workflows/:workflow_id/versions/:version/rollback", h.RollbackWorkflowHandler)
+
+	wf := newTestWorkflow("rollback", logMessageAction("greet", "v1"))
+	saveTestWorkflow(t, h.Store, wf)
+	wf.Actions = []workflow.ActionDefinition{logMessageAction("greet", "v2")}
+	saveTestWorkflow(t, h.Store, wf)
+
+	path := fmt.Sprintf("/workflows/%s/versions/1/rollback", wf.ID)
+	for _, tc := range []struct {
+		ifMatch string
+		want    int
+	}{
+		{"", http.StatusPreconditionRequired},
+		{`"1"`, http.StatusPreconditionFailed},
+		{`W/"2"`, http.StatusPreconditionFailed},
+	} {
+		headers := map[string]string{}
+		if tc.ifMatch != "" {
+			headers["If-Match"] = tc.ifMatch
+		}
+		if rec := doRequest(router, http.MethodPost, path, "", headers); rec.Code != tc.want {
+			t.Errorf("If-Match %q: status = %d, want %d", tc.ifMatch, rec.Code, tc.want)
+		}
+	}
+
+	rec := doRequest(router, http.MethodPost, path, "", map[string]string{"If-Match": `"2"`})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var restored workflow.Workflow
+	if err := json.Unmarshal(rec.Body.Bytes(), &restored); err != nil {
+		t.Fatalf("response: %v", err)
+	}
+	if restored.Version != 3 || restored.Actions[0].Config["message"] != "v1" {
+		t.Errorf("restored version %d with actions %v, want v1's definition as version 3", restored.Version, restored.Actions)
+	}
+	if etag := rec.Header().Get("ETag"); etag != `"3"` {
+		t.Errorf("ETag = %q, want \"3\"", etag)
+	}
+}
+
+func TestRollbackWorkflowRejectsInvalidDefinition(t *testing.T) {
+	h, router := newTestHandler(t)
+	router.POST("/workflows/:workflow_id/versions/:version/rollback", h.RollbackWorkflowHandler)
+
+	// La versión 1 se guardó antes de que el servidor acotara max_steps.
+	wf := newTestWorkflow("stale", workflow.ActionDefinition{Name: "s", Type: workflow.ActionTypeScript,
+		Config: map[string]interface{}{"source": "output = 1", "max_steps": float64(1 << 50)}})
+	saveTestWorkflow(t, h.Store, wf)
+	wf.Actions = []workflow.ActionDefinition{logMessageAction("greet", "v2")}
+	saveTestWorkflow(t, h.Store, wf)
+
+	rec := doRequest(router, http.MethodPost, fmt.Sprintf("/workflows/%s/versions/1/rollback", wf.ID), "", map[string]string{"If-Match": `"2"`})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "max_steps") {
+		t.Fatalf("status = %d, body %s; want 400 naming the invalid field", rec.Code, rec.Body)
+	}
+	current, _ := h.Store.GetWorkflowByID(context.Background(), testUserID, wf.ID)
+	if current.Version != 2 {
+		t.Errorf("an invalid rollback saved version %d", current.Version)
+	}
+}
+
+func TestRollbackManagedWorkflowIsRejected(t *testing.T) {
+	h, router := newTestHandler(t)
+	router.POST("/workflows/:workflow_id/versions/:version/rollback", h.RollbackWorkflowHandler)
+
+	wf := newTestWorkflow("managed", logMessageAction("greet", "v1"))
+	wf.ManagedBy = "git"
+	saveTestWorkflow(t, h.Store, wf)
+	wf.Actions = []workflow.ActionDefinition{logMessageAction("greet", "v2")}
+	saveTestWorkflow(t, h.Store, wf)
+
+	rec := doRequest(router, http.MethodPost, fmt.Sprintf("/workflows/%s/versions/1/rollback", wf.ID), "", map[string]string{"If-Match": "*"})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, body %s; want 409", rec.Code, rec.Body)
+	}
+}
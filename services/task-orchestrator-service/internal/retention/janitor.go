@@ -0,0 +1,178 @@
+// services/task-orchestrator-service/internal/retention/janitor.go
+package retention
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// Janitor borra periódicamente las ejecuciones que exceden la retención y los
+// workflows que llevan en la papelera más que el periodo de gracia, por lotes para
+// no bloquear la tabla con un único DELETE enorme.
+type Janitor struct {
+	store      workflow.Store
+	defaults   workflow.RetentionLimits
+	interval   time.Duration
+	batchSize  int
+	archiveDir string
+	// trashGrace es el tiempo en la papelera antes de purgar; 0 no purga nunca.
+	trashGrace time.Duration
+	// ctx se cancela en Stop e interrumpe la purga en curso.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New crea un Janitor. Con archiveDir vacío las ejecuciones se borran sin archivar.
+func New(store workflow.Store, defaults workflow.RetentionLimits, interval time.Duration, batchSize int, archiveDir string, trashGrace time.Duration) *Janitor {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Janitor{
+		store:      store,
+		defaults:   defaults,
+		interval:   interval,
+		batchSize:  batchSize,
+		archiveDir: archiveDir,
+		trashGrace: trashGrace,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start ejecuta una purga inicial y después una cada intervalo.
+func (j *Janitor) Start() {
+	if j.archiveDir != "" {
+		if err := os.MkdirAll(j.archiveDir, 0o750); err != nil {
+			log.Printf("ERROR: Cannot create execution archive directory %s: %v", j.archiveDir, err)
+		}
+	}
+	log.Printf("Retention janitor started (every %s, default max age %d days, default max count %d, trash grace period %s)",
+		j.interval, j.defaults.MaxAgeDays, j.defaults.MaxCount, j.trashGrace)
+
+	j.Purge()
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-j.ctx.Done():
+			return
+		case <-ticker.C:
+			j.Purge()
+		}
+	}
+}
+
+// Stop detiene el janitor.
+func (j *Janitor) Stop() {
+	j.cancel()
+}
+
+// Purge borra lotes hasta que no quedan ejecuciones vencidas.
+func (j *Janitor) Purge() {
+	var archive func([]*workflow.ExecutionLog) error
+	if j.archiveDir != "" {
+		archive = j.archive
+	}
+
+	total := 0
+	for {
+		if j.ctx.Err() != nil {
+			return
+		}
+		deleted, err := j.store.PurgeExpiredExecutions(j.ctx, j.defaults, time.Now().UTC(), j.batchSize, archive)
+		if err != nil {
+			log.Printf("ERROR: Retention purge failed: %v", err)
+			break
+		}
+		total += deleted
+		if deleted < j.batchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("RETENTION: Purged %d expired execution(s)", total)
+	}
+	j.purgeTrash()
+}
+
+// purgeTrash borra definitivamente los workflows que superan el periodo de gracia
+// en la papelera. Sus ejecuciones se borran con ellos sin archivarse.
+func (j *Janitor) purgeTrash() {
+	if j.trashGrace <= 0 {
+		return
+	}
+	total := 0
+	for {
+		if j.ctx.Err() != nil {
+			return
+		}
+		deleted, err := j.store.PurgeDeletedWorkflows(j.ctx, time.Now().UTC().Add(-j.trashGrace), j.batchSize)
+		if err != nil {
+			log.Printf("ERROR: Trash purge failed: %v", err)
+			break
+		}
+		total += deleted
+		if deleted < j.batchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("RETENTION: Purged %d workflow(s) from the trash", total)
+	}
+}
+
+// archivedExecution incluye el estado interno, que ExecutionLog no serializa.
+type archivedExecution struct {
+	*workflow.ExecutionLog
+	State json.RawMessage `json:"state,omitempty"`
+}
+
+// archive escribe el lote en un fichero NDJSON comprimido con gzip. El fichero se
+// escribe con un nombre temporal y se renombra al final, así que un archivo
+// presente en disco siempre está completo.
+func (j *Janitor) archive(executions []*workflow.ExecutionLog) error {
+	name := fmt.Sprintf("executions-%s-%s.ndjson.gz", time.Now().UTC().Format("20060102T150405.000000000Z"), executions[0].ID)
+	path := filepath.Join(j.archiveDir, name)
+	tmp := path + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(file)
+	buf := bufio.NewWriter(gz)
+	encoder := json.NewEncoder(buf)
+
+	writeErr := func() error {
+		for _, exec := range executions {
+			if err := encoder.Encode(archivedExecution{ExecutionLog: exec, State: exec.State}); err != nil {
+				return err
+			}
+		}
+		if err := buf.Flush(); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		return file.Sync()
+	}()
+	if closeErr := file.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		os.Remove(tmp)
+		return writeErr
+	}
+	return os.Rename(tmp, path)
+}
@@ -2,7 +2,9 @@
 package scheduler
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/robfig/cron/v3"
 	// Asegúrate que la ruta al modelo de workflow y su store sea correcta
@@ -13,24 +15,40 @@ import (
 // La firma ahora espera un workflow.Store, que es la interfaz central.
 type WorkflowExecutor func(wf workflow.Workflow, store workflow.Store)
 
+// ExecutionResumer continúa una ejecución suspendida cuyo resume_at ya venció.
+type ExecutionResumer func(exec *workflow.ExecutionLog, store workflow.Store)
+
+// resumeBatchSize es el máximo de ejecuciones reanudadas en cada sondeo.
+const resumeBatchSize = 50
+
 type Scheduler struct {
 	cronRunner      *cron.Cron
 	// Usa directamente la interfaz del paquete workflow.
 	workflowStore   workflow.Store
 	executeWorkflow WorkflowExecutor
+	resumeExecution ExecutionResumer
+	resumeInterval  time.Duration
+	// ctx se cancela en Stop y aborta las consultas del scheduler en curso.
+	ctx             context.Context
+	cancel          context.CancelFunc
 }
 
 // New crea una nueva instancia del Scheduler.
-func New(store workflow.Store, executor WorkflowExecutor) *Scheduler {
+func New(store workflow.Store, executor WorkflowExecutor, resumer ExecutionResumer, resumeInterval time.Duration) *Scheduler {
 	c := cron.New(cron.WithChain(
 		cron.SkipIfStillRunning(cron.DefaultLogger),
 		cron.Recover(cron.DefaultLogger),
 	))
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
 		cronRunner:      c,
 		workflowStore:   store,
 		executeWorkflow: executor,
+		resumeExecution: resumer,
+		resumeInterval:  resumeInterval,
+		ctx:             ctx,
+		cancel:          cancel,
 	}
 }
 
@@ -39,6 +57,7 @@ func (s *Scheduler) Start() {
 	log.Println("Scheduler starting...")
 	s.loadAndScheduleWorkflows()
 	s.cronRunner.Start()
+	go s.resumeLoop()
 	log.Println("Scheduler started and cron jobs running.")
 }
 
@@ -46,9 +65,37 @@ func (s *Scheduler) Start() {
 func (s *Scheduler) Stop() {
 	log.Println("Scheduler stopping...")
 	s.cronRunner.Stop()
+	s.cancel()
 	log.Println("Scheduler stopped.")
 }
 
+// resumeLoop sondea periódicamente las ejecuciones suspendidas que ya deben continuar.
+// Como el estado vive en la base de datos, las esperas sobreviven a reinicios.
+func (s *Scheduler) resumeLoop() {
+	ticker := time.NewTicker(s.resumeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.resumeDueExecutions()
+		}
+	}
+}
+
+func (s *Scheduler) resumeDueExecutions() {
+	executions, err := s.workflowStore.ClaimDueExecutions(s.ctx, time.Now().UTC(), resumeBatchSize)
+	if err != nil {
+		log.Printf("Error claiming suspended executions: %v", err)
+		return
+	}
+	for _, exec := range executions {
+		log.Printf("SCHEDULER: Resuming execution %s of workflow %s", exec.ID, exec.WorkflowID)
+		go s.resumeExecution(exec, s.workflowStore)
+	}
+}
+
 func (s *Scheduler) loadAndScheduleWorkflows() {
 	log.Println("Loading and scheduling workflows...")
 
@@ -56,7 +103,7 @@ func (s *Scheduler) loadAndScheduleWorkflows() {
 		s.cronRunner.Remove(entry.ID)
 	}
 	
-	workflowsToSchedule, err := s.workflowStore.GetAllEnabledScheduledWorkflows()
+	workflowsToSchedule, err := s.workflowStore.GetAllEnabledScheduledWorkflows(s.ctx)
 	if err != nil {
 		log.Printf("Error loading workflows for scheduler: %v", err)
 		return
@@ -0,0 +1,84 @@
+// services/task-orchestrator-service/internal/secret/memory_store.go
+package secret
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InMemorySecretStore guarda los secretos cifrados en memoria (STORE_BACKEND=memory).
+type InMemorySecretStore struct {
+	Cipher *Cipher
+
+	mu      sync.RWMutex
+	secrets map[string]map[string]*memorySecret // user_id -> name -> secreto
+}
+
+type memorySecret struct {
+	meta  Secret
+	value []byte
+}
+
+func NewInMemorySecretStore(cipher *Cipher) *InMemorySecretStore {
+	return &InMemorySecretStore{Cipher: cipher, secrets: make(map[string]map[string]*memorySecret)}
+}
+
+func (s *InMemorySecretStore) SaveSecret(ctx context.Context, userID, name, value string) (*Secret, error) {
+	encrypted, err := s.Cipher.Encrypt(value)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	if s.secrets[userID] == nil {
+		s.secrets[userID] = make(map[string]*memorySecret)
+	}
+	stored, ok := s.secrets[userID][name]
+	if !ok {
+		stored = &memorySecret{meta: Secret{ID: uuid.New().String(), UserID: userID, Name: name, CreatedAt: now}}
+		s.secrets[userID][name] = stored
+	}
+	stored.value = encrypted
+	stored.meta.UpdatedAt = now
+	meta := stored.meta
+	return &meta, nil
+}
+
+func (s *InMemorySecretStore) ListSecrets(ctx context.Context, userID string) ([]*Secret, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	secrets := []*Secret{}
+	for _, stored := range s.secrets[userID] {
+		meta := stored.meta
+		secrets = append(secrets, &meta)
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	return secrets, nil
+}
+
+func (s *InMemorySecretStore) GetSecretValue(ctx context.Context, userID, name string) (string, error) {
+	s.mu.RLock()
+	stored, ok := s.secrets[userID][name]
+	s.mu.RUnlock()
+	if !ok {
+		return "", ErrNotFound
+	}
+	return s.Cipher.Decrypt(stored.value)
+}
+
+func (s *InMemorySecretStore) DeleteSecret(ctx context.Context, userID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.secrets[userID][name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.secrets[userID], name)
+	return nil
+}
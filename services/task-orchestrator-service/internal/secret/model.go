@@ -0,0 +1,80 @@
+// services/task-orchestrator-service/internal/secret/model.go
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("secret not found")
+	ErrNoCipherKey   = errors.New("secret encryption key is not configured (SECRETS_KEY)")
+	ErrInvalidCipher = errors.New("secret value could not be decrypted")
+)
+
+// Secret es un valor sensible del usuario (ej. una cadena de conexión) que las
+// acciones referencian por nombre. El valor nunca se devuelve por la API.
+type Secret struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Cipher cifra y descifra los valores de los secretos con AES-256-GCM.
+// La clave se deriva con SHA-256 de la frase configurada en SECRETS_KEY.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher crea un Cipher; devuelve nil si no hay clave configurada.
+func NewCipher(passphrase string) (*Cipher, error) {
+	if passphrase == "" {
+		return nil, nil
+	}
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt devuelve nonce || ciphertext.
+func (c *Cipher) Encrypt(plaintext string) ([]byte, error) {
+	if c == nil {
+		return nil, ErrNoCipherKey
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, []byte(plaintext), nil), nil
+}
+
+// Decrypt invierte Encrypt.
+func (c *Cipher) Decrypt(data []byte) (string, error) {
+	if c == nil {
+		return "", ErrNoCipherKey
+	}
+	size := c.aead.NonceSize()
+	if len(data) < size {
+		return "", ErrInvalidCipher
+	}
+	plaintext, err := c.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return "", ErrInvalidCipher
+	}
+	return string(plaintext), nil
+}
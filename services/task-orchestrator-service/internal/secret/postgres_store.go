@@ -0,0 +1,93 @@
+// services/task-orchestrator-service/internal/secret/postgres_store.go
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresSecretStore struct {
+	DB     *pgxpool.Pool
+	Cipher *Cipher
+}
+
+func NewPostgresSecretStore(db *pgxpool.Pool, cipher *Cipher) *PostgresSecretStore {
+	return &PostgresSecretStore{DB: db, Cipher: cipher}
+}
+
+// SaveSecret crea o reemplaza el valor cifrado de un secreto del usuario.
+func (s *PostgresSecretStore) SaveSecret(ctx context.Context, userID, name, value string) (*Secret, error) {
+	encrypted, err := s.Cipher.Encrypt(value)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	query := `
+        INSERT INTO secrets (id, user_id, name, value, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $5)
+        ON CONFLICT (user_id, name) DO UPDATE SET
+            value = EXCLUDED.value,
+            updated_at = EXCLUDED.updated_at
+        RETURNING id, user_id, name, created_at, updated_at`
+
+	var sec Secret
+	err = s.DB.QueryRow(ctx, query, uuid.New(), userID, name, encrypted, now).
+		Scan(&sec.ID, &sec.UserID, &sec.Name, &sec.CreatedAt, &sec.UpdatedAt)
+	if err != nil {
+		log.Printf("Error saving secret to database: %v", err)
+		return nil, fmt.Errorf("could not save secret: %w", err)
+	}
+	return &sec, nil
+}
+
+func (s *PostgresSecretStore) ListSecrets(ctx context.Context, userID string) ([]*Secret, error) {
+	query := `SELECT id, user_id, name, created_at, updated_at FROM secrets WHERE user_id = $1 ORDER BY name`
+
+	rows, err := s.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	secrets := []*Secret{}
+	for rows.Next() {
+		var sec Secret
+		if err := rows.Scan(&sec.ID, &sec.UserID, &sec.Name, &sec.CreatedAt, &sec.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan secret row: %w", err)
+		}
+		secrets = append(secrets, &sec)
+	}
+	return secrets, rows.Err()
+}
+
+// GetSecretValue devuelve el valor descifrado; solo lo usa el motor de ejecución.
+func (s *PostgresSecretStore) GetSecretValue(ctx context.Context, userID, name string) (string, error) {
+	var encrypted []byte
+	err := s.DB.QueryRow(ctx, `SELECT value FROM secrets WHERE user_id = $1 AND name = $2`, userID, name).Scan(&encrypted)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("database query failed: %w", err)
+	}
+	return s.Cipher.Decrypt(encrypted)
+}
+
+func (s *PostgresSecretStore) DeleteSecret(ctx context.Context, userID, name string) error {
+	cmdTag, err := s.DB.Exec(ctx, `DELETE FROM secrets WHERE user_id = $1 AND name = $2`, userID, name)
+	if err != nil {
+		return fmt.Errorf("could not delete secret: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
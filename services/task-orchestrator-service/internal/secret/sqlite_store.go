@@ -0,0 +1,112 @@
+// services/task-orchestrator-service/internal/secret/sqlite_store.go
+package secret
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sqliteTimeLayout coincide con el formato de fechas del store de workflows en SQLite.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// SQLiteSecretStore guarda los secretos cifrados en la base SQLite (DATABASE_URL=sqlite://...).
+type SQLiteSecretStore struct {
+	DB     *sql.DB
+	Cipher *Cipher
+}
+
+func NewSQLiteSecretStore(db *sql.DB, cipher *Cipher) *SQLiteSecretStore {
+	return &SQLiteSecretStore{DB: db, Cipher: cipher}
+}
+
+// SaveSecret crea o reemplaza el valor cifrado de un secreto del usuario.
+func (s *SQLiteSecretStore) SaveSecret(ctx context.Context, userID, name, value string) (*Secret, error) {
+	encrypted, err := s.Cipher.Encrypt(value)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(sqliteTimeLayout)
+	query := `
+        INSERT INTO secrets (id, user_id, name, value, created_at, updated_at)
+        VALUES (?1, ?2, ?3, ?4, ?5, ?5)
+        ON CONFLICT (user_id, name) DO UPDATE SET
+            value = excluded.value,
+            updated_at = excluded.updated_at
+        RETURNING id, user_id, name, created_at, updated_at`
+
+	sec, err := scanSQLiteSecret(s.DB.QueryRowContext(ctx, query, uuid.New().String(), userID, name, encrypted, now))
+	if err != nil {
+		log.Printf("Error saving secret to database: %v", err)
+		return nil, fmt.Errorf("could not save secret: %w", err)
+	}
+	return sec, nil
+}
+
+func (s *SQLiteSecretStore) ListSecrets(ctx context.Context, userID string) ([]*Secret, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT id, user_id, name, created_at, updated_at FROM secrets WHERE user_id = ?1 ORDER BY name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	secrets := []*Secret{}
+	for rows.Next() {
+		sec, err := scanSQLiteSecret(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan secret row: %w", err)
+		}
+		secrets = append(secrets, sec)
+	}
+	return secrets, rows.Err()
+}
+
+// GetSecretValue devuelve el valor descifrado; solo lo usa el motor de ejecución.
+func (s *SQLiteSecretStore) GetSecretValue(ctx context.Context, userID, name string) (string, error) {
+	var encrypted []byte
+	err := s.DB.QueryRowContext(ctx, `SELECT value FROM secrets WHERE user_id = ?1 AND name = ?2`, userID, name).Scan(&encrypted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("database query failed: %w", err)
+	}
+	return s.Cipher.Decrypt(encrypted)
+}
+
+func (s *SQLiteSecretStore) DeleteSecret(ctx context.Context, userID, name string) error {
+	result, err := s.DB.ExecContext(ctx, `DELETE FROM secrets WHERE user_id = ?1 AND name = ?2`, userID, name)
+	if err != nil {
+		return fmt.Errorf("could not delete secret: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not delete secret: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanSQLiteSecret(row interface{ Scan(...any) error }) (*Secret, error) {
+	var sec Secret
+	var createdAt, updatedAt string
+	if err := row.Scan(&sec.ID, &sec.UserID, &sec.Name, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	var err error
+	if sec.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, fmt.Errorf("invalid created_at %q: %w", createdAt, err)
+	}
+	if sec.UpdatedAt, err = time.Parse(time.RFC3339Nano, updatedAt); err != nil {
+		return nil, fmt.Errorf("invalid updated_at %q: %w", updatedAt, err)
+	}
+	return &sec, nil
+}
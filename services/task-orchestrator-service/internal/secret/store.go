@@ -0,0 +1,14 @@
+// services/task-orchestrator-service/internal/secret/store.go
+package secret
+
+import "context"
+
+// Store define la interfaz para las operaciones de almacenamiento de secretos.
+// Todos los métodos reciben el contexto de la petición. GetSecretValue y
+// DeleteSecret devuelven ErrNotFound si el usuario no tiene ese secreto.
+type Store interface {
+	SaveSecret(ctx context.Context, userID, name, value string) (*Secret, error)
+	ListSecrets(ctx context.Context, userID string) ([]*Secret, error)
+	GetSecretValue(ctx context.Context, userID, name string) (string, error)
+	DeleteSecret(ctx context.Context, userID, name string) error
+}
@@ -0,0 +1,64 @@
+// services/task-orchestrator-service/internal/workflow/changes.go
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WorkflowChange es una de las escrituras de Store.ApplyWorkflowChanges: Save crea
+// o actualiza el workflow como SaveWorkflow y Delete lo mueve a la papelera como
+// DeleteWorkflow, condicionado a Delete.Version. Se indica solo uno de los dos.
+type WorkflowChange struct {
+	Save   *Workflow
+	Delete *Workflow
+}
+
+// WorkflowChangeError indica qué cambio del lote falló. Envuelve el error del store,
+// así que errors.Is sigue clasificándolo.
+type WorkflowChangeError struct {
+	Index int
+	Err   error
+}
+
+func (e *WorkflowChangeError) Error() string { return fmt.Sprintf("change %d: %v", e.Index, e.Err) }
+
+func (e *WorkflowChangeError) Unwrap() error { return e.Err }
+
+var errInvalidWorkflowChange = errors.New("a workflow change must either save or delete a workflow")
+
+// checkWorkflowChange rechaza un cambio sin operación (o con las dos) y el guardado
+// de un workflow de otro usuario.
+func checkWorkflowChange(userID string, change WorkflowChange) error {
+	switch {
+	case (change.Save == nil) == (change.Delete == nil):
+		return errInvalidWorkflowChange
+	case change.Save != nil && change.Save.UserID != userID:
+		return ErrWorkflowForbidden
+	}
+	return nil
+}
+
+// keepSavedState recuerda Version y CreatedAt de los workflows a guardar y devuelve
+// una función que los restaura: si el lote falla, los del llamador no se quedan con
+// versiones que nunca llegaron a guardarse.
+func keepSavedState(changes []WorkflowChange) func() {
+	type savedState struct {
+		version   int
+		createdAt time.Time
+	}
+	saved := make([]savedState, len(changes))
+	for i, change := range changes {
+		if change.Save != nil {
+			saved[i] = savedState{change.Save.Version, change.Save.CreatedAt}
+		}
+	}
+	return func() {
+		for i, change := range changes {
+			if change.Save != nil {
+				change.Save.Version, change.Save.CreatedAt = saved[i].version, saved[i].createdAt
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// services/task-orchestrator-service/internal/workflow/errors.go
+package workflow
+
+import "errors"
+
+// Errores genéricos del store. Los handlers los traducen a códigos HTTP con
+// errors.Is (404, 403, 409 y 412); los errores concretos de abajo los envuelven.
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrForbidden = errors.New("forbidden")
+	ErrConflict  = errors.New("conflict")
+	// ErrPreconditionFailed: la escritura esperaba una versión que ya no es la actual.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+var (
+	ErrWorkflowNotFound = &storeError{"workflow not found", ErrNotFound}
+	// ErrWorkflowForbidden: el workflow existe pero pertenece a otro usuario.
+	ErrWorkflowForbidden       = &storeError{"access to workflow denied", ErrForbidden}
+	ErrWorkflowVersionNotFound = &storeError{"workflow version not found", ErrNotFound}
+	// ErrWorkflowVersionMismatch: otra petición modificó el workflow desde que se leyó.
+	ErrWorkflowVersionMismatch = &storeError{"workflow has been modified since it was read", ErrPreconditionFailed}
+	// ErrWorkflowNotDeleted: se pidió restaurar un workflow que no está en la papelera.
+	ErrWorkflowNotDeleted = &storeError{"workflow is not in the trash", ErrConflict}
+	// ErrWorkflowManaged: el workflow lo gestiona un origen declarativo (sync) y
+	// solo se modifica desde ese origen.
+	ErrWorkflowManaged   = &storeError{"workflow is managed by a declarative source and is read-only", ErrConflict}
+	ErrExecutionNotFound = &storeError{"execution not found", ErrNotFound}
+	// ErrExecutionNotSuspended: la ejecución no existe o ya no está suspendida
+	// (otra petición la reanudó antes).
+	ErrExecutionNotSuspended = &storeError{"execution not found or not suspended", ErrConflict}
+	ErrExecutionExists       = &storeError{"execution already exists", ErrConflict}
+	// ErrExecutionLeaseLost: la ejecución ya no está en curso a nombre de esta réplica
+	// (terminó, o su reclamación caducó y otra réplica la reanudó).
+	ErrExecutionLeaseLost = &storeError{"execution lease lost", ErrConflict}
+)
+
+// workflowWriteError explica por qué una escritura condicionada de un workflow no
+// afectó a ninguna fila, a partir de su propietario actual (exists es false si no existe).
+func workflowWriteError(exists bool, ownerID, userID string) error {
+	switch {
+	case !exists:
+		return ErrWorkflowNotFound
+	case ownerID != userID:
+		return ErrWorkflowForbidden
+	default:
+		return ErrWorkflowVersionMismatch
+	}
+}
+
+// restoreError explica por qué RestoreWorkflow no restauró nada, a partir del
+// propietario actual del workflow (exists es false si no existe).
+func restoreError(exists bool, ownerID, userID string) error {
+	switch {
+	case !exists:
+		return ErrWorkflowNotFound
+	case ownerID != userID:
+		return ErrWorkflowForbidden
+	default:
+		return ErrWorkflowNotDeleted
+	}
+}
+
+// storeError es un error con mensaje propio que se clasifica con errors.Is
+// según el error genérico que envuelve.
+type storeError struct {
+	msg  string
+	kind error
+}
+
+func (e *storeError) Error() string { return e.msg }
+
+func (e *storeError) Unwrap() error { return e.kind }
@@ -0,0 +1,75 @@
+// services/task-orchestrator-service/internal/workflow/execution_query.go
+package workflow
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultExecutionPageSize = 50
+	MaxExecutionPageSize     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ExecutionCursor apunta a la última ejecución de una página. Las ejecuciones se
+// ordenan por triggered_at e id descendentes, así que la página siguiente empieza
+// en las estrictamente anteriores al cursor.
+type ExecutionCursor struct {
+	TriggeredAt time.Time
+	ID          uuid.UUID
+}
+
+// ExecutionFilter restringe los listados de ejecuciones. Los campos vacíos no filtran.
+type ExecutionFilter struct {
+	WorkflowID    *uuid.UUID
+	Statuses      []string
+	TriggerSource string
+	From          *time.Time // triggered_at >= From
+	To            *time.Time // triggered_at < To
+	After         *ExecutionCursor
+	Limit         int
+}
+
+// Encode serializa el cursor como texto opaco para la API.
+func (c ExecutionCursor) Encode() string {
+	raw := c.TriggeredAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeExecutionCursor interpreta un cursor generado por Encode.
+func DecodeExecutionCursor(s string) (*ExecutionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	triggeredAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &ExecutionCursor{TriggeredAt: triggeredAt, ID: id}, nil
+}
+
+// PageSize normaliza el límite pedido al rango permitido.
+func (f ExecutionFilter) PageSize() int {
+	switch {
+	case f.Limit <= 0:
+		return DefaultExecutionPageSize
+	case f.Limit > MaxExecutionPageSize:
+		return MaxExecutionPageSize
+	}
+	return f.Limit
+}
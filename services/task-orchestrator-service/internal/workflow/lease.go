@@ -0,0 +1,29 @@
+// services/task-orchestrator-service/internal/workflow/lease.go
+package workflow
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ExecutionLease es cuánto vale la reclamación de una ejecución reanudada sin
+// renovarse. Quien la reanuda la renueva (RenewExecutionLease) mientras corre; si
+// la réplica muere, al caducar ClaimDueExecutions la vuelve a reclamar.
+const ExecutionLease = 2 * time.Minute
+
+// LeaseOwner identifica a este proceso en claimed_by: host, pid y un sufijo
+// aleatorio, porque un contenedor reiniciado suele repetir host y pid.
+var LeaseOwner = newLeaseOwner()
+
+func newLeaseOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
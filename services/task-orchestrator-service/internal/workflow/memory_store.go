@@ -0,0 +1,720 @@
+// services/task-orchestrator-service/internal/workflow/memory_store.go
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InMemoryWorkflowStore implementa Store en memoria, protegido por un mutex.
+// Sirve para desarrollo (STORE_BACKEND=memory) y para tests sin Postgres.
+// Devuelve siempre copias, de modo que los llamadores no comparten estado con el store.
+// También implementa ExecutionNotifier para el stream de logs.
+type InMemoryWorkflowStore struct {
+	executionSubscribers
+
+	mu         sync.RWMutex
+	workflows  map[uuid.UUID]*Workflow
+	versions   map[uuid.UUID][]*WorkflowVersion // Por workflow, de la versión 1 en adelante
+	executions map[uuid.UUID]*ExecutionLog
+	leases     map[uuid.UUID]executionLease // Reclamación de cada ejecución reanudada
+}
+
+// executionLease son las columnas claimed_at y claimed_by de los stores SQL.
+type executionLease struct {
+	claimedAt time.Time
+	claimedBy string
+}
+
+var (
+	_ Store             = (*InMemoryWorkflowStore)(nil)
+	_ ExecutionNotifier = (*InMemoryWorkflowStore)(nil)
+)
+
+func NewInMemoryWorkflowStore() *InMemoryWorkflowStore {
+	return &InMemoryWorkflowStore{
+		workflows:  make(map[uuid.UUID]*Workflow),
+		versions:   make(map[uuid.UUID][]*WorkflowVersion),
+		executions: make(map[uuid.UUID]*ExecutionLog),
+		leases:     make(map[uuid.UUID]executionLease),
+	}
+}
+
+// SaveWorkflow inserta o actualiza un workflow y añade la versión resultante al
+// historial. Como en Postgres, una actualización conserva la fecha de creación original.
+func (s *InMemoryWorkflowStore) SaveWorkflow(ctx context.Context, wf *Workflow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveWorkflow(wf)
+}
+
+// ApplyWorkflowChanges aplica los cambios en orden y, si uno falla, deshace los
+// anteriores devolviendo a su estado previo los workflows y el historial que tocaron.
+func (s *InMemoryWorkflowStore) ApplyWorkflowChanges(ctx context.Context, userID string, changes []WorkflowChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type previous struct {
+		workflow *Workflow // nil si no existía
+		versions int
+	}
+	touched := map[uuid.UUID]previous{}
+	remember := func(id uuid.UUID) {
+		if _, ok := touched[id]; ok {
+			return
+		}
+		prev := previous{versions: len(s.versions[id])}
+		if wf, ok := s.workflows[id]; ok {
+			cp := *wf // DeleteWorkflow solo cambia DeletedAt; SaveWorkflow sustituye el puntero
+			prev.workflow = &cp
+		}
+		touched[id] = prev
+	}
+	undo := func() {
+		for id, prev := range touched {
+			if prev.workflow == nil {
+				delete(s.workflows, id)
+			} else {
+				s.workflows[id] = prev.workflow
+			}
+			if prev.versions == 0 {
+				delete(s.versions, id)
+			} else {
+				s.versions[id] = s.versions[id][:prev.versions]
+			}
+		}
+	}
+
+	restore := keepSavedState(changes)
+	for i, change := range changes {
+		err := checkWorkflowChange(userID, change)
+		switch {
+		case err != nil:
+		case change.Save != nil:
+			if change.Save.ID == uuid.Nil {
+				change.Save.ID = uuid.New()
+			}
+			remember(change.Save.ID)
+			err = s.saveWorkflow(change.Save)
+		default:
+			remember(change.Delete.ID)
+			err = s.deleteWorkflow(userID, change.Delete.ID, change.Delete.Version)
+		}
+		if err != nil {
+			undo()
+			restore()
+			return &WorkflowChangeError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+// saveWorkflow guarda el workflow. Requiere s.mu.
+func (s *InMemoryWorkflowStore) saveWorkflow(wf *Workflow) error {
+	if wf.ID == uuid.Nil {
+		wf.ID = uuid.New()
+	}
+	version := 1
+	createdAt := wf.CreatedAt
+	existing, ok := s.workflows[wf.ID]
+	switch {
+	case ok && existing.DeletedAt != nil:
+		return ErrWorkflowNotFound
+	case ok && existing.UserID != wf.UserID:
+		return ErrWorkflowForbidden
+	case wf.Version > 0 && !ok:
+		return ErrWorkflowNotFound
+	case wf.Version > 0 && existing.Version != wf.Version:
+		return ErrWorkflowVersionMismatch
+	}
+	if ok {
+		version = existing.Version + 1
+		createdAt = existing.CreatedAt
+	}
+	wf.Version, wf.CreatedAt = version, createdAt
+
+	stored, err := copyWorkflow(wf)
+	if err != nil {
+		return fmt.Errorf("could not save workflow: %w", err)
+	}
+	definition, err := copyWorkflow(wf)
+	if err != nil {
+		return fmt.Errorf("could not save workflow: %w", err)
+	}
+	s.workflows[wf.ID] = stored
+	s.versions[wf.ID] = append(s.versions[wf.ID], &WorkflowVersion{
+		WorkflowID: wf.ID, Version: version, Definition: definition, CreatedAt: wf.UpdatedAt,
+	})
+	return nil
+}
+
+// ListWorkflows lista los workflows del usuario que cumplen el filtro, de más
+// reciente a más antiguo. La búsqueda funciona como en SQLite: todas las palabras
+// deben aparecer en el nombre o la descripción.
+func (s *InMemoryWorkflowStore) ListWorkflows(ctx context.Context, userID string, filter WorkflowFilter) ([]*Workflow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make(map[string]bool, len(filter.LastRunStatuses))
+	for _, status := range filter.LastRunStatuses {
+		statuses[status] = true
+	}
+	terms := filter.SearchTerms()
+
+	workflows := []*Workflow{}
+	for _, wf := range s.workflows {
+		switch {
+		case wf.UserID != userID || wf.DeletedAt != nil,
+			!matchesTags(wf.Tags, filter.Tags),
+			filter.Folder != nil && wf.Folder != *filter.Folder,
+			filter.ManagedBy != nil && wf.ManagedBy != *filter.ManagedBy,
+			filter.TriggerType != "" && wf.Trigger.Type != filter.TriggerType,
+			filter.Enabled != nil && wf.IsEnabled != *filter.Enabled,
+			len(statuses) > 0 && !statuses[s.lastRunStatus(wf.ID)],
+			!matchesSearch(wf, terms):
+			continue
+		}
+		cp, err := copyWorkflow(wf)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, cp)
+	}
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].CreatedAt.After(workflows[j].CreatedAt) })
+	return workflows, nil
+}
+
+func (s *InMemoryWorkflowStore) GetWorkflowByID(ctx context.Context, userID string, workflowID uuid.UUID) (*Workflow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wf, err := s.ownedWorkflow(userID, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	return copyWorkflow(wf)
+}
+
+// lastRunStatus devuelve el estado de la ejecución más reciente del workflow, o ""
+// si nunca se ha ejecutado. Requiere s.mu.
+func (s *InMemoryWorkflowStore) lastRunStatus(workflowID uuid.UUID) string {
+	var last *ExecutionLog
+	for _, exec := range s.executions {
+		if exec.WorkflowID == workflowID && (last == nil || executionBefore(last, exec.TriggeredAt, exec.ID)) {
+			last = exec
+		}
+	}
+	if last == nil {
+		return ""
+	}
+	return last.Status
+}
+
+// ownedWorkflow devuelve el workflow si pertenece al usuario y no está en la
+// papelera. Requiere s.mu.
+func (s *InMemoryWorkflowStore) ownedWorkflow(userID string, workflowID uuid.UUID) (*Workflow, error) {
+	wf, ok := s.workflows[workflowID]
+	if !ok || wf.DeletedAt != nil {
+		return nil, ErrWorkflowNotFound
+	}
+	if wf.UserID != userID {
+		return nil, ErrWorkflowForbidden
+	}
+	return wf, nil
+}
+
+// DeleteWorkflow mueve el workflow a la papelera conservando sus ejecuciones.
+func (s *InMemoryWorkflowStore) DeleteWorkflow(ctx context.Context, userID string, workflowID uuid.UUID, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteWorkflow(userID, workflowID, version)
+}
+
+// deleteWorkflow mueve el workflow a la papelera. Requiere s.mu.
+func (s *InMemoryWorkflowStore) deleteWorkflow(userID string, workflowID uuid.UUID, version int) error {
+	wf, err := s.ownedWorkflow(userID, workflowID)
+	if err != nil {
+		return err
+	}
+	if version > 0 && wf.Version != version {
+		return ErrWorkflowVersionMismatch
+	}
+	now := time.Now().UTC()
+	wf.DeletedAt = &now
+	return nil
+}
+
+func (s *InMemoryWorkflowStore) ListDeletedWorkflows(ctx context.Context, userID string) ([]*Workflow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workflows := []*Workflow{}
+	for _, wf := range s.workflows {
+		if wf.UserID != userID || wf.DeletedAt == nil {
+			continue
+		}
+		cp, err := copyWorkflow(wf)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, cp)
+	}
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].DeletedAt.After(*workflows[j].DeletedAt) })
+	return workflows, nil
+}
+
+func (s *InMemoryWorkflowStore) RestoreWorkflow(ctx context.Context, userID string, workflowID uuid.UUID) (*Workflow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wf, ok := s.workflows[workflowID]
+	if !ok || wf.UserID != userID || wf.DeletedAt == nil {
+		var ownerID string
+		if ok {
+			ownerID = wf.UserID
+		}
+		return nil, restoreError(ok, ownerID, userID)
+	}
+	wf.DeletedAt = nil
+	return copyWorkflow(wf)
+}
+
+// PurgeDeletedWorkflows borra los workflows de la papelera junto con sus versiones
+// y ejecuciones (como ON DELETE CASCADE).
+func (s *InMemoryWorkflowStore) PurgeDeletedWorkflows(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []*Workflow
+	for _, wf := range s.workflows {
+		if wf.DeletedAt != nil && wf.DeletedAt.Before(deletedBefore) {
+			expired = append(expired, wf)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].DeletedAt.Before(*expired[j].DeletedAt) })
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	for _, wf := range expired {
+		delete(s.workflows, wf.ID)
+		delete(s.versions, wf.ID)
+		for id, exec := range s.executions {
+			if exec.WorkflowID == wf.ID {
+				delete(s.executions, id)
+				delete(s.leases, id)
+			}
+		}
+	}
+	if len(expired) > 0 {
+		s.clearParentLinks()
+	}
+	return len(expired), nil
+}
+
+func (s *InMemoryWorkflowStore) ListWorkflowVersions(ctx context.Context, userID string, workflowID uuid.UUID) ([]*WorkflowVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.ownedWorkflow(userID, workflowID); err != nil {
+		return nil, err
+	}
+	history := s.versions[workflowID]
+	versions := make([]*WorkflowVersion, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		cp, err := copyWorkflowVersion(history[i])
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, cp)
+	}
+	return versions, nil
+}
+
+func (s *InMemoryWorkflowStore) GetWorkflowVersion(ctx context.Context, userID string, workflowID uuid.UUID, version int) (*WorkflowVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, err := s.ownedWorkflow(userID, workflowID); err != nil {
+		return nil, err
+	}
+	history := s.versions[workflowID]
+	if version < 1 || version > len(history) {
+		return nil, ErrWorkflowVersionNotFound
+	}
+	return copyWorkflowVersion(history[version-1])
+}
+
+func (s *InMemoryWorkflowStore) GetAllEnabledScheduledWorkflows(ctx context.Context) ([]*Workflow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workflows := []*Workflow{}
+	for _, wf := range s.workflows {
+		if !wf.IsEnabled || wf.Trigger.Type != TriggerTypeSchedule || wf.DeletedAt != nil {
+			continue
+		}
+		cp, err := copyWorkflow(wf)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, cp)
+	}
+	return workflows, nil
+}
+
+func (s *InMemoryWorkflowStore) CreateExecution(ctx context.Context, exec *ExecutionLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.executions[exec.ID]; ok {
+		return ErrExecutionExists
+	}
+	if _, ok := s.workflows[exec.WorkflowID]; !ok {
+		return ErrWorkflowNotFound
+	}
+	stored := copyExecution(exec)
+	stored.Steps = stepsOrEmpty(stored.Steps)
+	s.executions[exec.ID] = stored
+	return nil
+}
+
+func (s *InMemoryWorkflowStore) UpdateExecution(ctx context.Context, exec *ExecutionLog) error {
+	s.mu.Lock()
+	stored, ok := s.executions[exec.ID]
+	if ok {
+		updated := copyExecution(exec)
+		// Igual que el UPDATE de Postgres: solo cambian las columnas de estado.
+		stored.Status = updated.Status
+		stored.CompletedAt = updated.CompletedAt
+		stored.Logs = updated.Logs
+		stored.Steps = stepsOrEmpty(updated.Steps)
+		stored.ResumeAt = updated.ResumeAt
+		stored.State = updated.State
+		stored.WaitEvent = updated.WaitEvent
+		stored.WaitKey = updated.WaitKey
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrExecutionNotFound
+	}
+	s.notify(exec.ID)
+	return nil
+}
+
+func (s *InMemoryWorkflowStore) ListExecutions(ctx context.Context, userID string, filter ExecutionFilter) ([]*ExecutionLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make(map[string]bool, len(filter.Statuses))
+	for _, status := range filter.Statuses {
+		statuses[status] = true
+	}
+
+	executions := []*ExecutionLog{}
+	for _, exec := range s.executions {
+		switch {
+		case exec.UserID != userID,
+			filter.WorkflowID != nil && exec.WorkflowID != *filter.WorkflowID,
+			len(statuses) > 0 && !statuses[exec.Status],
+			filter.TriggerSource != "" && exec.TriggerSource != filter.TriggerSource,
+			filter.From != nil && exec.TriggeredAt.Before(*filter.From),
+			filter.To != nil && !exec.TriggeredAt.Before(*filter.To),
+			filter.After != nil && !executionBefore(exec, filter.After.TriggeredAt, filter.After.ID):
+			continue
+		}
+		summary := copyExecution(exec)
+		summary.Logs, summary.Steps, summary.State = nil, nil, nil
+		executions = append(executions, summary)
+	}
+
+	sortExecutionsNewestFirst(executions)
+	if limit := filter.PageSize() + 1; len(executions) > limit {
+		executions = executions[:limit]
+	}
+	return executions, nil
+}
+
+func (s *InMemoryWorkflowStore) GetExecutionByID(ctx context.Context, userID string, executionID uuid.UUID) (*ExecutionLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	exec, ok := s.executions[executionID]
+	if !ok || exec.UserID != userID {
+		return nil, ErrExecutionNotFound
+	}
+	return copyExecution(exec), nil
+}
+
+func (s *InMemoryWorkflowStore) ClaimDueExecutions(ctx context.Context, now time.Time, limit int) ([]*ExecutionLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*ExecutionLog
+	for _, exec := range s.executions {
+		lease, claimed := s.leases[exec.ID]
+		switch {
+		case exec.Status == "suspended" && exec.ResumeAt != nil && !exec.ResumeAt.After(now):
+			due = append(due, exec)
+		case exec.Status == "running" && claimed && !lease.claimedAt.After(now.Add(-ExecutionLease)):
+			due = append(due, exec)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].ResumeAt == nil || due[j].ResumeAt == nil {
+			return due[i].ResumeAt != nil
+		}
+		return due[i].ResumeAt.Before(*due[j].ResumeAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return s.claim(due, now), nil
+}
+
+func (s *InMemoryWorkflowStore) RenewExecutionLease(ctx context.Context, executionID uuid.UUID, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exec, ok := s.executions[executionID]
+	lease, claimed := s.leases[executionID]
+	if !ok || !claimed || exec.Status != "running" || lease.claimedBy != LeaseOwner {
+		return ErrExecutionLeaseLost
+	}
+	s.leases[executionID] = executionLease{claimedAt: now, claimedBy: LeaseOwner}
+	return nil
+}
+
+func (s *InMemoryWorkflowStore) ClaimSuspendedExecution(ctx context.Context, executionID uuid.UUID, userID, waitKey string) (*ExecutionLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exec, ok := s.executions[executionID]
+	if !ok || exec.Status != "suspended" || (userID != "" && exec.UserID != userID) || (waitKey != "" && exec.WaitKey != waitKey) {
+		return nil, ErrExecutionNotSuspended
+	}
+	return s.claim([]*ExecutionLog{exec}, time.Now())[0], nil
+}
+
+func (s *InMemoryWorkflowStore) ClaimExecutionsWaitingForEvent(ctx context.Context, userID string, workflowID uuid.UUID, eventName, correlationKey string) ([]*ExecutionLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var waiting []*ExecutionLog
+	for _, exec := range s.executions {
+		if exec.Status == "suspended" && exec.UserID == userID && exec.WorkflowID == workflowID &&
+			exec.WaitEvent == eventName && exec.WaitKey == correlationKey {
+			waiting = append(waiting, exec)
+		}
+	}
+	return s.claim(waiting, time.Now()), nil
+}
+
+// claim marca las ejecuciones como "running" a nombre de esta réplica y devuelve
+// copias. Requiere s.mu.
+func (s *InMemoryWorkflowStore) claim(executions []*ExecutionLog, now time.Time) []*ExecutionLog {
+	claimed := make([]*ExecutionLog, 0, len(executions))
+	for _, exec := range executions {
+		exec.Status = "running"
+		s.leases[exec.ID] = executionLease{claimedAt: now, claimedBy: LeaseOwner}
+		claimed = append(claimed, copyExecution(exec))
+	}
+	return claimed
+}
+
+func (s *InMemoryWorkflowStore) AppendExecutionLogs(ctx context.Context, executionID uuid.UUID, entries []json.RawMessage) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	exec, ok := s.executions[executionID]
+	if ok {
+		var logged []json.RawMessage
+		if len(exec.Logs) > 0 {
+			if err := json.Unmarshal(exec.Logs, &logged); err != nil {
+				s.mu.Unlock()
+				return fmt.Errorf("failed to append execution logs: %w", err)
+			}
+		}
+		for _, entry := range entries {
+			logged = append(logged, append(json.RawMessage(nil), entry...))
+		}
+		logs, err := json.Marshal(logged)
+		if err != nil {
+			s.mu.Unlock()
+			return fmt.Errorf("failed to append execution logs: %w", err)
+		}
+		exec.Logs = logs
+	}
+	s.mu.Unlock()
+
+	if ok {
+		s.notify(executionID)
+	}
+	return nil
+}
+
+func (s *InMemoryWorkflowStore) GetExecutionLogsSince(ctx context.Context, userID string, executionID uuid.UUID, offset int) ([]json.RawMessage, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	exec, ok := s.executions[executionID]
+	if !ok || exec.UserID != userID {
+		return nil, "", ErrExecutionNotFound
+	}
+	var entries []json.RawMessage
+	if len(exec.Logs) > 0 {
+		if err := json.Unmarshal(exec.Logs, &entries); err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal execution logs: %w", err)
+		}
+	}
+	if offset >= len(entries) {
+		return []json.RawMessage{}, exec.Status, nil
+	}
+	return entries[offset:], exec.Status, nil
+}
+
+// PurgeExpiredExecutions aplica las mismas reglas que la versión de Postgres.
+func (s *InMemoryWorkflowStore) PurgeExpiredExecutions(ctx context.Context, defaults RetentionLimits, now time.Time, limit int, archive func([]*ExecutionLog) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byWorkflow := make(map[uuid.UUID][]*ExecutionLog)
+	for _, exec := range s.executions {
+		byWorkflow[exec.WorkflowID] = append(byWorkflow[exec.WorkflowID], exec)
+	}
+
+	var expired []*ExecutionLog
+	for workflowID, executions := range byWorkflow {
+		wf, ok := s.workflows[workflowID]
+		if !ok {
+			continue
+		}
+		maxAgeDays, maxCount := defaults.MaxAgeDays, defaults.MaxCount
+		if wf.Retention != nil && wf.Retention.MaxAgeDays != nil {
+			maxAgeDays = *wf.Retention.MaxAgeDays
+		}
+		if wf.Retention != nil && wf.Retention.MaxCount != nil {
+			maxCount = *wf.Retention.MaxCount
+		}
+
+		sortExecutionsNewestFirst(executions)
+		for position, exec := range executions {
+			if exec.Status != "completed" && exec.Status != "failed" {
+				continue
+			}
+			tooOld := maxAgeDays > 0 && exec.TriggeredAt.Before(now.AddDate(0, 0, -maxAgeDays))
+			tooMany := maxCount > 0 && position+1 > maxCount
+			if tooOld || tooMany {
+				expired = append(expired, exec)
+			}
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].TriggeredAt.Before(expired[j].TriggeredAt) })
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	if archive != nil {
+		copies := make([]*ExecutionLog, len(expired))
+		for i, exec := range expired {
+			copies[i] = copyExecution(exec)
+		}
+		if err := archive(copies); err != nil {
+			return 0, fmt.Errorf("failed to archive executions: %w", err)
+		}
+	}
+	for _, exec := range expired {
+		delete(s.executions, exec.ID)
+		delete(s.leases, exec.ID)
+	}
+	s.clearParentLinks()
+	return len(expired), nil
+}
+
+// clearParentLinks imita ON DELETE SET NULL de parent_execution_id. Requiere s.mu.
+func (s *InMemoryWorkflowStore) clearParentLinks() {
+	for _, exec := range s.executions {
+		if exec.ParentExecutionID != nil {
+			if _, ok := s.executions[*exec.ParentExecutionID]; !ok {
+				exec.ParentExecutionID = nil
+			}
+		}
+	}
+}
+
+// executionBefore indica si exec va después del cursor (triggeredAt, id) en orden descendente.
+func executionBefore(exec *ExecutionLog, triggeredAt time.Time, id uuid.UUID) bool {
+	if !exec.TriggeredAt.Equal(triggeredAt) {
+		return exec.TriggeredAt.Before(triggeredAt)
+	}
+	return bytes.Compare(exec.ID[:], id[:]) < 0
+}
+
+func sortExecutionsNewestFirst(executions []*ExecutionLog) {
+	sort.Slice(executions, func(i, j int) bool {
+		return executionBefore(executions[j], executions[i].TriggeredAt, executions[i].ID)
+	})
+}
+
+// copyWorkflow hace una copia profunda pasando por JSON, igual que al guardar en JSONB.
+func copyWorkflow(wf *Workflow) (*Workflow, error) {
+	data, err := json.Marshal(wf)
+	if err != nil {
+		return nil, err
+	}
+	var cp Workflow
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func copyWorkflowVersion(wv *WorkflowVersion) (*WorkflowVersion, error) {
+	definition, err := copyWorkflow(wv.Definition)
+	if err != nil {
+		return nil, err
+	}
+	cp := *wv
+	cp.Definition = definition
+	return &cp, nil
+}
+
+func copyExecution(exec *ExecutionLog) *ExecutionLog {
+	cp := *exec
+	cp.Logs = cloneJSON(exec.Logs)
+	cp.Steps = cloneJSON(exec.Steps)
+	cp.State = cloneJSON(exec.State)
+	cp.CompletedAt = cloneTime(exec.CompletedAt)
+	cp.ResumeAt = cloneTime(exec.ResumeAt)
+	if exec.ParentExecutionID != nil {
+		parent := *exec.ParentExecutionID
+		cp.ParentExecutionID = &parent
+	}
+	return &cp
+}
+
+func cloneJSON(data json.RawMessage) json.RawMessage {
+	if data == nil {
+		return nil
+	}
+	return append(json.RawMessage(nil), data...)
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	cp := *t
+	return &cp
+}
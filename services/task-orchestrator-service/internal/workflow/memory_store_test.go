@@ -0,0 +1,15 @@
+// services/task-orchestrator-service/internal/workflow/memory_store_test.go
+package workflow_test
+
+import (
+	"testing"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow/storetest"
+)
+
+func TestInMemoryWorkflowStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) workflow.Store {
+		return workflow.NewInMemoryWorkflowStore()
+	})
+}
@@ -1,7 +1,6 @@
 package workflow
 
 import (
-	"fmt"
     "time"
     "github.com/google/uuid"
 )
@@ -21,7 +20,14 @@ type ActionType string
 const (
     ActionTypeLogMessage      ActionType = "log_message"       // Simplemente escribe un mensaje en el log
     ActionTypeHTTPEndpoint    ActionType = "http_endpoint"     // Llama a una URL externa
-    // Podríamos añadir más (ej. ActionTypeSendEmail)
+    ActionTypeSendEmail       ActionType = "send_email"        // Envía un correo a través del relay SMTP configurado
+    ActionTypeSQLQuery        ActionType = "sql_query"         // Ejecuta una consulta contra una conexión Postgres guardada como secreto
+    ActionTypeScript          ActionType = "script"            // Ejecuta un script Starlark aislado para transformar datos
+    ActionTypeTransform       ActionType = "transform"         // Aplica una expresión jq a la salida de un paso previo
+    ActionTypeDelay           ActionType = "delay"             // Suspende la ejecución durante una duración
+    ActionTypeWaitUntil       ActionType = "wait_until"        // Suspende la ejecución hasta un instante concreto
+    ActionTypeApproval        ActionType = "approval"          // Suspende la ejecución hasta que una persona apruebe o rechace
+    ActionTypeWaitForEvent    ActionType = "wait_for_event"    // Suspende la ejecución hasta recibir un evento externo correlacionado
 )
 
 // TriggerDefinition contiene la configuración para un disparador
@@ -32,10 +38,26 @@ type TriggerDefinition struct {
 
 // ActionDefinition contiene la configuración para una acción
 type ActionDefinition struct {
-    Type        ActionType             `json:"type" validate:"required,oneof=log_message http_endpoint"`
+    Type        ActionType             `json:"type" validate:"required,oneof=log_message http_endpoint send_email sql_query script transform delay wait_until approval wait_for_event"`
     Name        string                 `json:"name" validate:"required"` // Un nombre descriptivo para el paso de acción
-    Config      map[string]interface{} `json:"config"`                   // Ej. para log_message: {"message": "Tarea ejecutada"}, para http_endpoint: {"url": "...", "method": "GET/POST", "body": "..."}
+    Config      map[string]interface{} `json:"config"`                   // Ej. para log_message: {"message": "Tarea ejecutada"}, para http_endpoint: {"url": "...", "method": "GET/POST", "body": "..."}, para send_email: {"to": [...], "subject": "...", "text": "..."}
     DependsOn   []string               `json:"depends_on,omitempty"`     // Nombres de otras acciones de las que depende (para flujos secuenciales/paralelos básicos)
+    Compensate  *ActionDefinition      `json:"compensate,omitempty"`     // Acción que deshace este paso si la ejecución falla más adelante (saga)
+    ContinueOnError bool               `json:"continue_on_error,omitempty"` // Si falla, se registra el error y la ejecución sigue como si nada
+}
+
+// RetentionPolicy limita cuánto se conservan las ejecuciones terminadas de un workflow.
+// Un campo nulo usa el valor global; 0 significa sin límite.
+type RetentionPolicy struct {
+    MaxAgeDays *int `json:"max_age_days,omitempty" validate:"omitempty,min=0"`
+    MaxCount   *int `json:"max_count,omitempty" validate:"omitempty,min=0"`
+}
+
+// RetentionLimits son los límites globales que se aplican cuando el workflow no
+// define los suyos. 0 significa sin límite.
+type RetentionLimits struct {
+    MaxAgeDays int
+    MaxCount   int
 }
 
 // Workflow es la estructura principal para nuestra definición de tarea
@@ -44,84 +66,19 @@ type Workflow struct {
     UserID          string             `json:"user_id"` // Vendrá del token JWT
     Name            string             `json:"name" validate:"required,min=3,max=100"`
     Description     string             `json:"description,omitempty"`
+    Tags            map[string]string  `json:"tags,omitempty" validate:"omitempty,max=50,dive,keys,min=1,max=63,excludesall=:,endkeys,max=255"` // Etiquetas clave/valor para filtrar
+    Folder          string             `json:"folder,omitempty" validate:"omitempty,max=100"` // Carpeta o proyecto al que pertenece
     Trigger         TriggerDefinition  `json:"trigger" validate:"required"`
     Actions         []ActionDefinition `json:"actions" validate:"required,min=1,dive"` // 'dive' valida cada elemento del slice
+    OnFailure       []ActionDefinition `json:"on_failure,omitempty" validate:"omitempty,dive"` // Acciones que se ejecutan si la ejecución falla
+    FailFast        bool               `json:"fail_fast"` // Detener la ejecución en el primer fallo (las acciones restantes quedan "skipped")
+    Retention       *RetentionPolicy   `json:"retention,omitempty"` // Sobrescribe la retención global de ejecuciones
     IsEnabled       bool               `json:"is_enabled"`
+    Version         int                `json:"version"` // Se incrementa en cada guardado; cada versión queda en workflow_versions
     CreatedAt       time.Time          `json:"created_at"`
     UpdatedAt       time.Time          `json:"updated_at"`
     LastRunAt       *time.Time         `json:"last_run_at,omitempty"` // Puntero para que pueda ser nulo
     NextRunAt       *time.Time         `json:"next_run_at,omitempty"` // Para triggers de schedule
+    DeletedAt       *time.Time         `json:"deleted_at,omitempty"` // Fecha en que se movió a la papelera
+    ManagedBy       string             `json:"managed_by,omitempty"` // Origen declarativo (sync) que lo gestiona; si no está vacío la API no permite modificarlo
 }
-
-// --- Almacenamiento en Memoria (Temporal) ---
-// Usaremos un mapa para guardar los workflows, la clave será el UserID
-// y el valor será otro mapa de WorkflowID a Workflow.
-// Esto es para desarrollo; lo reemplazaremos con una BD.
-var workflowStore = make(map[string]map[uuid.UUID]*Workflow)
-
-// Funciones de ayuda para el store en memoria (ejemplos)
-func GetWorkflowsByUserID(userID string) ([]*Workflow, error) {
-    userWorkflows, ok := workflowStore[userID]
-    if !ok {
-        return []*Workflow{}, nil // No hay workflows para este usuario aún
-    }
-
-    list := make([]*Workflow, 0, len(userWorkflows))
-    for _, wf := range userWorkflows {
-        list = append(list, wf)
-    }
-    return list, nil
-}
-
-func GetWorkflowByID(userID string, workflowID uuid.UUID) (*Workflow, bool) {
-    userWorkflows, ok := workflowStore[userID]
-    if !ok {
-        return nil, false
-    }
-    wf, found := userWorkflows[workflowID]
-    return wf, found
-}
-
-func SaveWorkflow(wf *Workflow) error {
-    if wf.ID == uuid.Nil {
-        wf.ID = uuid.New() // Asignar nuevo ID si es nuevo
-    }
-    if _, ok := workflowStore[wf.UserID]; !ok {
-        workflowStore[wf.UserID] = make(map[uuid.UUID]*Workflow)
-    }
-    // Simulación de validación simple
-    if wf.Name == "" {
-        return fmt.Errorf("workflow name cannot be empty")
-    }
-    workflowStore[wf.UserID][wf.ID] = wf
-    return nil
-}
-
- func DeleteWorkflow(userID string, workflowID uuid.UUID) bool {
-    userWorkflows, ok := workflowStore[userID]
-    if !ok {
-        return false // No hay workflows para este usuario
-    }
-    if _, found := userWorkflows[workflowID]; found {
-        delete(userWorkflows, workflowID)
-        return true
-    }
-    return false
-}
-
-
-type InMemoryWorkflowStore struct{}
-
-func (s *InMemoryWorkflowStore) GetAllEnabledScheduledWorkflows() ([]*Workflow, error) {
-    allScheduled := []*Workflow{}
-    // Iteramos sobre todos los usuarios y sus workflows
-    // Esto es simple para un store en memoria; una BD lo haría más eficientemente.
-    for _, userWorkflows := range workflowStore { // workflowStore es nuestro mapa global en memoria
-        for _, wf := range userWorkflows {
-            if wf.IsEnabled && wf.Trigger.Type == TriggerTypeSchedule {
-                allScheduled = append(allScheduled, wf)
-            }
-        }
-    }
-    return allScheduled, nil
-}
\ No newline at end of file
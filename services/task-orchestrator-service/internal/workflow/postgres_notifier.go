@@ -0,0 +1,109 @@
+// services/task-orchestrator-service/internal/workflow/postgres_notifier.go
+package workflow
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExecutionLogsChannel es el canal de NOTIFY por el que se anuncian los cambios
+// de una ejecución; el payload es el ID de la ejecución.
+const ExecutionLogsChannel = "execution_logs"
+
+// executionSubscribers reparte los avisos entre los suscriptores locales de cada ejecución.
+type executionSubscribers struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan struct{}]struct{}
+}
+
+// Subscribe registra interés en una ejecución. El canal tiene buffer de 1: varios
+// avisos seguidos se agrupan en uno, ya que el suscriptor relee desde su posición.
+func (n *executionSubscribers) Subscribe(executionID uuid.UUID) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	n.mu.Lock()
+	if n.subscribers == nil {
+		n.subscribers = make(map[uuid.UUID]map[chan struct{}]struct{})
+	}
+	if n.subscribers[executionID] == nil {
+		n.subscribers[executionID] = make(map[chan struct{}]struct{})
+	}
+	n.subscribers[executionID][ch] = struct{}{}
+	n.mu.Unlock()
+
+	return ch, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		delete(n.subscribers[executionID], ch)
+		if len(n.subscribers[executionID]) == 0 {
+			delete(n.subscribers, executionID)
+		}
+	}
+}
+
+func (n *executionSubscribers) notify(executionID uuid.UUID) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ch := range n.subscribers[executionID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// PostgresExecutionNotifier mantiene una conexión dedicada con LISTEN y reparte
+// los avisos entre los suscriptores locales de cada ejecución.
+type PostgresExecutionNotifier struct {
+	executionSubscribers
+	DB *pgxpool.Pool
+}
+
+func NewPostgresExecutionNotifier(db *pgxpool.Pool) *PostgresExecutionNotifier {
+	return &PostgresExecutionNotifier{DB: db}
+}
+
+// Run escucha el canal hasta que ctx se cancela, reconectando si se pierde la conexión.
+func (n *PostgresExecutionNotifier) Run(ctx context.Context) {
+	for {
+		if err := n.listen(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("ERROR: Execution notifier lost its connection: %v; retrying in 5s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (n *PostgresExecutionNotifier) listen(ctx context.Context) error {
+	pooled, err := n.DB.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// La conexión sale del pool para que nadie más la reutilice con LISTEN activo.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+ExecutionLogsChannel); err != nil {
+		return err
+	}
+	log.Printf("Execution notifier listening on '%s'", ExecutionLogsChannel)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		executionID, err := uuid.Parse(notification.Payload)
+		if err != nil {
+			continue
+		}
+		n.notify(executionID)
+	}
+}
@@ -7,15 +7,15 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time" // <-- Asegúrate de que time esté importado
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var ErrNotFound = errors.New("workflow not found")
-
 // ExecutionLog represents a workflow execution log entry.
 // CORREGIDO: Se han cambiado los tipos de TriggeredAt y CompletedAt.
 type ExecutionLog struct {
@@ -25,9 +25,26 @@ type ExecutionLog struct {
 	Status      string          `json:"status"`
 	TriggeredAt time.Time       `json:"triggered_at"`         // <-- CORREGIDO de string a time.Time
 	CompletedAt *time.Time      `json:"completed_at,omitempty"` // <-- CORREGIDO de string a *time.Time
-	Logs        json.RawMessage `json:"logs"`
+	Logs        json.RawMessage `json:"logs,omitempty"`  // Se omite en los listados
+	Steps       json.RawMessage `json:"steps,omitempty"` // Resultado de cada acción (estado, salida, error)
+	ResumeAt    *time.Time      `json:"resume_at,omitempty"` // Cuándo reanudar una ejecución suspendida
+	State       json.RawMessage `json:"-"`                   // Estado interno del motor para reanudar
+	WaitEvent   string          `json:"wait_event,omitempty"` // Evento externo que espera la ejecución suspendida
+	WaitKey     string          `json:"wait_key,omitempty"`   // Clave de correlación del evento esperado
+	TriggerSource string        `json:"trigger_source,omitempty"` // Origen del disparo: "schedule", "manual", "webhook", "rerun", "resume"...
+	ParentExecutionID *uuid.UUID `json:"parent_execution_id,omitempty"` // Ejecución original de un re-run o reanudación
+	WorkflowVersion int          `json:"workflow_version,omitempty"` // Versión del workflow con la que corrió (0 si es anterior al historial)
 }
 
+// Códigos SQLSTATE que CreateExecution traduce a errores del store.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+// pgWorkflowColumns son las columnas que lee scanWorkflow, en ese orden.
+const pgWorkflowColumns = `id, user_id, name, description, trigger, actions, on_failure, fail_fast, retention, is_enabled, created_at, updated_at, version, deleted_at, tags, folder, managed_by`
+
 type PostgresWorkflowStore struct {
 	DB *pgxpool.Pool
 }
@@ -36,8 +53,61 @@ func NewPostgresWorkflowStore(db *pgxpool.Pool) *PostgresWorkflowStore {
 	return &PostgresWorkflowStore{DB: db}
 }
 
+// pgQuerier lo cumplen *pgxpool.Pool y pgx.Tx.
+type pgQuerier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // SaveWorkflow inserta o actualiza un workflow en la base de datos.
-func (s *PostgresWorkflowStore) SaveWorkflow(wf *Workflow) error {
+func (s *PostgresWorkflowStore) SaveWorkflow(ctx context.Context, wf *Workflow) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not save workflow: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := saveWorkflow(ctx, tx, wf); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("could not save workflow: %w", err)
+	}
+	return nil
+}
+
+// ApplyWorkflowChanges aplica los cambios en una transacción.
+func (s *PostgresWorkflowStore) ApplyWorkflowChanges(ctx context.Context, userID string, changes []WorkflowChange) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("could not apply workflow changes: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	restore := keepSavedState(changes)
+	for i, change := range changes {
+		err := checkWorkflowChange(userID, change)
+		switch {
+		case err != nil:
+		case change.Save != nil:
+			err = saveWorkflow(ctx, tx, change.Save)
+		default:
+			err = deleteWorkflow(ctx, tx, userID, change.Delete.ID, change.Delete.Version)
+		}
+		if err != nil {
+			restore()
+			return &WorkflowChangeError{Index: i, Err: err}
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		restore()
+		return fmt.Errorf("could not apply workflow changes: %w", err)
+	}
+	return nil
+}
+
+// saveWorkflow guarda el workflow y su versión dentro de la transacción tx.
+func saveWorkflow(ctx context.Context, tx pgx.Tx, wf *Workflow) error {
 	// Convertimos los campos de struct/slice a JSON para guardarlos en columnas JSONB.
 	triggerJSON, err := json.Marshal(wf.Trigger)
 	if err != nil {
@@ -49,37 +119,112 @@ func (s *PostgresWorkflowStore) SaveWorkflow(wf *Workflow) error {
 		return fmt.Errorf("failed to marshal actions: %w", err)
 	}
 
-	query := `
-        INSERT INTO workflows (id, user_id, name, description, trigger, actions, is_enabled, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-        ON CONFLICT (id) DO UPDATE SET
-            name = EXCLUDED.name,
-            description = EXCLUDED.description,
-            trigger = EXCLUDED.trigger,
-            actions = EXCLUDED.actions,
-            is_enabled = EXCLUDED.is_enabled,
-            updated_at = EXCLUDED.updated_at;
-    `
-	_, err = s.DB.Exec(context.Background(), query,
-		wf.ID, wf.UserID, wf.Name, wf.Description, triggerJSON, actionsJSON, wf.IsEnabled, wf.CreatedAt, wf.UpdatedAt)
+	onFailure := wf.OnFailure
+	if onFailure == nil {
+		onFailure = []ActionDefinition{}
+	}
+	onFailureJSON, err := json.Marshal(onFailure)
+	if err != nil {
+		return fmt.Errorf("failed to marshal on_failure: %w", err)
+	}
+
+	var retentionJSON []byte
+	if wf.Retention != nil {
+		if retentionJSON, err = json.Marshal(wf.Retention); err != nil {
+			return fmt.Errorf("failed to marshal retention: %w", err)
+		}
+	}
 
+	tags := wf.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	tagsJSON, err := json.Marshal(tags)
 	if err != nil {
+		return fmt.Errorf("failed to marshal tags: %w", err)
+	}
+
+	// Sin versión esperada se hace un upsert; su WHERE descarta la fila si el id ya
+	// pertenece a otro usuario o está en la papelera. Con versión, solo se actualiza
+	// si sigue siendo la actual.
+	var row pgx.Row
+	expected := wf.Version
+	if expected > 0 {
+		query := `
+            UPDATE workflows SET
+                name = $3,
+                description = $4,
+                trigger = $5,
+                actions = $6,
+                on_failure = $7,
+                fail_fast = $8,
+                retention = $9,
+                is_enabled = $10,
+                updated_at = $11,
+                tags = $13,
+                folder = $14,
+                managed_by = $15,
+                version = version + 1
+            WHERE id = $1 AND user_id = $2 AND version = $12 AND deleted_at IS NULL
+            RETURNING version, created_at;
+        `
+		row = tx.QueryRow(ctx, query,
+			wf.ID, wf.UserID, wf.Name, wf.Description, triggerJSON, actionsJSON, onFailureJSON, wf.FailFast, retentionJSON, wf.IsEnabled, wf.UpdatedAt, expected, tagsJSON, wf.Folder, wf.ManagedBy)
+	} else {
+		query := `
+            INSERT INTO workflows (id, user_id, name, description, trigger, actions, on_failure, fail_fast, retention, is_enabled, created_at, updated_at, tags, folder, managed_by, version)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, 1)
+            ON CONFLICT (id) DO UPDATE SET
+                name = EXCLUDED.name,
+                description = EXCLUDED.description,
+                trigger = EXCLUDED.trigger,
+                actions = EXCLUDED.actions,
+                on_failure = EXCLUDED.on_failure,
+                fail_fast = EXCLUDED.fail_fast,
+                retention = EXCLUDED.retention,
+                is_enabled = EXCLUDED.is_enabled,
+                updated_at = EXCLUDED.updated_at,
+                tags = EXCLUDED.tags,
+                folder = EXCLUDED.folder,
+                managed_by = EXCLUDED.managed_by,
+                version = workflows.version + 1
+            WHERE workflows.user_id = EXCLUDED.user_id AND workflows.deleted_at IS NULL
+            RETURNING version, created_at;
+        `
+		row = tx.QueryRow(ctx, query,
+			wf.ID, wf.UserID, wf.Name, wf.Description, triggerJSON, actionsJSON, onFailureJSON, wf.FailFast, retentionJSON, wf.IsEnabled, wf.CreatedAt, wf.UpdatedAt, tagsJSON, wf.Folder, wf.ManagedBy)
+	}
+	if err := row.Scan(&wf.Version, &wf.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgWorkflowWriteError(ctx, tx, wf.ID, wf.UserID)
+		}
 		log.Printf("Error saving workflow to database: %v", err)
 		return fmt.Errorf("could not save workflow: %w", err)
 	}
+
+	definition, err := json.Marshal(wf)
+	if err != nil {
+		return fmt.Errorf("failed to marshal workflow version: %w", err)
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO workflow_versions (workflow_id, version, definition, created_at) VALUES ($1, $2, $3, $4)`,
+		wf.ID, wf.Version, definition, wf.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("could not save workflow version: %w", err)
+	}
 	return nil
 }
 
 // scanWorkflow es una función de ayuda para escanear una fila de la BD a un struct Workflow.
 func scanWorkflow(row pgx.Row) (*Workflow, error) {
 	var wf Workflow
-	var triggerJSON, actionsJSON []byte
+	var triggerJSON, actionsJSON, onFailureJSON, retentionJSON, tagsJSON []byte
 
 	// Asumiendo que las columnas last_run_at y next_run_at no están en esta consulta.
 	// Si estuvieran, necesitarías añadirlas aquí.
 	err := row.Scan(
-		&wf.ID, &wf.UserID, &wf.Name, &wf.Description, &triggerJSON, &actionsJSON,
-		&wf.IsEnabled, &wf.CreatedAt, &wf.UpdatedAt,
+		&wf.ID, &wf.UserID, &wf.Name, &wf.Description, &triggerJSON, &actionsJSON, &onFailureJSON,
+		&wf.FailFast, &retentionJSON, &wf.IsEnabled, &wf.CreatedAt, &wf.UpdatedAt, &wf.Version, &wf.DeletedAt,
+		&tagsJSON, &wf.Folder, &wf.ManagedBy,
 	)
 	if err != nil {
 		return nil, err
@@ -91,14 +236,84 @@ func scanWorkflow(row pgx.Row) (*Workflow, error) {
 	if err := json.Unmarshal(actionsJSON, &wf.Actions); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal actions: %w", err)
 	}
+	if len(onFailureJSON) > 0 {
+		if err := json.Unmarshal(onFailureJSON, &wf.OnFailure); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal on_failure: %w", err)
+		}
+	}
+	if len(retentionJSON) > 0 {
+		if err := json.Unmarshal(retentionJSON, &wf.Retention); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal retention: %w", err)
+		}
+	}
+	if err := json.Unmarshal(tagsJSON, &wf.Tags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
+	}
+	if len(wf.Tags) == 0 {
+		wf.Tags = nil
+	}
 	return &wf, nil
 }
 
-func (s *PostgresWorkflowStore) GetWorkflowsByUserID(userID string) ([]*Workflow, error) {
-	query := `SELECT id, user_id, name, description, trigger, actions, is_enabled, created_at, updated_at 
-              FROM workflows WHERE user_id = $1 ORDER BY created_at DESC`
+// ListWorkflows lista los workflows del usuario que cumplen el filtro, de más
+// reciente a más antiguo. Con búsqueda de texto se ordenan antes por relevancia
+// (ts_rank sobre search_vector); websearch_to_tsquery admite "frases" y -exclusiones.
+func (s *PostgresWorkflowStore) ListWorkflows(ctx context.Context, userID string, filter WorkflowFilter) ([]*Workflow, error) {
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	args := []interface{}{userID}
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	// Las etiquetas con valor se agrupan en un único @> y las de solo clave en ?&,
+	// ambos servidos por el índice GIN de tags.
+	withValue := map[string]string{}
+	var keysOnly []string
+	for _, sel := range filter.Tags {
+		if sel.Value != nil {
+			withValue[sel.Key] = *sel.Value
+		} else {
+			keysOnly = append(keysOnly, sel.Key)
+		}
+	}
+	if len(withValue) > 0 {
+		tagsJSON, err := json.Marshal(withValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal tag filter: %w", err)
+		}
+		addCondition("tags @> $%d::jsonb", tagsJSON)
+	}
+	if len(keysOnly) > 0 {
+		addCondition("tags ?& $%d::text[]", keysOnly)
+	}
+	if filter.Folder != nil {
+		addCondition("folder = $%d", *filter.Folder)
+	}
+	if filter.ManagedBy != nil {
+		addCondition("managed_by = $%d", *filter.ManagedBy)
+	}
+	if filter.TriggerType != "" {
+		addCondition("trigger->>'type' = $%d", string(filter.TriggerType))
+	}
+	if filter.Enabled != nil {
+		addCondition("is_enabled = $%d", *filter.Enabled)
+	}
+	if len(filter.LastRunStatuses) > 0 {
+		addCondition(`(SELECT e.status FROM workflow_executions e WHERE e.workflow_id = workflows.id
+                ORDER BY e.triggered_at DESC, e.id DESC LIMIT 1) = ANY($%d)`, filter.LastRunStatuses)
+	}
+	orderBy := "created_at DESC"
+	if strings.TrimSpace(filter.Search) != "" {
+		addCondition("search_vector @@ websearch_to_tsquery('simple', $%d)", filter.Search)
+		orderBy = fmt.Sprintf("ts_rank(search_vector, websearch_to_tsquery('simple', $%d)) DESC, created_at DESC", len(args))
+	}
+
+	query := `SELECT ` + pgWorkflowColumns + `
+              FROM workflows WHERE ` + strings.Join(conditions, " AND ") + `
+              ORDER BY ` + orderBy
 
-	rows, err := s.DB.Query(context.Background(), query, userID)
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("database query failed: %w", err)
 	}
@@ -112,41 +327,175 @@ func (s *PostgresWorkflowStore) GetWorkflowsByUserID(userID string) ([]*Workflow
 		}
 		workflows = append(workflows, wf)
 	}
-	return workflows, nil
+	return workflows, rows.Err()
 }
 
-func (s *PostgresWorkflowStore) GetWorkflowByID(userID string, workflowID uuid.UUID) (*Workflow, bool) {
-	query := `SELECT id, user_id, name, description, trigger, actions, is_enabled, created_at, updated_at 
-              FROM workflows WHERE id = $1 AND user_id = $2`
+// GetWorkflowByID devuelve ErrWorkflowNotFound si no existe o está en la papelera
+// y ErrWorkflowForbidden si pertenece a otro usuario.
+func (s *PostgresWorkflowStore) GetWorkflowByID(ctx context.Context, userID string, workflowID uuid.UUID) (*Workflow, error) {
+	query := `SELECT ` + pgWorkflowColumns + `
+              FROM workflows WHERE id = $1`
 
-	row := s.DB.QueryRow(context.Background(), query, workflowID, userID)
+	row := s.DB.QueryRow(ctx, query, workflowID)
 	wf, err := scanWorkflow(row)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, false
+			return nil, ErrWorkflowNotFound
+		}
+		return nil, fmt.Errorf("failed to load workflow: %w", err)
+	}
+	if wf.DeletedAt != nil {
+		return nil, ErrWorkflowNotFound
+	}
+	if wf.UserID != userID {
+		return nil, ErrWorkflowForbidden
+	}
+	return wf, nil
+}
+
+// DeleteWorkflow mueve el workflow a la papelera; sus ejecuciones se conservan
+// hasta que PurgeDeletedWorkflows lo borra de verdad. Si no cambia nada, distingue
+// entre un workflow inexistente, uno de otro usuario y una versión que ya no es la actual.
+func (s *PostgresWorkflowStore) DeleteWorkflow(ctx context.Context, userID string, workflowID uuid.UUID, version int) error {
+	return deleteWorkflow(ctx, s.DB, userID, workflowID, version)
+}
+
+func deleteWorkflow(ctx context.Context, q pgQuerier, userID string, workflowID uuid.UUID, version int) error {
+	query := `UPDATE workflows SET deleted_at = NOW()
+              WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)`
+	cmdTag, err := q.Exec(ctx, query, workflowID, userID, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete workflow: %w", err)
+	}
+	if cmdTag.RowsAffected() > 0 {
+		return nil
+	}
+	return pgWorkflowWriteError(ctx, q, workflowID, userID)
+}
+
+// pgWorkflowWriteError consulta el propietario actual para explicar una escritura
+// condicionada que no afectó a ninguna fila. Un workflow en la papelera cuenta como inexistente.
+func pgWorkflowWriteError(ctx context.Context, q pgQuerier, workflowID uuid.UUID, userID string) error {
+	var ownerID string
+	err := q.QueryRow(ctx, `SELECT user_id FROM workflows WHERE id = $1 AND deleted_at IS NULL`, workflowID).Scan(&ownerID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to look up workflow: %w", err)
+	}
+	return workflowWriteError(err == nil, ownerID, userID)
+}
+
+// ListDeletedWorkflows devuelve la papelera del usuario, del borrado más reciente al más antiguo.
+func (s *PostgresWorkflowStore) ListDeletedWorkflows(ctx context.Context, userID string) ([]*Workflow, error) {
+	query := `SELECT ` + pgWorkflowColumns + `
+              FROM workflows WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+
+	rows, err := s.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	workflows := []*Workflow{}
+	for rows.Next() {
+		wf, err := scanWorkflow(rows)
+		if err != nil {
+			return nil, err
 		}
-		log.Printf("Error scanning workflow by ID: %v", err)
-		return nil, false
+		workflows = append(workflows, wf)
+	}
+	return workflows, rows.Err()
+}
+
+// RestoreWorkflow saca el workflow de la papelera.
+func (s *PostgresWorkflowStore) RestoreWorkflow(ctx context.Context, userID string, workflowID uuid.UUID) (*Workflow, error) {
+	query := `UPDATE workflows SET deleted_at = NULL
+              WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+              RETURNING ` + pgWorkflowColumns
+	wf, err := scanWorkflow(s.DB.QueryRow(ctx, query, workflowID, userID))
+	if err == nil {
+		return wf, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to restore workflow: %w", err)
+	}
+
+	var ownerID string
+	err = s.DB.QueryRow(ctx, `SELECT user_id FROM workflows WHERE id = $1`, workflowID).Scan(&ownerID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to look up workflow: %w", err)
+	}
+	return nil, restoreError(err == nil, ownerID, userID)
+}
+
+// PurgeDeletedWorkflows borra definitivamente (con sus ejecuciones y versiones)
+// hasta limit workflows que llevan en la papelera desde antes de deletedBefore.
+func (s *PostgresWorkflowStore) PurgeDeletedWorkflows(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	query := `DELETE FROM workflows WHERE id IN (
+                  SELECT id FROM workflows WHERE deleted_at < $1 ORDER BY deleted_at LIMIT $2)`
+	cmdTag, err := s.DB.Exec(ctx, query, deletedBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted workflows: %w", err)
 	}
-	return wf, true
+	return int(cmdTag.RowsAffected()), nil
 }
 
-func (s *PostgresWorkflowStore) DeleteWorkflow(userID string, workflowID uuid.UUID) bool {
-	query := `DELETE FROM workflows WHERE id = $1 AND user_id = $2`
-	cmdTag, err := s.DB.Exec(context.Background(), query, workflowID, userID)
+// ListWorkflowVersions devuelve el historial del workflow, de la versión más reciente a la más antigua.
+func (s *PostgresWorkflowStore) ListWorkflowVersions(ctx context.Context, userID string, workflowID uuid.UUID) ([]*WorkflowVersion, error) {
+	if _, err := s.GetWorkflowByID(ctx, userID, workflowID); err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(ctx, `SELECT workflow_id, version, definition, created_at FROM workflow_versions
+		WHERE workflow_id = $1 ORDER BY version DESC`, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []*WorkflowVersion{}
+	for rows.Next() {
+		version, err := scanWorkflowVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workflow version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+func (s *PostgresWorkflowStore) GetWorkflowVersion(ctx context.Context, userID string, workflowID uuid.UUID, version int) (*WorkflowVersion, error) {
+	if _, err := s.GetWorkflowByID(ctx, userID, workflowID); err != nil {
+		return nil, err
+	}
+	row := s.DB.QueryRow(ctx, `SELECT workflow_id, version, definition, created_at FROM workflow_versions
+		WHERE workflow_id = $1 AND version = $2`, workflowID, version)
+	wv, err := scanWorkflowVersion(row)
 	if err != nil {
-		log.Printf("Error deleting workflow: %v", err)
-		return false
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWorkflowVersionNotFound
+		}
+		return nil, fmt.Errorf("failed to load workflow version: %w", err)
+	}
+	return wv, nil
+}
+
+func scanWorkflowVersion(row pgx.Row) (*WorkflowVersion, error) {
+	var wv WorkflowVersion
+	var definition []byte
+	if err := row.Scan(&wv.WorkflowID, &wv.Version, &definition, &wv.CreatedAt); err != nil {
+		return nil, err
 	}
-	return cmdTag.RowsAffected() > 0
+	if err := json.Unmarshal(definition, &wv.Definition); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workflow version: %w", err)
+	}
+	return &wv, nil
 }
 
-func (s *PostgresWorkflowStore) GetAllEnabledScheduledWorkflows() ([]*Workflow, error) {
+func (s *PostgresWorkflowStore) GetAllEnabledScheduledWorkflows(ctx context.Context) ([]*Workflow, error) {
 	// Esta consulta busca en el campo JSONB del trigger.
-	query := `SELECT id, user_id, name, description, trigger, actions, is_enabled, created_at, updated_at 
-              FROM workflows WHERE is_enabled = TRUE AND trigger->>'type' = 'schedule'`
+	query := `SELECT ` + pgWorkflowColumns + `
+              FROM workflows WHERE is_enabled = TRUE AND trigger->>'type' = 'schedule' AND deleted_at IS NULL`
 
-	rows, err := s.DB.Query(context.Background(), query)
+	rows, err := s.DB.Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("database query for scheduler failed: %w", err)
 	}
@@ -160,14 +509,14 @@ func (s *PostgresWorkflowStore) GetAllEnabledScheduledWorkflows() ([]*Workflow,
 		}
 		workflows = append(workflows, wf)
 	}
-	return workflows, nil
+	return workflows, rows.Err()
 }
 
 // CreateExecution ahora recibe un `ExecutionLog` con los tipos de fecha correctos.
-func (s *PostgresWorkflowStore) CreateExecution(exec *ExecutionLog) error {
+func (s *PostgresWorkflowStore) CreateExecution(ctx context.Context, exec *ExecutionLog) error {
 	query := `
-        INSERT INTO workflow_executions (id, workflow_id, user_id, status, triggered_at, logs)
-        VALUES ($1, $2, $3, $4, $5, $6)`
+        INSERT INTO workflow_executions (id, workflow_id, user_id, status, triggered_at, logs, steps, resume_at, state, wait_event, wait_key, trigger_source, parent_execution_id, workflow_version)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), $13, NULLIF($14, 0))`
 
 	// El campo `exec.Logs` ya viene como json.RawMessage, por lo que no necesita Marshal aquí
 	// si se inicializa como json.RawMessage("[]"). Si lo inicializas como un slice de LogEntry,
@@ -177,82 +526,360 @@ func (s *PostgresWorkflowStore) CreateExecution(exec *ExecutionLog) error {
 	// Pero el `exec` que llega aquí ya tiene los logs como `json.RawMessage("[]")`
 	// El motor de ejecución es el que debe hacer el marshal final.
 	// Para la inserción inicial, el valor de logs es simple.
-	_, err := s.DB.Exec(context.Background(), query, exec.ID, exec.WorkflowID, exec.UserID, exec.Status, exec.TriggeredAt, exec.Logs)
+	_, err := s.DB.Exec(ctx, query, exec.ID, exec.WorkflowID, exec.UserID, exec.Status, exec.TriggeredAt, exec.Logs, stepsOrEmpty(exec.Steps), exec.ResumeAt, nullableJSON(exec.State), exec.WaitEvent, exec.WaitKey, exec.TriggerSource, exec.ParentExecutionID, exec.WorkflowVersion)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case pgUniqueViolation:
+				return ErrExecutionExists
+			case pgForeignKeyViolation:
+				return ErrWorkflowNotFound
+			}
+		}
 		return fmt.Errorf("failed to insert execution record: %w", err)
 	}
 	return nil
 }
 
+// stepsOrEmpty evita guardar NULL cuando el motor todavía no produjo resultados de pasos.
+func stepsOrEmpty(steps json.RawMessage) json.RawMessage {
+	if len(steps) == 0 {
+		return json.RawMessage("[]")
+	}
+	return steps
+}
+
+// nullableJSON guarda NULL en lugar de un JSON vacío.
+func nullableJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
 // UpdateExecution actualiza un registro de ejecución al finalizar un workflow.
-func (s *PostgresWorkflowStore) UpdateExecution(exec *ExecutionLog) error {
+func (s *PostgresWorkflowStore) UpdateExecution(ctx context.Context, exec *ExecutionLog) error {
 	query := `
-        UPDATE workflow_executions 
-        SET status = $1, completed_at = $2, logs = $3
-        WHERE id = $4`
+        WITH updated AS (
+            UPDATE workflow_executions 
+            SET status = $1, completed_at = $2, logs = $3, steps = $4, resume_at = $5, state = $6,
+                wait_event = NULLIF($7, ''), wait_key = NULLIF($8, '')
+            WHERE id = $9
+            RETURNING id
+        )
+        SELECT pg_notify('` + ExecutionLogsChannel + `', id::text) FROM updated`
 	
 	// En la actualización, `exec.Logs` sí contiene los logs completos que han sido
 	// convertidos a json.RawMessage por el motor de ejecución.
-	_, err := s.DB.Exec(context.Background(), query, exec.Status, exec.CompletedAt, exec.Logs, exec.ID)
+	cmdTag, err := s.DB.Exec(ctx, query, exec.Status, exec.CompletedAt, exec.Logs, stepsOrEmpty(exec.Steps), exec.ResumeAt, nullableJSON(exec.State), exec.WaitEvent, exec.WaitKey, exec.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update execution record: %w", err)
 	}
+	// El SELECT final devuelve una fila por cada ejecución actualizada.
+	if cmdTag.RowsAffected() == 0 {
+		return ErrExecutionNotFound
+	}
 	return nil
 }
 
-
-func (s *PostgresWorkflowStore) GetExecutionsByWorkflowID(userID string, workflowID uuid.UUID) ([]*ExecutionLog, error) {
-	log.Printf("DEBUG: GetExecutionsByWorkflowID called with userID=%s, workflowID=%s", userID, workflowID)
-	
-	// Primero verificamos que el usuario tenga acceso al workflow
-	_, exists := s.GetWorkflowByID(userID, workflowID)
-	if !exists {
-		log.Printf("DEBUG: Workflow %s not found or access denied for user %s", workflowID, userID)
-		return nil, fmt.Errorf("workflow not found or access denied")
+// AppendExecutionLogs añade las entradas al final de logs y avisa con un solo
+// NOTIFY a quienes siguen la ejecución en cualquier réplica.
+func (s *PostgresWorkflowStore) AppendExecutionLogs(ctx context.Context, executionID uuid.UUID, entries []json.RawMessage) error {
+	if len(entries) == 0 {
+		return nil
 	}
-	
-	log.Printf("DEBUG: Workflow access verified, querying executions...")
+	batch, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to append execution logs: %w", err)
+	}
+	query := `
+        WITH updated AS (
+            UPDATE workflow_executions
+            SET logs = COALESCE(logs, '[]'::jsonb) || $2::jsonb, updated_at = NOW()
+            WHERE id = $1
+            RETURNING id
+        )
+        SELECT pg_notify('` + ExecutionLogsChannel + `', id::text) FROM updated`
+	if _, err := s.DB.Exec(ctx, query, executionID, batch); err != nil {
+		return fmt.Errorf("failed to append execution logs: %w", err)
+	}
+	return nil
+}
 
+// GetExecutionLogsSince devuelve las entradas de log posteriores a offset sin
+// releer el log completo en cada aviso.
+func (s *PostgresWorkflowStore) GetExecutionLogsSince(ctx context.Context, userID string, executionID uuid.UUID, offset int) ([]json.RawMessage, string, error) {
 	query := `
-		SELECT id, workflow_id, user_id, status, triggered_at, completed_at, logs
-		FROM workflow_executions 
-		WHERE workflow_id = $1 AND user_id = $2 
-		ORDER BY triggered_at DESC`
+        SELECT we.status,
+            COALESCE(jsonb_agg(t.entry ORDER BY t.idx) FILTER (WHERE t.entry IS NOT NULL), '[]'::jsonb)
+        FROM workflow_executions we
+        LEFT JOIN LATERAL jsonb_array_elements(COALESCE(we.logs, '[]'::jsonb)) WITH ORDINALITY AS t(entry, idx)
+            ON t.idx > $3
+        WHERE we.id = $1 AND we.user_id = $2
+        GROUP BY we.status`
+
+	var status string
+	var logsJSON []byte
+	err := s.DB.QueryRow(ctx, query, executionID, userID, offset).Scan(&status, &logsJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", ErrExecutionNotFound
+		}
+		return nil, "", fmt.Errorf("failed to load execution logs: %w", err)
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal(logsJSON, &entries); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal execution logs: %w", err)
+	}
+	return entries, status, nil
+}
+
+// ListExecutions construye la consulta según los filtros presentes. Los listados
+// no incluyen logs ni pasos: para eso está GetExecutionByID.
+func (s *PostgresWorkflowStore) ListExecutions(ctx context.Context, userID string, filter ExecutionFilter) ([]*ExecutionLog, error) {
+	conditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
 
-	rows, err := s.DB.Query(context.Background(), query, workflowID, userID)
+	if filter.WorkflowID != nil {
+		addCondition("workflow_id = $%d", *filter.WorkflowID)
+	}
+	if len(filter.Statuses) > 0 {
+		addCondition("status = ANY($%d)", filter.Statuses)
+	}
+	if filter.TriggerSource != "" {
+		addCondition("trigger_source = $%d", filter.TriggerSource)
+	}
+	if filter.From != nil {
+		addCondition("triggered_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("triggered_at < $%d", *filter.To)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.TriggeredAt, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(triggered_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	args = append(args, filter.PageSize()+1)
+
+	query := `
+		SELECT id, workflow_id, user_id, status, triggered_at, completed_at, resume_at,
+			COALESCE(wait_event, ''), COALESCE(wait_key, ''), COALESCE(trigger_source, ''), parent_execution_id, COALESCE(workflow_version, 0)
+		FROM workflow_executions
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY triggered_at DESC, id DESC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
-		log.Printf("ERROR: Database query failed for GetExecutionsByWorkflowID: %v", err)
+		log.Printf("ERROR: Database query failed for ListExecutions: %v", err)
 		return nil, fmt.Errorf("database query failed: %w", err)
 	}
 	defer rows.Close()
 
-	var executions []*ExecutionLog
-	rowCount := 0
-	
+	executions := []*ExecutionLog{}
 	for rows.Next() {
 		var exec ExecutionLog
-		err := rows.Scan(
-			&exec.ID,
-			&exec.WorkflowID,
-			&exec.UserID,
-			&exec.Status,
-			&exec.TriggeredAt,
-			&exec.CompletedAt,
-			&exec.Logs,
-		)
+		err := rows.Scan(&exec.ID, &exec.WorkflowID, &exec.UserID, &exec.Status, &exec.TriggeredAt,
+			&exec.CompletedAt, &exec.ResumeAt, &exec.WaitEvent, &exec.WaitKey, &exec.TriggerSource, &exec.ParentExecutionID, &exec.WorkflowVersion)
 		if err != nil {
-			log.Printf("ERROR: Failed to scan execution row %d: %v", rowCount, err)
 			return nil, fmt.Errorf("failed to scan execution row: %w", err)
 		}
 		executions = append(executions, &exec)
-		rowCount++
 	}
-
-	if err = rows.Err(); err != nil {
-		log.Printf("ERROR: Error iterating execution rows: %v", err)
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating execution rows: %w", err)
 	}
-
-	log.Printf("SUCCESS: Retrieved %d executions for workflow %s", len(executions), workflowID)
 	return executions, nil
-}
\ No newline at end of file
+}
+
+// GetExecutionByID devuelve una ejecución con sus logs, resultados por paso y
+// estado interno (necesario para re-ejecutarla o reanudarla).
+func (s *PostgresWorkflowStore) GetExecutionByID(ctx context.Context, userID string, executionID uuid.UUID) (*ExecutionLog, error) {
+	query := `
+		SELECT id, workflow_id, user_id, status, triggered_at, completed_at, logs, steps, resume_at, state,
+			COALESCE(wait_event, ''), COALESCE(wait_key, ''), COALESCE(trigger_source, ''), parent_execution_id, COALESCE(workflow_version, 0)
+		FROM workflow_executions
+		WHERE id = $1 AND user_id = $2`
+
+	var exec ExecutionLog
+	err := s.DB.QueryRow(ctx, query, executionID, userID).Scan(
+		&exec.ID, &exec.WorkflowID, &exec.UserID, &exec.Status, &exec.TriggeredAt, &exec.CompletedAt,
+		&exec.Logs, &exec.Steps, &exec.ResumeAt, &exec.State, &exec.WaitEvent, &exec.WaitKey, &exec.TriggerSource,
+		&exec.ParentExecutionID, &exec.WorkflowVersion)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExecutionNotFound
+		}
+		return nil, fmt.Errorf("failed to load execution: %w", err)
+	}
+	return &exec, nil
+}
+
+// PurgeExpiredExecutions selecciona y borra en una misma transacción. FOR UPDATE
+// SKIP LOCKED evita que dos réplicas archiven y borren las mismas filas.
+// Solo se purgan ejecuciones "completed" o "failed"; el recuento por workflow
+// (max_count) incluye todas sus ejecuciones, de la más reciente a la más antigua.
+func (s *PostgresWorkflowStore) PurgeExpiredExecutions(ctx context.Context, defaults RetentionLimits, now time.Time, limit int, archive func([]*ExecutionLog) error) (int, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin purge transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		WITH ranked AS (
+			SELECT e.id, e.status, e.triggered_at,
+				COALESCE((w.retention->>'max_age_days')::int, $1) AS max_age_days,
+				COALESCE((w.retention->>'max_count')::int, $2) AS max_count,
+				ROW_NUMBER() OVER (PARTITION BY e.workflow_id ORDER BY e.triggered_at DESC, e.id DESC) AS position
+			FROM workflow_executions e
+			JOIN workflows w ON w.id = e.workflow_id
+		)
+		SELECT ` + claimedExecutionColumns + `
+		FROM workflow_executions
+		WHERE id IN (
+			SELECT id FROM ranked
+			WHERE status IN ('completed', 'failed')
+				AND ((max_age_days > 0 AND triggered_at < $3::timestamptz - make_interval(days => max_age_days))
+					OR (max_count > 0 AND position > max_count))
+			ORDER BY triggered_at
+			LIMIT $4
+		)
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.Query(ctx, query, defaults.MaxAgeDays, defaults.MaxCount, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select expired executions: %w", err)
+	}
+	executions, err := scanClaimedExecutions(rows)
+	if err != nil {
+		return 0, err
+	}
+	if len(executions) == 0 {
+		return 0, nil
+	}
+
+	if archive != nil {
+		if err := archive(executions); err != nil {
+			return 0, fmt.Errorf("failed to archive executions: %w", err)
+		}
+	}
+
+	ids := make([]uuid.UUID, len(executions))
+	for i, exec := range executions {
+		ids[i] = exec.ID
+	}
+	cmdTag, err := tx.Exec(ctx, `DELETE FROM workflow_executions WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired executions: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit purge: %w", err)
+	}
+	return int(cmdTag.RowsAffected()), nil
+}
+
+// ClaimDueExecutions reclama atómicamente las ejecuciones suspendidas que deben
+// reanudarse y las reanudadas cuya reclamación caducó. FOR UPDATE SKIP LOCKED
+// evita que dos réplicas reanuden la misma.
+func (s *PostgresWorkflowStore) ClaimDueExecutions(ctx context.Context, now time.Time, limit int) ([]*ExecutionLog, error) {
+	query := `
+		UPDATE workflow_executions SET status = 'running', claimed_at = $1, claimed_by = $3
+		WHERE id IN (
+			SELECT id FROM workflow_executions
+			WHERE (status = 'suspended' AND resume_at <= $1)
+				OR (status = 'running' AND claimed_at <= $4)
+			ORDER BY resume_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + claimedExecutionColumns
+
+	rows, err := s.DB.Query(ctx, query, now, limit, LeaseOwner, now.Add(-ExecutionLease))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due executions: %w", err)
+	}
+	return scanClaimedExecutions(rows)
+}
+
+// RenewExecutionLease actualiza claimed_at si la ejecución sigue en curso a nombre de esta réplica.
+func (s *PostgresWorkflowStore) RenewExecutionLease(ctx context.Context, executionID uuid.UUID, now time.Time) error {
+	cmdTag, err := s.DB.Exec(ctx,
+		`UPDATE workflow_executions SET claimed_at = $2 WHERE id = $1 AND status = 'running' AND claimed_by = $3`,
+		executionID, now, LeaseOwner)
+	if err != nil {
+		return fmt.Errorf("failed to renew execution lease: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrExecutionLeaseLost
+	}
+	return nil
+}
+
+// claimedExecutionColumns son las columnas que devuelven los UPDATE ... RETURNING
+// de las ejecuciones que se van a reanudar (incluye el estado interno). También
+// se usan al purgar, para archivar la fila completa.
+const claimedExecutionColumns = `id, workflow_id, user_id, status, triggered_at, completed_at, logs, steps, resume_at, state,
+		COALESCE(wait_event, ''), COALESCE(wait_key, ''), COALESCE(trigger_source, ''), parent_execution_id, COALESCE(workflow_version, 0)`
+
+func scanClaimedExecution(row pgx.Row) (*ExecutionLog, error) {
+	var exec ExecutionLog
+	err := row.Scan(&exec.ID, &exec.WorkflowID, &exec.UserID, &exec.Status, &exec.TriggeredAt,
+		&exec.CompletedAt, &exec.Logs, &exec.Steps, &exec.ResumeAt, &exec.State, &exec.WaitEvent, &exec.WaitKey, &exec.TriggerSource,
+		&exec.ParentExecutionID, &exec.WorkflowVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &exec, nil
+}
+
+func scanClaimedExecutions(rows pgx.Rows) ([]*ExecutionLog, error) {
+	defer rows.Close()
+	var executions []*ExecutionLog
+	for rows.Next() {
+		exec, err := scanClaimedExecution(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan execution row: %w", err)
+		}
+		executions = append(executions, exec)
+	}
+	return executions, rows.Err()
+}
+
+// ClaimSuspendedExecution reclama una ejecución suspendida concreta (ej. al recibir una aprobación).
+// El UPDATE condicional garantiza que solo una petición la reanude, y solo si sigue
+// esperando waitKey: un enlace ya usado no escribe nada.
+func (s *PostgresWorkflowStore) ClaimSuspendedExecution(ctx context.Context, executionID uuid.UUID, userID, waitKey string) (*ExecutionLog, error) {
+	query := `
+		UPDATE workflow_executions SET status = 'running', claimed_at = NOW(), claimed_by = $3
+		WHERE id = $1 AND status = 'suspended' AND ($2 = '' OR user_id = $2) AND ($4 = '' OR wait_key = $4)
+		RETURNING ` + claimedExecutionColumns
+
+	exec, err := scanClaimedExecution(s.DB.QueryRow(ctx, query, executionID, userID, LeaseOwner, waitKey))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExecutionNotSuspended
+		}
+		return nil, fmt.Errorf("failed to claim execution: %w", err)
+	}
+	return exec, nil
+}
+
+// ClaimExecutionsWaitingForEvent reclama las ejecuciones del workflow que esperan un evento con esa clave.
+func (s *PostgresWorkflowStore) ClaimExecutionsWaitingForEvent(ctx context.Context, userID string, workflowID uuid.UUID, eventName, correlationKey string) ([]*ExecutionLog, error) {
+	query := `
+		UPDATE workflow_executions SET status = 'running', claimed_at = NOW(), claimed_by = $5
+		WHERE status = 'suspended' AND wait_event = $1 AND wait_key = $2 AND user_id = $3 AND workflow_id = $4
+		RETURNING ` + claimedExecutionColumns
+
+	rows, err := s.DB.Query(ctx, query, eventName, correlationKey, userID, workflowID, LeaseOwner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim executions waiting for event: %w", err)
+	}
+	return scanClaimedExecutions(rows)
+}
@@ -0,0 +1,41 @@
+// services/task-orchestrator-service/internal/workflow/postgres_store_test.go
+package workflow_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow/storetest"
+	"github.com/guildmember145/task-orchestrator-service/pkg/database"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TEST_DATABASE_URL debe apuntar a una base de datos dedicada a tests:
+// cada subtest vacía las tablas de workflows y ejecuciones.
+const testDatabaseURLEnv = "TEST_DATABASE_URL"
+
+func TestPostgresWorkflowStoreConformance(t *testing.T) {
+	dsn := os.Getenv(testDatabaseURLEnv)
+	if dsn == "" {
+		t.Skipf("%s not set; skipping Postgres conformance tests", testDatabaseURLEnv)
+	}
+
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	defer pool.Close()
+	if err := pool.Ping(context.Background()); err != nil {
+		t.Fatalf("ping test database: %v", err)
+	}
+	database.RunMigrations(pool)
+
+	storetest.Run(t, func(t *testing.T) workflow.Store {
+		if _, err := pool.Exec(context.Background(), `TRUNCATE workflow_executions, workflows CASCADE`); err != nil {
+			t.Fatalf("reset test database: %v", err)
+		}
+		return workflow.NewPostgresWorkflowStore(pool)
+	})
+}
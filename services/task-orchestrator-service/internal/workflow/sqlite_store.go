@@ -0,0 +1,918 @@
+// services/task-orchestrator-service/internal/workflow/sqlite_store.go
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteTimeLayout guarda las fechas en UTC con ancho fijo: el orden de texto
+// coincide con el cronológico y julianday() sigue pudiendo interpretarlas.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// sqliteLogBatchSize es cuántas entradas de log se pasan a un mismo json_insert;
+// SQLite limita a 127 los argumentos de una función.
+const sqliteLogBatchSize = 50
+
+// SQLiteWorkflowStore implementa Store sobre un único fichero SQLite, pensado para
+// despliegues de un solo nodo (DATABASE_URL=sqlite://...). Los campos JSON se guardan
+// como TEXT. Como no hay otras réplicas, también implementa ExecutionNotifier avisando
+// en el propio proceso.
+type SQLiteWorkflowStore struct {
+	executionSubscribers
+
+	DB *sql.DB
+}
+
+var (
+	_ Store             = (*SQLiteWorkflowStore)(nil)
+	_ ExecutionNotifier = (*SQLiteWorkflowStore)(nil)
+)
+
+func NewSQLiteWorkflowStore(db *sql.DB) *SQLiteWorkflowStore {
+	return &SQLiteWorkflowStore{DB: db}
+}
+
+const sqliteWorkflowColumns = `id, user_id, name, description, "trigger", actions, on_failure, fail_fast, retention, is_enabled, created_at, updated_at, version, deleted_at, tags, folder, managed_by`
+
+// SaveWorkflow inserta o actualiza un workflow y guarda la versión resultante en
+// la misma transacción; al actualizar se conservan el propietario y la fecha de creación.
+func (s *SQLiteWorkflowStore) SaveWorkflow(ctx context.Context, wf *Workflow) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not save workflow: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := sqliteSaveWorkflow(ctx, tx, wf); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not save workflow: %w", err)
+	}
+	return nil
+}
+
+// ApplyWorkflowChanges aplica los cambios en una transacción.
+func (s *SQLiteWorkflowStore) ApplyWorkflowChanges(ctx context.Context, userID string, changes []WorkflowChange) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not apply workflow changes: %w", err)
+	}
+	defer tx.Rollback()
+
+	restore := keepSavedState(changes)
+	for i, change := range changes {
+		err := checkWorkflowChange(userID, change)
+		switch {
+		case err != nil:
+		case change.Save != nil:
+			err = sqliteSaveWorkflow(ctx, tx, change.Save)
+		default:
+			err = sqliteDeleteWorkflow(ctx, tx, userID, change.Delete.ID, change.Delete.Version)
+		}
+		if err != nil {
+			restore()
+			return &WorkflowChangeError{Index: i, Err: err}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		restore()
+		return fmt.Errorf("could not apply workflow changes: %w", err)
+	}
+	return nil
+}
+
+// sqliteSaveWorkflow guarda el workflow y su versión dentro de la transacción tx.
+func sqliteSaveWorkflow(ctx context.Context, tx *sql.Tx, wf *Workflow) error {
+	triggerJSON, err := json.Marshal(wf.Trigger)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trigger: %w", err)
+	}
+	actionsJSON, err := json.Marshal(wf.Actions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal actions: %w", err)
+	}
+	onFailure := wf.OnFailure
+	if onFailure == nil {
+		onFailure = []ActionDefinition{}
+	}
+	onFailureJSON, err := json.Marshal(onFailure)
+	if err != nil {
+		return fmt.Errorf("failed to marshal on_failure: %w", err)
+	}
+	var retentionJSON interface{}
+	if wf.Retention != nil {
+		data, err := json.Marshal(wf.Retention)
+		if err != nil {
+			return fmt.Errorf("failed to marshal retention: %w", err)
+		}
+		retentionJSON = string(data)
+	}
+	tags := wf.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tags: %w", err)
+	}
+
+	// Sin versión esperada se hace un upsert; su WHERE descarta la fila si el id ya
+	// pertenece a otro usuario o está en la papelera. Con versión, solo se actualiza
+	// si sigue siendo la actual.
+	var row *sql.Row
+	expected := wf.Version
+	if expected > 0 {
+		query := `
+            UPDATE workflows SET
+                name = ?3,
+                description = ?4,
+                "trigger" = ?5,
+                actions = ?6,
+                on_failure = ?7,
+                fail_fast = ?8,
+                retention = ?9,
+                is_enabled = ?10,
+                updated_at = ?11,
+                tags = ?13,
+                folder = ?14,
+                managed_by = ?15,
+                version = version + 1
+            WHERE id = ?1 AND user_id = ?2 AND version = ?12 AND deleted_at IS NULL
+            RETURNING version, created_at`
+		row = tx.QueryRowContext(ctx, query, wf.ID.String(), wf.UserID, wf.Name, wf.Description, string(triggerJSON), string(actionsJSON),
+			string(onFailureJSON), wf.FailFast, retentionJSON, wf.IsEnabled, sqliteTime(wf.UpdatedAt), expected, string(tagsJSON), wf.Folder, wf.ManagedBy)
+	} else {
+		query := `
+            INSERT INTO workflows (id, user_id, name, description, "trigger", actions, on_failure, fail_fast, retention, is_enabled, created_at, updated_at, tags, folder, managed_by, version)
+            VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14, ?15, 1)
+            ON CONFLICT (id) DO UPDATE SET
+                name = excluded.name,
+                description = excluded.description,
+                "trigger" = excluded."trigger",
+                actions = excluded.actions,
+                on_failure = excluded.on_failure,
+                fail_fast = excluded.fail_fast,
+                retention = excluded.retention,
+                is_enabled = excluded.is_enabled,
+                updated_at = excluded.updated_at,
+                tags = excluded.tags,
+                folder = excluded.folder,
+                managed_by = excluded.managed_by,
+                version = workflows.version + 1
+            WHERE workflows.user_id = excluded.user_id AND workflows.deleted_at IS NULL
+            RETURNING version, created_at`
+		row = tx.QueryRowContext(ctx, query, wf.ID.String(), wf.UserID, wf.Name, wf.Description, string(triggerJSON), string(actionsJSON),
+			string(onFailureJSON), wf.FailFast, retentionJSON, wf.IsEnabled, sqliteTime(wf.CreatedAt), sqliteTime(wf.UpdatedAt), string(tagsJSON), wf.Folder, wf.ManagedBy)
+	}
+	var createdAt string
+	if err := row.Scan(&wf.Version, &createdAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sqliteWorkflowWriteError(ctx, tx, wf.ID, wf.UserID)
+		}
+		log.Printf("Error saving workflow to database: %v", err)
+		return fmt.Errorf("could not save workflow: %w", err)
+	}
+	if wf.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+		return err
+	}
+
+	definition, err := json.Marshal(wf)
+	if err != nil {
+		return fmt.Errorf("failed to marshal workflow version: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO workflow_versions (workflow_id, version, definition, created_at) VALUES (?1, ?2, ?3, ?4)`,
+		wf.ID.String(), wf.Version, string(definition), sqliteTime(wf.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("could not save workflow version: %w", err)
+	}
+	return nil
+}
+
+// scanSQLiteWorkflow convierte una fila de workflows en un Workflow.
+func scanSQLiteWorkflow(row interface{ Scan(...any) error }) (*Workflow, error) {
+	var wf Workflow
+	var description, retentionJSON, deletedAt sql.NullString
+	var triggerJSON, actionsJSON, onFailureJSON, createdAt, updatedAt, tagsJSON string
+
+	err := row.Scan(&wf.ID, &wf.UserID, &wf.Name, &description, &triggerJSON, &actionsJSON, &onFailureJSON,
+		&wf.FailFast, &retentionJSON, &wf.IsEnabled, &createdAt, &updatedAt, &wf.Version, &deletedAt,
+		&tagsJSON, &wf.Folder, &wf.ManagedBy)
+	if err != nil {
+		return nil, err
+	}
+	wf.Description = description.String
+
+	if err := json.Unmarshal([]byte(triggerJSON), &wf.Trigger); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trigger: %w", err)
+	}
+	if err := json.Unmarshal([]byte(actionsJSON), &wf.Actions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal actions: %w", err)
+	}
+	if err := json.Unmarshal([]byte(onFailureJSON), &wf.OnFailure); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal on_failure: %w", err)
+	}
+	if retentionJSON.Valid {
+		if err := json.Unmarshal([]byte(retentionJSON.String), &wf.Retention); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal retention: %w", err)
+		}
+	}
+	if err := json.Unmarshal([]byte(tagsJSON), &wf.Tags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
+	}
+	if len(wf.Tags) == 0 {
+		wf.Tags = nil
+	}
+	if wf.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+		return nil, err
+	}
+	if wf.UpdatedAt, err = parseSQLiteTime(updatedAt); err != nil {
+		return nil, err
+	}
+	if wf.DeletedAt, err = parseSQLiteNullableTime(deletedAt); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+func (s *SQLiteWorkflowStore) queryWorkflows(ctx context.Context, query string, args ...any) ([]*Workflow, error) {
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var workflows []*Workflow
+	for rows.Next() {
+		wf, err := scanSQLiteWorkflow(rows)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, wf)
+	}
+	return workflows, rows.Err()
+}
+
+// ListWorkflows lista los workflows del usuario que cumplen el filtro, de más
+// reciente a más antiguo. Sin tsvector, cada palabra buscada debe aparecer (LIKE,
+// sin distinguir mayúsculas en ASCII) en el nombre o la descripción.
+func (s *SQLiteWorkflowStore) ListWorkflows(ctx context.Context, userID string, filter WorkflowFilter) ([]*Workflow, error) {
+	conditions := []string{"user_id = ?", "deleted_at IS NULL"}
+	args := []any{userID}
+
+	for _, sel := range filter.Tags {
+		if sel.Value != nil {
+			conditions = append(conditions, "EXISTS (SELECT 1 FROM json_each(workflows.tags) WHERE key = ? AND value = ?)")
+			args = append(args, sel.Key, *sel.Value)
+		} else {
+			conditions = append(conditions, "EXISTS (SELECT 1 FROM json_each(workflows.tags) WHERE key = ?)")
+			args = append(args, sel.Key)
+		}
+	}
+	if filter.Folder != nil {
+		conditions = append(conditions, "folder = ?")
+		args = append(args, *filter.Folder)
+	}
+	if filter.ManagedBy != nil {
+		conditions = append(conditions, "managed_by = ?")
+		args = append(args, *filter.ManagedBy)
+	}
+	if filter.TriggerType != "" {
+		conditions = append(conditions, `json_extract("trigger", '$.type') = ?`)
+		args = append(args, string(filter.TriggerType))
+	}
+	if filter.Enabled != nil {
+		conditions = append(conditions, "is_enabled = ?")
+		args = append(args, *filter.Enabled)
+	}
+	if len(filter.LastRunStatuses) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.LastRunStatuses)), ", ")
+		conditions = append(conditions, `(SELECT e.status FROM workflow_executions e WHERE e.workflow_id = workflows.id
+			ORDER BY e.triggered_at DESC, e.id DESC LIMIT 1) IN (`+placeholders+`)`)
+		for _, status := range filter.LastRunStatuses {
+			args = append(args, status)
+		}
+	}
+	for _, term := range filter.SearchTerms() {
+		conditions = append(conditions, `(name LIKE ? ESCAPE '\' OR COALESCE(description, '') LIKE ? ESCAPE '\')`)
+		pattern := "%" + sqliteLikeEscaper.Replace(term) + "%"
+		args = append(args, pattern, pattern)
+	}
+
+	return s.queryWorkflows(ctx, `SELECT `+sqliteWorkflowColumns+` FROM workflows
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY created_at DESC`, args...)
+}
+
+// sqliteLikeEscaper escapa los comodines de LIKE para buscar el texto literal.
+var sqliteLikeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (s *SQLiteWorkflowStore) GetWorkflowByID(ctx context.Context, userID string, workflowID uuid.UUID) (*Workflow, error) {
+	row := s.DB.QueryRowContext(ctx, `SELECT `+sqliteWorkflowColumns+` FROM workflows WHERE id = ?1`, workflowID.String())
+	wf, err := scanSQLiteWorkflow(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWorkflowNotFound
+		}
+		return nil, fmt.Errorf("failed to load workflow: %w", err)
+	}
+	if wf.DeletedAt != nil {
+		return nil, ErrWorkflowNotFound
+	}
+	if wf.UserID != userID {
+		return nil, ErrWorkflowForbidden
+	}
+	return wf, nil
+}
+
+// DeleteWorkflow mueve el workflow a la papelera conservando sus ejecuciones.
+func (s *SQLiteWorkflowStore) DeleteWorkflow(ctx context.Context, userID string, workflowID uuid.UUID, version int) error {
+	return sqliteDeleteWorkflow(ctx, s.DB, userID, workflowID, version)
+}
+
+func sqliteDeleteWorkflow(ctx context.Context, q sqliteQueryer, userID string, workflowID uuid.UUID, version int) error {
+	result, err := q.ExecContext(ctx, `UPDATE workflows SET deleted_at = ?4
+		WHERE id = ?1 AND user_id = ?2 AND deleted_at IS NULL AND (?3 = 0 OR version = ?3)`,
+		workflowID.String(), userID, version, sqliteTime(time.Now().UTC()))
+	if err != nil {
+		return fmt.Errorf("failed to delete workflow: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected > 0 {
+		return nil
+	}
+	return sqliteWorkflowWriteError(ctx, q, workflowID, userID)
+}
+
+// sqliteQueryer lo cumplen *sql.DB y *sql.Tx.
+type sqliteQueryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sqliteWorkflowWriteError consulta el propietario actual para explicar una escritura
+// condicionada que no afectó a ninguna fila. Un workflow en la papelera cuenta como inexistente.
+func sqliteWorkflowWriteError(ctx context.Context, q sqliteQueryer, workflowID uuid.UUID, userID string) error {
+	var ownerID string
+	err := q.QueryRowContext(ctx, `SELECT user_id FROM workflows WHERE id = ?1 AND deleted_at IS NULL`, workflowID.String()).Scan(&ownerID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to look up workflow: %w", err)
+	}
+	return workflowWriteError(err == nil, ownerID, userID)
+}
+
+func (s *SQLiteWorkflowStore) ListDeletedWorkflows(ctx context.Context, userID string) ([]*Workflow, error) {
+	return s.queryWorkflows(ctx, `SELECT `+sqliteWorkflowColumns+` FROM workflows
+		WHERE user_id = ?1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC`, userID)
+}
+
+func (s *SQLiteWorkflowStore) RestoreWorkflow(ctx context.Context, userID string, workflowID uuid.UUID) (*Workflow, error) {
+	row := s.DB.QueryRowContext(ctx, `UPDATE workflows SET deleted_at = NULL
+		WHERE id = ?1 AND user_id = ?2 AND deleted_at IS NOT NULL
+		RETURNING `+sqliteWorkflowColumns, workflowID.String(), userID)
+	wf, err := scanSQLiteWorkflow(row)
+	if err == nil {
+		return wf, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to restore workflow: %w", err)
+	}
+
+	var ownerID string
+	err = s.DB.QueryRowContext(ctx, `SELECT user_id FROM workflows WHERE id = ?1`, workflowID.String()).Scan(&ownerID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to look up workflow: %w", err)
+	}
+	return nil, restoreError(err == nil, ownerID, userID)
+}
+
+// PurgeDeletedWorkflows borra definitivamente los workflows de la papelera más
+// antiguos que deletedBefore; ON DELETE CASCADE se lleva ejecuciones y versiones.
+func (s *SQLiteWorkflowStore) PurgeDeletedWorkflows(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	result, err := s.DB.ExecContext(ctx, `DELETE FROM workflows WHERE id IN (
+		SELECT id FROM workflows WHERE deleted_at < ?1 ORDER BY deleted_at LIMIT ?2)`, sqliteTime(deletedBefore), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted workflows: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted workflows: %w", err)
+	}
+	return int(deleted), nil
+}
+
+func (s *SQLiteWorkflowStore) ListWorkflowVersions(ctx context.Context, userID string, workflowID uuid.UUID) ([]*WorkflowVersion, error) {
+	if _, err := s.GetWorkflowByID(ctx, userID, workflowID); err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.QueryContext(ctx, `SELECT workflow_id, version, definition, created_at FROM workflow_versions
+		WHERE workflow_id = ?1 ORDER BY version DESC`, workflowID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []*WorkflowVersion{}
+	for rows.Next() {
+		version, err := scanSQLiteWorkflowVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workflow version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+func (s *SQLiteWorkflowStore) GetWorkflowVersion(ctx context.Context, userID string, workflowID uuid.UUID, version int) (*WorkflowVersion, error) {
+	if _, err := s.GetWorkflowByID(ctx, userID, workflowID); err != nil {
+		return nil, err
+	}
+	row := s.DB.QueryRowContext(ctx, `SELECT workflow_id, version, definition, created_at FROM workflow_versions
+		WHERE workflow_id = ?1 AND version = ?2`, workflowID.String(), version)
+	wv, err := scanSQLiteWorkflowVersion(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWorkflowVersionNotFound
+		}
+		return nil, fmt.Errorf("failed to load workflow version: %w", err)
+	}
+	return wv, nil
+}
+
+func scanSQLiteWorkflowVersion(row interface{ Scan(...any) error }) (*WorkflowVersion, error) {
+	var wv WorkflowVersion
+	var definition, createdAt string
+	if err := row.Scan(&wv.WorkflowID, &wv.Version, &definition, &createdAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(definition), &wv.Definition); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workflow version: %w", err)
+	}
+	var err error
+	if wv.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+		return nil, err
+	}
+	return &wv, nil
+}
+
+func (s *SQLiteWorkflowStore) GetAllEnabledScheduledWorkflows(ctx context.Context) ([]*Workflow, error) {
+	workflows, err := s.queryWorkflows(ctx, `SELECT `+sqliteWorkflowColumns+` FROM workflows
+		WHERE is_enabled = 1 AND json_extract("trigger", '$.type') = 'schedule' AND deleted_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("database query for scheduler failed: %w", err)
+	}
+	return workflows, nil
+}
+
+func (s *SQLiteWorkflowStore) CreateExecution(ctx context.Context, exec *ExecutionLog) error {
+	query := `
+        INSERT INTO workflow_executions (id, workflow_id, user_id, status, triggered_at, completed_at, logs, steps, resume_at, state,
+            wait_event, wait_key, trigger_source, parent_execution_id, workflow_version)
+        VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, NULLIF(?11, ''), NULLIF(?12, ''), NULLIF(?13, ''), ?14, NULLIF(?15, 0))`
+	_, err := s.DB.ExecContext(ctx, query, exec.ID.String(), exec.WorkflowID.String(), exec.UserID, exec.Status,
+		sqliteTime(exec.TriggeredAt), sqliteNullableTime(exec.CompletedAt), string(logsOrEmpty(exec.Logs)), string(stepsOrEmpty(exec.Steps)),
+		sqliteNullableTime(exec.ResumeAt), sqliteNullableJSON(exec.State), exec.WaitEvent, exec.WaitKey, exec.TriggerSource,
+		sqliteNullableUUID(exec.ParentExecutionID), exec.WorkflowVersion)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) {
+			switch sqliteErr.Code() {
+			case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+				return ErrExecutionExists
+			case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+				return ErrWorkflowNotFound
+			}
+		}
+		return fmt.Errorf("failed to insert execution record: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteWorkflowStore) UpdateExecution(ctx context.Context, exec *ExecutionLog) error {
+	query := `
+        UPDATE workflow_executions
+        SET status = ?1, completed_at = ?2, logs = ?3, steps = ?4, resume_at = ?5, state = ?6,
+            wait_event = NULLIF(?7, ''), wait_key = NULLIF(?8, ''), updated_at = ?9
+        WHERE id = ?10`
+	result, err := s.DB.ExecContext(ctx, query, exec.Status, sqliteNullableTime(exec.CompletedAt), string(logsOrEmpty(exec.Logs)),
+		string(stepsOrEmpty(exec.Steps)), sqliteNullableTime(exec.ResumeAt), sqliteNullableJSON(exec.State),
+		exec.WaitEvent, exec.WaitKey, sqliteTime(time.Now()), exec.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to update execution record: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrExecutionNotFound
+	}
+	s.notify(exec.ID)
+	return nil
+}
+
+// AppendExecutionLogs añade las entradas al final del array de logs sin reescribirlo
+// desde Go. json_insert aplica los pares en orden, así que '$[#]' apunta cada vez
+// al nuevo final; se hace en tandas para no pasar del límite de argumentos.
+func (s *SQLiteWorkflowStore) AppendExecutionLogs(ctx context.Context, executionID uuid.UUID, entries []json.RawMessage) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to append execution logs: %w", err)
+	}
+	defer tx.Rollback()
+
+	var affected int64
+	for start := 0; start < len(entries); start += sqliteLogBatchSize {
+		chunk := entries[start:min(start+sqliteLogBatchSize, len(entries))]
+		args := []any{executionID.String(), sqliteTime(time.Now())}
+		pairs := make([]string, len(chunk))
+		for i, entry := range chunk {
+			args = append(args, string(entry))
+			pairs[i] = fmt.Sprintf("'$[#]', json(?%d)", len(args))
+		}
+		query := `
+        UPDATE workflow_executions
+        SET logs = json_insert(logs, ` + strings.Join(pairs, ", ") + `), updated_at = ?2
+        WHERE id = ?1`
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to append execution logs: %w", err)
+		}
+		affected, _ = result.RowsAffected()
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to append execution logs: %w", err)
+	}
+	if affected > 0 {
+		s.notify(executionID)
+	}
+	return nil
+}
+
+// GetExecutionLogsSince devuelve las entradas con posición >= offset. La fila del
+// LEFT JOIN existe aunque no haya entradas nuevas, para distinguirlo de "no existe".
+func (s *SQLiteWorkflowStore) GetExecutionLogsSince(ctx context.Context, userID string, executionID uuid.UUID, offset int) ([]json.RawMessage, string, error) {
+	query := `
+        SELECT we.status,
+            CASE WHEN t.key IS NULL THEN NULL
+                WHEN t.type IN ('object', 'array') THEN t.value ELSE json_quote(t.value) END
+        FROM workflow_executions we
+        LEFT JOIN json_each(we.logs) AS t ON t.key >= ?3
+        WHERE we.id = ?1 AND we.user_id = ?2
+        ORDER BY t.key`
+	rows, err := s.DB.QueryContext(ctx, query, executionID.String(), userID, offset)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load execution logs: %w", err)
+	}
+	defer rows.Close()
+
+	var status string
+	found := false
+	entries := []json.RawMessage{}
+	for rows.Next() {
+		var entry sql.NullString
+		if err := rows.Scan(&status, &entry); err != nil {
+			return nil, "", fmt.Errorf("failed to scan execution log: %w", err)
+		}
+		found = true
+		if entry.Valid {
+			entries = append(entries, json.RawMessage(entry.String))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to load execution logs: %w", err)
+	}
+	if !found {
+		return nil, "", ErrExecutionNotFound
+	}
+	return entries, status, nil
+}
+
+func (s *SQLiteWorkflowStore) ListExecutions(ctx context.Context, userID string, filter ExecutionFilter) ([]*ExecutionLog, error) {
+	conditions := []string{"user_id = ?"}
+	args := []any{userID}
+
+	if filter.WorkflowID != nil {
+		conditions = append(conditions, "workflow_id = ?")
+		args = append(args, filter.WorkflowID.String())
+	}
+	if len(filter.Statuses) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.Statuses)), ", ")
+		conditions = append(conditions, "status IN ("+placeholders+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if filter.TriggerSource != "" {
+		conditions = append(conditions, "trigger_source = ?")
+		args = append(args, filter.TriggerSource)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "triggered_at >= ?")
+		args = append(args, sqliteTime(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "triggered_at < ?")
+		args = append(args, sqliteTime(*filter.To))
+	}
+	if filter.After != nil {
+		conditions = append(conditions, "(triggered_at, id) < (?, ?)")
+		args = append(args, sqliteTime(filter.After.TriggeredAt), filter.After.ID.String())
+	}
+	args = append(args, filter.PageSize()+1)
+
+	query := `
+		SELECT id, workflow_id, user_id, status, triggered_at, completed_at, resume_at,
+			COALESCE(wait_event, ''), COALESCE(wait_key, ''), COALESCE(trigger_source, ''), parent_execution_id, COALESCE(workflow_version, 0)
+		FROM workflow_executions
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY triggered_at DESC, id DESC
+		LIMIT ?`
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("ERROR: Database query failed for ListExecutions: %v", err)
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	executions := []*ExecutionLog{}
+	for rows.Next() {
+		var exec ExecutionLog
+		var triggeredAt string
+		var completedAt, resumeAt sql.NullString
+		var parentID uuid.NullUUID
+		err := rows.Scan(&exec.ID, &exec.WorkflowID, &exec.UserID, &exec.Status, &triggeredAt, &completedAt, &resumeAt,
+			&exec.WaitEvent, &exec.WaitKey, &exec.TriggerSource, &parentID, &exec.WorkflowVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan execution row: %w", err)
+		}
+		if err := exec.setSQLiteTimes(triggeredAt, completedAt, resumeAt); err != nil {
+			return nil, err
+		}
+		exec.ParentExecutionID = sqliteParentID(parentID)
+		executions = append(executions, &exec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating execution rows: %w", err)
+	}
+	return executions, nil
+}
+
+// sqliteExecutionColumns son las columnas de una ejecución completa (logs, pasos y estado).
+const sqliteExecutionColumns = `id, workflow_id, user_id, status, triggered_at, completed_at, logs, steps, resume_at, state,
+		COALESCE(wait_event, ''), COALESCE(wait_key, ''), COALESCE(trigger_source, ''), parent_execution_id, COALESCE(workflow_version, 0)`
+
+func scanSQLiteExecution(row interface{ Scan(...any) error }) (*ExecutionLog, error) {
+	var exec ExecutionLog
+	var triggeredAt, logs, steps string
+	var completedAt, resumeAt, state sql.NullString
+	var parentID uuid.NullUUID
+	err := row.Scan(&exec.ID, &exec.WorkflowID, &exec.UserID, &exec.Status, &triggeredAt, &completedAt, &logs, &steps,
+		&resumeAt, &state, &exec.WaitEvent, &exec.WaitKey, &exec.TriggerSource, &parentID, &exec.WorkflowVersion)
+	if err != nil {
+		return nil, err
+	}
+	if err := exec.setSQLiteTimes(triggeredAt, completedAt, resumeAt); err != nil {
+		return nil, err
+	}
+	exec.Logs = json.RawMessage(logs)
+	exec.Steps = json.RawMessage(steps)
+	if state.Valid {
+		exec.State = json.RawMessage(state.String)
+	}
+	exec.ParentExecutionID = sqliteParentID(parentID)
+	return &exec, nil
+}
+
+func scanSQLiteExecutions(rows *sql.Rows) ([]*ExecutionLog, error) {
+	defer rows.Close()
+	var executions []*ExecutionLog
+	for rows.Next() {
+		exec, err := scanSQLiteExecution(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan execution row: %w", err)
+		}
+		executions = append(executions, exec)
+	}
+	return executions, rows.Err()
+}
+
+func (s *SQLiteWorkflowStore) GetExecutionByID(ctx context.Context, userID string, executionID uuid.UUID) (*ExecutionLog, error) {
+	row := s.DB.QueryRowContext(ctx, `SELECT `+sqliteExecutionColumns+` FROM workflow_executions WHERE id = ?1 AND user_id = ?2`,
+		executionID.String(), userID)
+	exec, err := scanSQLiteExecution(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrExecutionNotFound
+		}
+		return nil, fmt.Errorf("failed to load execution: %w", err)
+	}
+	return exec, nil
+}
+
+// ClaimDueExecutions reclama las ejecuciones suspendidas que deben reanudarse y
+// las reanudadas cuya reclamación caducó. SQLite serializa las escrituras, así
+// que el UPDATE ... RETURNING es atómico.
+func (s *SQLiteWorkflowStore) ClaimDueExecutions(ctx context.Context, now time.Time, limit int) ([]*ExecutionLog, error) {
+	query := `
+		UPDATE workflow_executions SET status = 'running', claimed_at = ?1, claimed_by = ?3
+		WHERE id IN (
+			SELECT id FROM workflow_executions
+			WHERE (status = 'suspended' AND resume_at <= ?1)
+				OR (status = 'running' AND claimed_at <= ?4)
+			ORDER BY resume_at IS NULL, resume_at
+			LIMIT ?2
+		)
+		RETURNING ` + sqliteExecutionColumns
+	rows, err := s.DB.QueryContext(ctx, query, sqliteTime(now), limit, LeaseOwner, sqliteTime(now.Add(-ExecutionLease)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due executions: %w", err)
+	}
+	return scanSQLiteExecutions(rows)
+}
+
+func (s *SQLiteWorkflowStore) RenewExecutionLease(ctx context.Context, executionID uuid.UUID, now time.Time) error {
+	result, err := s.DB.ExecContext(ctx,
+		`UPDATE workflow_executions SET claimed_at = ?2 WHERE id = ?1 AND status = 'running' AND claimed_by = ?3`,
+		executionID.String(), sqliteTime(now), LeaseOwner)
+	if err != nil {
+		return fmt.Errorf("failed to renew execution lease: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrExecutionLeaseLost
+	}
+	return nil
+}
+
+func (s *SQLiteWorkflowStore) ClaimSuspendedExecution(ctx context.Context, executionID uuid.UUID, userID, waitKey string) (*ExecutionLog, error) {
+	query := `
+		UPDATE workflow_executions SET status = 'running', claimed_at = ?3, claimed_by = ?4
+		WHERE id = ?1 AND status = 'suspended' AND (?2 = '' OR user_id = ?2) AND (?5 = '' OR wait_key = ?5)
+		RETURNING ` + sqliteExecutionColumns
+	exec, err := scanSQLiteExecution(s.DB.QueryRowContext(ctx, query, executionID.String(), userID, sqliteTime(time.Now()), LeaseOwner, waitKey))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrExecutionNotSuspended
+		}
+		return nil, fmt.Errorf("failed to claim execution: %w", err)
+	}
+	return exec, nil
+}
+
+func (s *SQLiteWorkflowStore) ClaimExecutionsWaitingForEvent(ctx context.Context, userID string, workflowID uuid.UUID, eventName, correlationKey string) ([]*ExecutionLog, error) {
+	query := `
+		UPDATE workflow_executions SET status = 'running', claimed_at = ?5, claimed_by = ?6
+		WHERE status = 'suspended' AND wait_event = ?1 AND wait_key = ?2 AND user_id = ?3 AND workflow_id = ?4
+		RETURNING ` + sqliteExecutionColumns
+	rows, err := s.DB.QueryContext(ctx, query, eventName, correlationKey, userID, workflowID.String(), sqliteTime(time.Now()), LeaseOwner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim executions waiting for event: %w", err)
+	}
+	return scanSQLiteExecutions(rows)
+}
+
+// PurgeExpiredExecutions aplica las mismas reglas que la versión de Postgres dentro
+// de una transacción; con un solo nodo no hace falta SKIP LOCKED.
+func (s *SQLiteWorkflowStore) PurgeExpiredExecutions(ctx context.Context, defaults RetentionLimits, now time.Time, limit int, archive func([]*ExecutionLog) error) (int, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin purge transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		WITH ranked AS (
+			SELECT e.id, e.status, e.triggered_at,
+				COALESCE(json_extract(w.retention, '$.max_age_days'), ?1) AS max_age_days,
+				COALESCE(json_extract(w.retention, '$.max_count'), ?2) AS max_count,
+				ROW_NUMBER() OVER (PARTITION BY e.workflow_id ORDER BY e.triggered_at DESC, e.id DESC) AS position
+			FROM workflow_executions e
+			JOIN workflows w ON w.id = e.workflow_id
+		)
+		SELECT ` + sqliteExecutionColumns + `
+		FROM workflow_executions
+		WHERE id IN (
+			SELECT id FROM ranked
+			WHERE status IN ('completed', 'failed')
+				AND ((max_age_days > 0 AND julianday(triggered_at) < julianday(?3) - max_age_days)
+					OR (max_count > 0 AND position > max_count))
+			ORDER BY triggered_at
+			LIMIT ?4
+		)
+		ORDER BY triggered_at`
+	rows, err := tx.QueryContext(ctx, query, defaults.MaxAgeDays, defaults.MaxCount, sqliteTime(now), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select expired executions: %w", err)
+	}
+	executions, err := scanSQLiteExecutions(rows)
+	if err != nil {
+		return 0, err
+	}
+	if len(executions) == 0 {
+		return 0, nil
+	}
+
+	if archive != nil {
+		if err := archive(executions); err != nil {
+			return 0, fmt.Errorf("failed to archive executions: %w", err)
+		}
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(executions)), ", ")
+	ids := make([]any, len(executions))
+	for i, exec := range executions {
+		ids[i] = exec.ID.String()
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM workflow_executions WHERE id IN (`+placeholders+`)`, ids...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired executions: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit purge: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+	return int(deleted), nil
+}
+
+// setSQLiteTimes interpreta las columnas de fecha de una ejecución.
+func (exec *ExecutionLog) setSQLiteTimes(triggeredAt string, completedAt, resumeAt sql.NullString) error {
+	var err error
+	if exec.TriggeredAt, err = parseSQLiteTime(triggeredAt); err != nil {
+		return err
+	}
+	if exec.CompletedAt, err = parseSQLiteNullableTime(completedAt); err != nil {
+		return err
+	}
+	exec.ResumeAt, err = parseSQLiteNullableTime(resumeAt)
+	return err
+}
+
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+func sqliteNullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
+
+func parseSQLiteTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", value, err)
+	}
+	return t, nil
+}
+
+func parseSQLiteNullableTime(value sql.NullString) (*time.Time, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	t, err := parseSQLiteTime(value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// sqliteNullableJSON guarda NULL en lugar de un JSON vacío.
+func sqliteNullableJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+func sqliteNullableUUID(id *uuid.UUID) interface{} {
+	if id == nil {
+		return nil
+	}
+	return id.String()
+}
+
+func sqliteParentID(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+// logsOrEmpty evita guardar un log vacío, que no sería JSON válido.
+func logsOrEmpty(logs json.RawMessage) json.RawMessage {
+	if len(logs) == 0 {
+		return json.RawMessage("[]")
+	}
+	return logs
+}
@@ -0,0 +1,20 @@
+// services/task-orchestrator-service/internal/workflow/sqlite_store_test.go
+package workflow_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow/storetest"
+	"github.com/guildmember145/task-orchestrator-service/pkg/database"
+)
+
+func TestSQLiteWorkflowStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) workflow.Store {
+		db := database.ConnectSQLite("sqlite://" + filepath.Join(t.TempDir(), "orchestrator.db"))
+		t.Cleanup(func() { db.Close() })
+		database.RunSQLiteMigrations(db)
+		return workflow.NewSQLiteWorkflowStore(db)
+	})
+}
@@ -1,17 +1,102 @@
 // services/task-orchestrator-service/internal/workflow/store.go
 package workflow
 
-import "github.com/google/uuid"
+import (
+    "context"
+    "encoding/json"
+    "time"
+
+    "github.com/google/uuid"
+)
 
 // Store define la interfaz para las operaciones de almacenamiento de workflows.
+// Todos los métodos reciben el contexto de la petición: si se cancela o vence su
+// plazo, la consulta se aborta en la base de datos. Los errores de "no existe",
+// "pertenece a otro usuario" y "conflicto" envuelven ErrNotFound, ErrForbidden y
+// ErrConflict respectivamente.
 type Store interface {
-    SaveWorkflow(wf *Workflow) error
-    GetWorkflowsByUserID(userID string) ([]*Workflow, error)
-    GetWorkflowByID(userID string, workflowID uuid.UUID) (*Workflow, bool)
-    DeleteWorkflow(userID string, workflowID uuid.UUID) bool
-    GetAllEnabledScheduledWorkflows() ([]*Workflow, error)
-    CreateExecution(exec *ExecutionLog) error
-    UpdateExecution(exec *ExecutionLog) error
-    GetExecutionsByWorkflowID(userID string, workflowID uuid.UUID) ([]*ExecutionLog, error)
+    // SaveWorkflow inserta o actualiza un workflow, incrementa su versión y guarda
+    // la definición resultante en el historial; actualiza wf.Version y wf.CreatedAt.
+    // Si wf.Version es mayor que 0, solo actualiza si la versión guardada sigue siendo
+    // esa (control de concurrencia optimista): si no, devuelve ErrWorkflowVersionMismatch,
+    // y ErrWorkflowNotFound si el workflow ya no existe. Con 0 crea o sobrescribe.
+    // Devuelve ErrWorkflowForbidden si el id ya existe y pertenece a otro usuario.
+    SaveWorkflow(ctx context.Context, wf *Workflow) error
+    // ListWorkflows devuelve los workflows del usuario que cumplen el filtro, del más
+    // reciente al más antiguo (Postgres ordena antes por relevancia si hay búsqueda).
+    ListWorkflows(ctx context.Context, userID string, filter WorkflowFilter) ([]*Workflow, error)
+    // GetWorkflowByID devuelve ErrWorkflowNotFound si no existe o está en la papelera
+    // y ErrWorkflowForbidden si es de otro usuario. Lo mismo vale para el resto de
+    // métodos: un workflow en la papelera solo es visible para ListDeletedWorkflows,
+    // RestoreWorkflow y PurgeDeletedWorkflows.
+    GetWorkflowByID(ctx context.Context, userID string, workflowID uuid.UUID) (*Workflow, error)
+    // DeleteWorkflow mueve el workflow a la papelera (deleted_at) conservando sus
+    // ejecuciones, con los mismos errores que GetWorkflowByID. Si version es mayor
+    // que 0, solo borra si es la versión actual (si no, ErrWorkflowVersionMismatch).
+    DeleteWorkflow(ctx context.Context, userID string, workflowID uuid.UUID, version int) error
+    // ApplyWorkflowChanges aplica los cambios del usuario en orden y en una sola
+    // transacción: si uno falla no se aplica ninguno y devuelve un *WorkflowChangeError
+    // con su índice, que envuelve el mismo error que SaveWorkflow o DeleteWorkflow.
+    // Los workflows guardados solo actualizan Version y CreatedAt si se aplica todo.
+    ApplyWorkflowChanges(ctx context.Context, userID string, changes []WorkflowChange) error
+    // ListDeletedWorkflows devuelve la papelera del usuario, del borrado más reciente al más antiguo.
+    ListDeletedWorkflows(ctx context.Context, userID string) ([]*Workflow, error)
+    // RestoreWorkflow saca un workflow de la papelera. Devuelve ErrWorkflowNotDeleted
+    // si no estaba en ella.
+    RestoreWorkflow(ctx context.Context, userID string, workflowID uuid.UUID) (*Workflow, error)
+    // PurgeDeletedWorkflows borra definitivamente, con sus ejecuciones y versiones,
+    // hasta limit workflows borrados antes de deletedBefore. Devuelve cuántos borró.
+    PurgeDeletedWorkflows(ctx context.Context, deletedBefore time.Time, limit int) (int, error)
+    // ListWorkflowVersions devuelve el historial del workflow, de la versión más reciente a la más antigua.
+    ListWorkflowVersions(ctx context.Context, userID string, workflowID uuid.UUID) ([]*WorkflowVersion, error)
+    // GetWorkflowVersion devuelve una versión concreta o ErrWorkflowVersionNotFound.
+    GetWorkflowVersion(ctx context.Context, userID string, workflowID uuid.UUID, version int) (*WorkflowVersion, error)
+    GetAllEnabledScheduledWorkflows(ctx context.Context) ([]*Workflow, error)
+    // CreateExecution devuelve ErrExecutionExists si el id ya existe y
+    // ErrWorkflowNotFound si el workflow no existe.
+    CreateExecution(ctx context.Context, exec *ExecutionLog) error
+    // UpdateExecution devuelve ErrExecutionNotFound si la ejecución ya no existe.
+    UpdateExecution(ctx context.Context, exec *ExecutionLog) error
+    // ListExecutions devuelve una página de ejecuciones del usuario (sin logs ni pasos),
+    // de la más reciente a la más antigua. Devuelve hasta filter.PageSize()+1 elementos
+    // para que el llamador sepa si hay otra página.
+    ListExecutions(ctx context.Context, userID string, filter ExecutionFilter) ([]*ExecutionLog, error)
+    // GetExecutionByID devuelve una ejecución completa o ErrExecutionNotFound.
+    GetExecutionByID(ctx context.Context, userID string, executionID uuid.UUID) (*ExecutionLog, error)
+    // ClaimDueExecutions marca como "running" y devuelve las ejecuciones suspendidas
+    // cuyo resume_at ya venció y las reanudadas cuya reclamación caducó (claimed_at
+    // anterior a now - ExecutionLease: la réplica que las tenía murió). Cada ejecución
+    // se entrega a una sola réplica. Todas las reclamaciones (también las dos
+    // siguientes) guardan claimed_at y claimed_by = LeaseOwner.
+    ClaimDueExecutions(ctx context.Context, now time.Time, limit int) ([]*ExecutionLog, error)
+    // RenewExecutionLease renueva la reclamación de una ejecución en curso de esta
+    // réplica. Devuelve ErrExecutionLeaseLost si ya no está "running" a su nombre.
+    RenewExecutionLease(ctx context.Context, executionID uuid.UUID, now time.Time) error
+    // ClaimSuspendedExecution marca como "running" una ejecución suspendida concreta.
+    // Con userID vacío no se filtra por usuario (enlaces firmados); con waitKey no
+    // vacía solo la reclama si espera esa clave (el nonce de una aprobación). Devuelve
+    // ErrExecutionNotSuspended si no existe o ya no está suspendida con esa clave.
+    ClaimSuspendedExecution(ctx context.Context, executionID uuid.UUID, userID, waitKey string) (*ExecutionLog, error)
+    // ClaimExecutionsWaitingForEvent reclama las ejecuciones suspendidas del workflow
+    // workflowID (propiedad de userID) que esperan el evento eventName con la clave
+    // de correlación indicada. Un evento nunca reanuda ejecuciones de otro workflow.
+    ClaimExecutionsWaitingForEvent(ctx context.Context, userID string, workflowID uuid.UUID, eventName, correlationKey string) ([]*ExecutionLog, error)
+    // AppendExecutionLogs añade una tanda de entradas (JSON) al log de la ejecución
+    // mientras se ejecuta, con una sola escritura y un solo aviso a los suscriptores.
+    AppendExecutionLogs(ctx context.Context, executionID uuid.UUID, entries []json.RawMessage) error
+    // GetExecutionLogsSince devuelve las entradas de log a partir de la posición offset
+    // y el estado actual de la ejecución. Devuelve ErrExecutionNotFound si no pertenece al usuario.
+    GetExecutionLogsSince(ctx context.Context, userID string, executionID uuid.UUID, offset int) ([]json.RawMessage, string, error)
+    // PurgeExpiredExecutions borra hasta limit ejecuciones terminadas que exceden la
+    // retención de su workflow (o defaults). Si archive no es nil, recibe las filas
+    // antes de borrarlas y, si devuelve error, no se borra nada. Devuelve cuántas borró.
+    PurgeExpiredExecutions(ctx context.Context, defaults RetentionLimits, now time.Time, limit int, archive func([]*ExecutionLog) error) (int, error)
+}
 
+// ExecutionNotifier avisa cuando una ejecución añade logs o cambia de estado,
+// incluso si ocurre en otra réplica.
+type ExecutionNotifier interface {
+    // Subscribe devuelve un canal que recibe un aviso por cada cambio y una
+    // función para cancelar la suscripción.
+    Subscribe(executionID uuid.UUID) (<-chan struct{}, func())
 }
\ No newline at end of file
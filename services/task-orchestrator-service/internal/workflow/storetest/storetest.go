@@ -0,0 +1,1152 @@
+// services/task-orchestrator-service/internal/workflow/storetest/storetest.go
+
+// Package storetest contiene la batería de conformidad que debe pasar cualquier
+// implementación de workflow.Store. Cada backend la ejecuta desde su propio test:
+//
+//	storetest.Run(t, func(t *testing.T) workflow.Store { return newStore(t) })
+//
+// La fábrica debe devolver un store vacío (o al menos sin ejecuciones suspendidas
+// ni workflows programados de otros tests), ya que algunas operaciones son globales.
+package storetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guildmember145/task-orchestrator-service/internal/workflow"
+)
+
+// Factory crea un store limpio para cada subtest.
+type Factory func(t *testing.T) workflow.Store
+
+// Run ejecuta todos los casos de la batería contra el store que crea newStore.
+func Run(t *testing.T, newStore Factory) {
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, store workflow.Store)
+	}{
+		{"WorkflowCRUD", testWorkflowCRUD},
+		{"UserIsolation", testUserIsolation},
+		{"ScheduledWorkflows", testScheduledWorkflows},
+		{"ExecutionLifecycle", testExecutionLifecycle},
+		{"ExecutionLogs", testExecutionLogs},
+		{"ExecutionOrderingAndPagination", testExecutionOrderingAndPagination},
+		{"ExecutionFilters", testExecutionFilters},
+		{"WorkflowFilters", testWorkflowFilters},
+		{"ClaimSuspended", testClaimSuspended},
+		{"ExecutionLease", testExecutionLease},
+		{"NotFound", testNotFound},
+		{"Trash", testTrash},
+		{"PurgeDeletedWorkflows", testPurgeDeletedWorkflows},
+		{"WorkflowVersions", testWorkflowVersions},
+		{"OptimisticConcurrency", testOptimisticConcurrency},
+		{"ApplyWorkflowChanges", testApplyWorkflowChanges},
+		{"PurgeExpiredExecutions", testPurgeExpiredExecutions},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, newStore(t))
+		})
+	}
+}
+
+// now devuelve la hora truncada a microsegundos, la precisión de TIMESTAMPTZ.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func newUserID() string {
+	return uuid.NewString()
+}
+
+func newWorkflow(userID, name string) *workflow.Workflow {
+	ts := now()
+	return &workflow.Workflow{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Name:        name,
+		Description: "conformance test workflow",
+		Trigger: workflow.TriggerDefinition{
+			Type:   workflow.TriggerTypeSchedule,
+			Config: map[string]interface{}{"cron": "*/5 * * * *"},
+		},
+		Actions: []workflow.ActionDefinition{
+			{Type: workflow.ActionTypeLogMessage, Name: "hello", Config: map[string]interface{}{"message": "hi"}},
+		},
+		FailFast:  true,
+		IsEnabled: true,
+		CreatedAt: ts,
+		UpdatedAt: ts,
+	}
+}
+
+func mustSaveWorkflow(t *testing.T, store workflow.Store, wf *workflow.Workflow) {
+	ctx := t.Context()
+	t.Helper()
+	if err := store.SaveWorkflow(ctx, wf); err != nil {
+		t.Fatalf("SaveWorkflow(%s): %v", wf.Name, err)
+	}
+}
+
+func newExecution(wf *workflow.Workflow, status string, triggeredAt time.Time) *workflow.ExecutionLog {
+	return &workflow.ExecutionLog{
+		ID:            uuid.New(),
+		WorkflowID:    wf.ID,
+		UserID:        wf.UserID,
+		Status:        status,
+		TriggeredAt:   triggeredAt,
+		Logs:          json.RawMessage(`[]`),
+		TriggerSource: "manual",
+	}
+}
+
+func mustCreateExecution(t *testing.T, store workflow.Store, exec *workflow.ExecutionLog) {
+	ctx := t.Context()
+	t.Helper()
+	if err := store.CreateExecution(ctx, exec); err != nil {
+		t.Fatalf("CreateExecution: %v", err)
+	}
+}
+
+func mustList(t *testing.T, store workflow.Store, userID string, filter workflow.ExecutionFilter) []*workflow.ExecutionLog {
+	ctx := t.Context()
+	t.Helper()
+	executions, err := store.ListExecutions(ctx, userID, filter)
+	if err != nil {
+		t.Fatalf("ListExecutions: %v", err)
+	}
+	return executions
+}
+
+func ids(executions []*workflow.ExecutionLog) []uuid.UUID {
+	out := make([]uuid.UUID, len(executions))
+	for i, exec := range executions {
+		out[i] = exec.ID
+	}
+	return out
+}
+
+func assertIDs(t *testing.T, got []*workflow.ExecutionLog, want ...*workflow.ExecutionLog) {
+	t.Helper()
+	gotIDs := ids(got)
+	if len(gotIDs) != len(want) {
+		t.Fatalf("got %d executions %v, want %d %v", len(gotIDs), gotIDs, len(want), ids(want))
+	}
+	for i := range want {
+		if gotIDs[i] != want[i].ID {
+			t.Fatalf("execution %d: got %s, want %s (got order %v)", i, gotIDs[i], want[i].ID, gotIDs)
+		}
+	}
+}
+
+func testWorkflowCRUD(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	older := newWorkflow(userID, "older workflow")
+	older.CreatedAt = now().Add(-time.Hour)
+	newer := newWorkflow(userID, "newer workflow")
+	newer.OnFailure = []workflow.ActionDefinition{{Type: workflow.ActionTypeLogMessage, Name: "alert", Config: map[string]interface{}{"message": "failed"}}}
+	maxCount := 10
+	newer.Retention = &workflow.RetentionPolicy{MaxCount: &maxCount}
+	mustSaveWorkflow(t, store, older)
+	mustSaveWorkflow(t, store, newer)
+
+	got, err := store.GetWorkflowByID(ctx, userID, newer.ID)
+	if err != nil {
+		t.Fatalf("GetWorkflowByID: %v", err)
+	}
+	if got.Name != newer.Name || got.UserID != userID || len(got.Actions) != 1 || got.Actions[0].Name != "hello" {
+		t.Fatalf("GetWorkflowByID returned %+v", got)
+	}
+	if len(got.OnFailure) != 1 || got.Retention == nil || got.Retention.MaxCount == nil || *got.Retention.MaxCount != 10 {
+		t.Fatalf("on_failure/retention not round-tripped: %+v %+v", got.OnFailure, got.Retention)
+	}
+	if !got.FailFast {
+		t.Fatalf("fail_fast not round-tripped")
+	}
+
+	list, err := store.ListWorkflows(ctx, userID, workflow.WorkflowFilter{})
+	if err != nil {
+		t.Fatalf("ListWorkflows: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != newer.ID || list[1].ID != older.ID {
+		t.Fatalf("ListWorkflows should list newest first, got %v", list)
+	}
+
+	// Actualizar: cambian los campos editables, se conservan la fecha de creación y el propietario.
+	update := *got
+	update.Name = "renamed workflow"
+	update.IsEnabled = false
+	update.CreatedAt = now().Add(24 * time.Hour)
+	update.UpdatedAt = now()
+	mustSaveWorkflow(t, store, &update)
+	got, _ = store.GetWorkflowByID(ctx, userID, newer.ID)
+	if got.Name != "renamed workflow" || got.IsEnabled {
+		t.Fatalf("update not applied: %+v", got)
+	}
+	if !got.CreatedAt.Equal(newer.CreatedAt) {
+		t.Fatalf("update changed created_at: got %s, want %s", got.CreatedAt, newer.CreatedAt)
+	}
+
+	// Los valores devueltos no comparten estado con el store.
+	got.Name = "mutated locally"
+	again, _ := store.GetWorkflowByID(ctx, userID, newer.ID)
+	if again.Name != "renamed workflow" {
+		t.Fatalf("store returned shared state: name is %q", again.Name)
+	}
+
+	if err := store.DeleteWorkflow(ctx, userID, newer.ID, 0); err != nil {
+		t.Fatalf("DeleteWorkflow: %v", err)
+	}
+	if _, err := store.GetWorkflowByID(ctx, userID, newer.ID); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("GetWorkflowByID after delete: got %v, want ErrNotFound", err)
+	}
+	if err := store.DeleteWorkflow(ctx, userID, newer.ID, 0); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("DeleteWorkflow of a deleted workflow: got %v, want ErrNotFound", err)
+	}
+}
+
+func testUserIsolation(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	owner, intruder := newUserID(), newUserID()
+	wf := newWorkflow(owner, "owner workflow")
+	mustSaveWorkflow(t, store, wf)
+	exec := newExecution(wf, "completed", now())
+	mustCreateExecution(t, store, exec)
+
+	if _, err := store.GetWorkflowByID(ctx, intruder, wf.ID); !errors.Is(err, workflow.ErrForbidden) {
+		t.Fatalf("GetWorkflowByID for another user: got %v, want ErrForbidden", err)
+	}
+	list, err := store.ListWorkflows(ctx, intruder, workflow.WorkflowFilter{})
+	if err != nil {
+		t.Fatalf("ListWorkflows: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("another user lists %d workflows", len(list))
+	}
+	if err := store.DeleteWorkflow(ctx, intruder, wf.ID, 0); !errors.Is(err, workflow.ErrForbidden) {
+		t.Fatalf("DeleteWorkflow for another user: got %v, want ErrForbidden", err)
+	}
+	// Guardar con el id de otro usuario no debe apropiarse del workflow.
+	hijack := newWorkflow(intruder, "hijacked")
+	hijack.ID = wf.ID
+	if err := store.SaveWorkflow(ctx, hijack); !errors.Is(err, workflow.ErrForbidden) {
+		t.Fatalf("SaveWorkflow over another user's workflow: got %v, want ErrForbidden", err)
+	}
+	if got, err := store.GetWorkflowByID(ctx, owner, wf.ID); err != nil || got.Name != wf.Name {
+		t.Fatalf("workflow changed after foreign delete/save attempts: %+v, %v", got, err)
+	}
+
+	if _, err := store.GetExecutionByID(ctx, intruder, exec.ID); !errors.Is(err, workflow.ErrExecutionNotFound) {
+		t.Fatalf("GetExecutionByID for another user: got %v, want ErrExecutionNotFound", err)
+	}
+	if _, _, err := store.GetExecutionLogsSince(ctx, intruder, exec.ID, 0); !errors.Is(err, workflow.ErrExecutionNotFound) {
+		t.Fatalf("GetExecutionLogsSince for another user: got %v, want ErrExecutionNotFound", err)
+	}
+	if got := mustList(t, store, intruder, workflow.ExecutionFilter{}); len(got) != 0 {
+		t.Fatalf("another user lists %d executions", len(got))
+	}
+	if got := mustList(t, store, intruder, workflow.ExecutionFilter{WorkflowID: &wf.ID}); len(got) != 0 {
+		t.Fatalf("another user lists %d executions of the workflow", len(got))
+	}
+}
+
+func testScheduledWorkflows(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	scheduled := newWorkflow(userID, "scheduled")
+	disabled := newWorkflow(userID, "disabled")
+	disabled.IsEnabled = false
+	webhook := newWorkflow(userID, "webhook")
+	webhook.Trigger = workflow.TriggerDefinition{Type: workflow.TriggerTypeWebhook, Config: map[string]interface{}{}}
+	for _, wf := range []*workflow.Workflow{scheduled, disabled, webhook} {
+		mustSaveWorkflow(t, store, wf)
+	}
+
+	all, err := store.GetAllEnabledScheduledWorkflows(ctx)
+	if err != nil {
+		t.Fatalf("GetAllEnabledScheduledWorkflows: %v", err)
+	}
+	found := map[uuid.UUID]bool{}
+	for _, wf := range all {
+		found[wf.ID] = true
+	}
+	if !found[scheduled.ID] {
+		t.Fatalf("enabled scheduled workflow missing")
+	}
+	if found[disabled.ID] {
+		t.Fatalf("disabled workflow returned")
+	}
+	if found[webhook.ID] {
+		t.Fatalf("webhook workflow returned")
+	}
+}
+
+func testExecutionLifecycle(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	wf := newWorkflow(userID, "lifecycle")
+	mustSaveWorkflow(t, store, wf)
+
+	exec := newExecution(wf, "running", now())
+	mustCreateExecution(t, store, exec)
+
+	got, err := store.GetExecutionByID(ctx, userID, exec.ID)
+	if err != nil {
+		t.Fatalf("GetExecutionByID: %v", err)
+	}
+	if got.Status != "running" || got.WorkflowID != wf.ID || !got.TriggeredAt.Equal(exec.TriggeredAt) || got.TriggerSource != "manual" {
+		t.Fatalf("created execution does not round-trip: %+v", got)
+	}
+	if got.CompletedAt != nil {
+		t.Fatalf("new execution has completed_at set")
+	}
+
+	// Suspender hasta un instante futuro: todavía no debe reclamarse.
+	resumeAt := now().Add(time.Minute)
+	exec.Status = "suspended"
+	exec.ResumeAt = &resumeAt
+	exec.State = json.RawMessage(`{"next_step": 1}`)
+	exec.Steps = json.RawMessage(`[{"name": "hello", "status": "completed"}]`)
+	if err := store.UpdateExecution(ctx, exec); err != nil {
+		t.Fatalf("UpdateExecution: %v", err)
+	}
+	due, err := store.ClaimDueExecutions(ctx, now(), 10)
+	if err != nil {
+		t.Fatalf("ClaimDueExecutions: %v", err)
+	}
+	if len(due) != 0 {
+		t.Fatalf("claimed %d executions before resume_at", len(due))
+	}
+
+	// Una vez vencido se reclama una sola vez, con su estado interno.
+	due, err = store.ClaimDueExecutions(ctx, resumeAt.Add(time.Second), 10)
+	if err != nil {
+		t.Fatalf("ClaimDueExecutions: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != exec.ID || due[0].Status != "running" {
+		t.Fatalf("ClaimDueExecutions returned %v", ids(due))
+	}
+	var state map[string]interface{}
+	if err := json.Unmarshal(due[0].State, &state); err != nil || state["next_step"] != float64(1) {
+		t.Fatalf("claimed execution lost its state: %s", due[0].State)
+	}
+	due, _ = store.ClaimDueExecutions(ctx, resumeAt.Add(time.Second), 10)
+	if len(due) != 0 {
+		t.Fatalf("execution claimed twice")
+	}
+
+	completedAt := now()
+	exec.Status = "completed"
+	exec.CompletedAt = &completedAt
+	exec.ResumeAt = nil
+	if err := store.UpdateExecution(ctx, exec); err != nil {
+		t.Fatalf("UpdateExecution: %v", err)
+	}
+	got, _ = store.GetExecutionByID(ctx, userID, exec.ID)
+	if got.Status != "completed" || got.CompletedAt == nil || !got.CompletedAt.Equal(completedAt) || got.ResumeAt != nil {
+		t.Fatalf("completed execution does not round-trip: %+v", got)
+	}
+	var steps []map[string]interface{}
+	if err := json.Unmarshal(got.Steps, &steps); err != nil || len(steps) != 1 || steps[0]["name"] != "hello" {
+		t.Fatalf("steps not round-tripped: %s", got.Steps)
+	}
+}
+
+func testExecutionLogs(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	wf := newWorkflow(userID, "logs")
+	mustSaveWorkflow(t, store, wf)
+	exec := newExecution(wf, "running", now())
+	exec.Logs = json.RawMessage(`[{"message": "start"}]`)
+	mustCreateExecution(t, store, exec)
+
+	logEntries := func(messages ...string) []json.RawMessage {
+		var entries []json.RawMessage
+		for _, msg := range messages {
+			entry, _ := json.Marshal(map[string]string{"message": msg})
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	if err := store.AppendExecutionLogs(ctx, exec.ID, logEntries("one", "two")); err != nil {
+		t.Fatalf("AppendExecutionLogs: %v", err)
+	}
+	if err := store.AppendExecutionLogs(ctx, exec.ID, nil); err != nil {
+		t.Fatalf("AppendExecutionLogs with no entries: %v", err)
+	}
+	// Una tanda mayor que cualquier límite de argumentos por sentencia.
+	var many []string
+	for i := 0; i < 130; i++ {
+		many = append(many, fmt.Sprintf("batch-%d", i))
+	}
+	if err := store.AppendExecutionLogs(ctx, exec.ID, logEntries(many...)); err != nil {
+		t.Fatalf("AppendExecutionLogs with a large batch: %v", err)
+	}
+
+	entries, status, err := store.GetExecutionLogsSince(ctx, userID, exec.ID, 0)
+	if err != nil {
+		t.Fatalf("GetExecutionLogsSince: %v", err)
+	}
+	if status != "running" || len(entries) != 3+len(many) {
+		t.Fatalf("got %d entries with status %q, want %d running", len(entries), status, 3+len(many))
+	}
+	for i, want := range append([]string{"start", "one", "two"}, many...) {
+		var got map[string]string
+		if json.Unmarshal(entries[i], &got) != nil || got["message"] != want {
+			t.Fatalf("entry %d = %s, want message %q", i, entries[i], want)
+		}
+	}
+	entries, _, _ = store.GetExecutionLogsSince(ctx, userID, exec.ID, 2)
+	var next map[string]string
+	if len(entries) != 1+len(many) || json.Unmarshal(entries[0], &next) != nil || next["message"] != "two" {
+		t.Fatalf("GetExecutionLogsSince(offset=2) returned %d entries starting with %s", len(entries), entries[0])
+	}
+	entries, _, err = store.GetExecutionLogsSince(ctx, userID, exec.ID, 1000)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("offset past the end: got %d entries, err %v", len(entries), err)
+	}
+}
+
+func testExecutionOrderingAndPagination(t *testing.T, store workflow.Store) {
+	userID := newUserID()
+	wf := newWorkflow(userID, "pagination")
+	mustSaveWorkflow(t, store, wf)
+
+	base := now().Add(-time.Hour)
+	var created []*workflow.ExecutionLog
+	for i := 0; i < 5; i++ {
+		exec := newExecution(wf, "completed", base.Add(time.Duration(i)*time.Minute))
+		mustCreateExecution(t, store, exec)
+		created = append(created, exec)
+	}
+	// Dos ejecuciones con el mismo triggered_at: el id desempata de forma estable.
+	tieA := newExecution(wf, "completed", base.Add(10*time.Minute))
+	tieB := newExecution(wf, "completed", base.Add(10*time.Minute))
+	mustCreateExecution(t, store, tieA)
+	mustCreateExecution(t, store, tieB)
+
+	all := mustList(t, store, userID, workflow.ExecutionFilter{WorkflowID: &wf.ID})
+	if len(all) != 7 {
+		t.Fatalf("got %d executions, want 7", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].TriggeredAt.After(all[i-1].TriggeredAt) {
+			t.Fatalf("executions not ordered newest first at position %d", i)
+		}
+	}
+	for _, exec := range all {
+		if len(exec.Logs) != 0 || len(exec.Steps) != 0 {
+			t.Fatalf("list items should not include logs or steps")
+		}
+	}
+
+	// Recorrer páginas de 3 con el cursor debe devolver todo, en orden y sin repetir.
+	var paged []*workflow.ExecutionLog
+	filter := workflow.ExecutionFilter{WorkflowID: &wf.ID, Limit: 3}
+	for page := 0; page < 5; page++ {
+		items := mustList(t, store, userID, filter)
+		if len(items) > filter.PageSize()+1 {
+			t.Fatalf("page returned %d items, limit is %d (+1)", len(items), filter.PageSize())
+		}
+		more := len(items) > filter.PageSize()
+		if more {
+			items = items[:filter.PageSize()]
+		}
+		paged = append(paged, items...)
+		if !more {
+			break
+		}
+		last := items[len(items)-1]
+		filter.After = &workflow.ExecutionCursor{TriggeredAt: last.TriggeredAt, ID: last.ID}
+	}
+	assertIDs(t, paged, all...)
+
+	// El cursor sobrevive a la codificación de la API.
+	encoded := workflow.ExecutionCursor{TriggeredAt: all[2].TriggeredAt, ID: all[2].ID}.Encode()
+	cursor, err := workflow.DecodeExecutionCursor(encoded)
+	if err != nil {
+		t.Fatalf("DecodeExecutionCursor: %v", err)
+	}
+	rest := mustList(t, store, userID, workflow.ExecutionFilter{WorkflowID: &wf.ID, After: cursor})
+	assertIDs(t, rest, all[3:]...)
+}
+
+func testExecutionFilters(t *testing.T, store workflow.Store) {
+	userID := newUserID()
+	wf := newWorkflow(userID, "filters")
+	other := newWorkflow(userID, "other filters")
+	mustSaveWorkflow(t, store, wf)
+	mustSaveWorkflow(t, store, other)
+
+	base := now().Add(-time.Hour)
+	completed := newExecution(wf, "completed", base)
+	failed := newExecution(wf, "failed", base.Add(10*time.Minute))
+	scheduled := newExecution(wf, "completed", base.Add(20*time.Minute))
+	scheduled.TriggerSource = "schedule"
+	otherExec := newExecution(other, "failed", base.Add(30*time.Minute))
+	for _, exec := range []*workflow.ExecutionLog{completed, failed, scheduled, otherExec} {
+		mustCreateExecution(t, store, exec)
+	}
+
+	assertIDs(t, mustList(t, store, userID, workflow.ExecutionFilter{}), otherExec, scheduled, failed, completed)
+	assertIDs(t, mustList(t, store, userID, workflow.ExecutionFilter{WorkflowID: &wf.ID}), scheduled, failed, completed)
+	assertIDs(t, mustList(t, store, userID, workflow.ExecutionFilter{Statuses: []string{"failed"}}), otherExec, failed)
+	assertIDs(t, mustList(t, store, userID, workflow.ExecutionFilter{Statuses: []string{"failed", "completed"}, WorkflowID: &wf.ID}), scheduled, failed, completed)
+	assertIDs(t, mustList(t, store, userID, workflow.ExecutionFilter{TriggerSource: "schedule"}), scheduled)
+
+	from, to := base.Add(5*time.Minute), base.Add(20*time.Minute)
+	assertIDs(t, mustList(t, store, userID, workflow.ExecutionFilter{From: &from, To: &to}), failed)
+}
+
+func testWorkflowFilters(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	base := now().Add(-time.Hour)
+
+	backup := newWorkflow(userID, "Nightly backup")
+	backup.Description = "Copies the billing database to cold storage"
+	backup.Tags = map[string]string{"env": "prod", "team": "data"}
+	backup.Folder = "ops"
+	backup.CreatedAt = base
+
+	report := newWorkflow(userID, "Weekly report")
+	report.Description = "Sends the billing summary"
+	report.Tags = map[string]string{"env": "staging"}
+	report.Folder = "ops"
+	report.IsEnabled = false
+	report.CreatedAt = base.Add(time.Minute)
+
+	hook := newWorkflow(userID, "Deploy hook")
+	hook.Trigger = workflow.TriggerDefinition{Type: workflow.TriggerTypeWebhook, Config: map[string]interface{}{}}
+	hook.CreatedAt = base.Add(2 * time.Minute)
+	hook.ManagedBy = "git:platform"
+
+	for _, wf := range []*workflow.Workflow{backup, report, hook} {
+		mustSaveWorkflow(t, store, wf)
+	}
+	mustSaveWorkflow(t, store, newWorkflow(newUserID(), "Nightly backup"))
+
+	// La ejecución más reciente manda: backup falló antes pero luego se completó.
+	mustCreateExecution(t, store, newExecution(backup, "failed", base.Add(10*time.Minute)))
+	mustCreateExecution(t, store, newExecution(backup, "completed", base.Add(20*time.Minute)))
+	mustCreateExecution(t, store, newExecution(report, "failed", base.Add(15*time.Minute)))
+
+	got, err := store.GetWorkflowByID(ctx, userID, backup.ID)
+	if err != nil || got.Folder != "ops" || len(got.Tags) != 2 || got.Tags["team"] != "data" {
+		t.Fatalf("tags and folder should round-trip, got %+v (err %v)", got, err)
+	}
+	if got, err := store.GetWorkflowByID(ctx, userID, hook.ID); err != nil || got.ManagedBy != "git:platform" {
+		t.Fatalf("managed_by should round-trip, got %+v (err %v)", got, err)
+	}
+
+	value := func(v string) *string { return &v }
+	boolean := func(b bool) *bool { return &b }
+	cases := []struct {
+		name   string
+		filter workflow.WorkflowFilter
+		want   []*workflow.Workflow
+	}{
+		{"none", workflow.WorkflowFilter{}, []*workflow.Workflow{hook, report, backup}},
+		{"tag key", workflow.WorkflowFilter{Tags: []workflow.TagSelector{{Key: "env"}}}, []*workflow.Workflow{report, backup}},
+		{"tag value", workflow.WorkflowFilter{Tags: []workflow.TagSelector{{Key: "env", Value: value("prod")}}}, []*workflow.Workflow{backup}},
+		{"all tags", workflow.WorkflowFilter{Tags: []workflow.TagSelector{{Key: "env", Value: value("staging")}, {Key: "team"}}}, nil},
+		{"folder", workflow.WorkflowFilter{Folder: value("ops")}, []*workflow.Workflow{report, backup}},
+		{"no folder", workflow.WorkflowFilter{Folder: value("")}, []*workflow.Workflow{hook}},
+		{"trigger type", workflow.WorkflowFilter{TriggerType: workflow.TriggerTypeWebhook}, []*workflow.Workflow{hook}},
+		{"disabled", workflow.WorkflowFilter{Enabled: boolean(false)}, []*workflow.Workflow{report}},
+		{"last run failed", workflow.WorkflowFilter{LastRunStatuses: []string{"failed"}}, []*workflow.Workflow{report}},
+		{"last run any", workflow.WorkflowFilter{LastRunStatuses: []string{"failed", "completed"}}, []*workflow.Workflow{report, backup}},
+		{"search name", workflow.WorkflowFilter{Search: "backup"}, []*workflow.Workflow{backup}},
+		{"search all words", workflow.WorkflowFilter{Search: "Billing summary"}, []*workflow.Workflow{report}},
+		{"search no match", workflow.WorkflowFilter{Search: "billing deploy"}, nil},
+		{"managed", workflow.WorkflowFilter{ManagedBy: value("git:platform")}, []*workflow.Workflow{hook}},
+		{"unmanaged", workflow.WorkflowFilter{ManagedBy: value("")}, []*workflow.Workflow{report, backup}},
+		{"combined", workflow.WorkflowFilter{Search: "billing", Enabled: boolean(true), Folder: value("ops")}, []*workflow.Workflow{backup}},
+	}
+	for _, tc := range cases {
+		list, err := store.ListWorkflows(ctx, userID, tc.filter)
+		if err != nil {
+			t.Fatalf("ListWorkflows(%s): %v", tc.name, err)
+		}
+		if len(list) != len(tc.want) {
+			t.Fatalf("ListWorkflows(%s): got %d workflows, want %d", tc.name, len(list), len(tc.want))
+		}
+		for i := range list {
+			if list[i].ID != tc.want[i].ID {
+				t.Fatalf("ListWorkflows(%s)[%d] = %s, want %s", tc.name, i, list[i].Name, tc.want[i].Name)
+			}
+		}
+	}
+}
+
+// testExecutionLease comprueba que una ejecución reclamada cuya réplica deja de
+// renovar la reclamación vuelve a reclamarse, y solo entonces.
+func testExecutionLease(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	wf := newWorkflow(userID, "leases")
+	mustSaveWorkflow(t, store, wf)
+
+	// Una ejecución en curso que nunca se reclamó no tiene reclamación que caduque.
+	fresh := newExecution(wf, "running", now())
+	mustCreateExecution(t, store, fresh)
+	if err := store.RenewExecutionLease(ctx, fresh.ID, now()); !errors.Is(err, workflow.ErrExecutionLeaseLost) {
+		t.Fatalf("renewing an unclaimed execution: got %v, want ErrExecutionLeaseLost", err)
+	}
+
+	claimedAt := now()
+	exec := newExecution(wf, "suspended", claimedAt.Add(-time.Hour))
+	resumeAt := claimedAt.Add(-time.Minute)
+	exec.ResumeAt = &resumeAt
+	exec.State = json.RawMessage(`{"next_step": 1}`)
+	mustCreateExecution(t, store, exec)
+	due, err := store.ClaimDueExecutions(ctx, claimedAt, 10)
+	if err != nil || len(due) != 1 || due[0].ID != exec.ID {
+		t.Fatalf("ClaimDueExecutions = %v, %v; want the suspended execution", ids(due), err)
+	}
+
+	// Mientras la reclamación está vigente nadie más la reanuda.
+	due, _ = store.ClaimDueExecutions(ctx, claimedAt.Add(workflow.ExecutionLease-time.Second), 10)
+	if len(due) != 0 {
+		t.Fatalf("claimed %v while the lease was live", ids(due))
+	}
+	renewedAt := claimedAt.Add(workflow.ExecutionLease - time.Second)
+	if err := store.RenewExecutionLease(ctx, exec.ID, renewedAt); err != nil {
+		t.Fatalf("RenewExecutionLease: %v", err)
+	}
+	due, _ = store.ClaimDueExecutions(ctx, claimedAt.Add(workflow.ExecutionLease+time.Second), 10)
+	if len(due) != 0 {
+		t.Fatalf("claimed %v although the lease was renewed", ids(due))
+	}
+
+	// La réplica deja de renovarla (murió): caduca y se reclama una vez, con su estado.
+	expired := renewedAt.Add(workflow.ExecutionLease + time.Second)
+	due, err = store.ClaimDueExecutions(ctx, expired, 10)
+	if err != nil || len(due) != 1 || due[0].ID != exec.ID || due[0].Status != "running" {
+		t.Fatalf("ClaimDueExecutions after the lease expired = %v, %v; want the execution", ids(due), err)
+	}
+	var state map[string]interface{}
+	if err := json.Unmarshal(due[0].State, &state); err != nil || state["next_step"] != float64(1) {
+		t.Fatalf("reclaimed execution lost its state: %s", due[0].State)
+	}
+	if due, _ = store.ClaimDueExecutions(ctx, expired, 10); len(due) != 0 {
+		t.Fatalf("expired execution reclaimed twice")
+	}
+
+	// Al terminar deja de estar reclamada.
+	completedAt := now()
+	exec.Status = "completed"
+	exec.CompletedAt = &completedAt
+	exec.ResumeAt = nil
+	if err := store.UpdateExecution(ctx, exec); err != nil {
+		t.Fatalf("UpdateExecution: %v", err)
+	}
+	if err := store.RenewExecutionLease(ctx, exec.ID, expired); !errors.Is(err, workflow.ErrExecutionLeaseLost) {
+		t.Fatalf("renewing a completed execution: got %v, want ErrExecutionLeaseLost", err)
+	}
+	if due, _ = store.ClaimDueExecutions(ctx, expired.Add(time.Hour), 10); len(due) != 0 {
+		t.Fatalf("completed execution reclaimed: %v", ids(due))
+	}
+}
+
+func testClaimSuspended(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	wf := newWorkflow(userID, "claims")
+	mustSaveWorkflow(t, store, wf)
+
+	approval := newExecution(wf, "suspended", now())
+	approval.WaitKey = "nonce-1"
+	mustCreateExecution(t, store, approval)
+
+	if err := store.CreateExecution(ctx, approval); !errors.Is(err, workflow.ErrConflict) {
+		t.Fatalf("duplicate CreateExecution: got %v, want ErrConflict", err)
+	}
+	if _, err := store.ClaimSuspendedExecution(ctx, approval.ID, newUserID(), ""); !errors.Is(err, workflow.ErrExecutionNotSuspended) {
+		t.Fatalf("claim by another user: got %v, want ErrExecutionNotSuspended", err)
+	}
+	if _, err := store.ClaimSuspendedExecution(ctx, approval.ID, "", "nonce-0"); !errors.Is(err, workflow.ErrExecutionNotSuspended) {
+		t.Fatalf("claim with a stale wait key: got %v, want ErrExecutionNotSuspended", err)
+	}
+	if got, err := store.GetExecutionByID(ctx, userID, approval.ID); err != nil || got.Status != "suspended" {
+		t.Fatalf("a rejected claim changed the execution: %v, %v", got, err)
+	}
+	claimed, err := store.ClaimSuspendedExecution(ctx, approval.ID, userID, "nonce-1")
+	if err != nil {
+		t.Fatalf("ClaimSuspendedExecution: %v", err)
+	}
+	if claimed.ID != approval.ID || claimed.Status != "running" {
+		t.Fatalf("claimed %+v", claimed)
+	}
+	if _, err := store.ClaimSuspendedExecution(ctx, approval.ID, "", ""); !errors.Is(err, workflow.ErrExecutionNotSuspended) {
+		t.Fatalf("second claim: got %v, want ErrExecutionNotSuspended", err)
+	}
+
+	waiting := newExecution(wf, "suspended", now())
+	waiting.WaitEvent = "payment.settled"
+	waiting.WaitKey = "order-42"
+	mustCreateExecution(t, store, waiting)
+
+	// La misma espera en otro workflow y en otro usuario: el evento no les llega.
+	otherWorkflow := newWorkflow(userID, "other-events")
+	mustSaveWorkflow(t, store, otherWorkflow)
+	sameEventOtherWorkflow := newExecution(otherWorkflow, "suspended", now())
+	sameEventOtherWorkflow.WaitEvent, sameEventOtherWorkflow.WaitKey = "payment.settled", "order-42"
+	mustCreateExecution(t, store, sameEventOtherWorkflow)
+	otherUserID := newUserID()
+	otherUserWorkflow := newWorkflow(otherUserID, "other-user-events")
+	mustSaveWorkflow(t, store, otherUserWorkflow)
+	sameEventOtherUser := newExecution(otherUserWorkflow, "suspended", now())
+	sameEventOtherUser.WaitEvent, sameEventOtherUser.WaitKey = "payment.settled", "order-42"
+	mustCreateExecution(t, store, sameEventOtherUser)
+
+	if got, err := store.ClaimExecutionsWaitingForEvent(ctx, userID, wf.ID, "payment.settled", "order-7"); err != nil || len(got) != 0 {
+		t.Fatalf("claim with another correlation key: got %v, err %v", ids(got), err)
+	}
+	if got, err := store.ClaimExecutionsWaitingForEvent(ctx, otherUserID, wf.ID, "payment.settled", "order-42"); err != nil || len(got) != 0 {
+		t.Fatalf("claim by another user: got %v, err %v", ids(got), err)
+	}
+	got, err := store.ClaimExecutionsWaitingForEvent(ctx, userID, wf.ID, "payment.settled", "order-42")
+	if err != nil {
+		t.Fatalf("ClaimExecutionsWaitingForEvent: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != waiting.ID || got[0].WaitKey != "order-42" {
+		t.Fatalf("ClaimExecutionsWaitingForEvent returned %v, want only %s", ids(got), waiting.ID)
+	}
+	if got, _ := store.ClaimExecutionsWaitingForEvent(ctx, userID, wf.ID, "payment.settled", "order-42"); len(got) != 0 {
+		t.Fatalf("event claim delivered twice")
+	}
+	for _, other := range []*workflow.ExecutionLog{sameEventOtherWorkflow, sameEventOtherUser} {
+		stored, err := store.GetExecutionByID(ctx, other.UserID, other.ID)
+		if err != nil || stored.Status != "suspended" {
+			t.Fatalf("execution %s of another workflow was claimed: %+v, err %v", other.ID, stored, err)
+		}
+	}
+}
+
+func testNotFound(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	missing := uuid.New()
+
+	if _, err := store.GetWorkflowByID(ctx, userID, missing); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("GetWorkflowByID: got %v, want ErrNotFound", err)
+	}
+	if err := store.DeleteWorkflow(ctx, userID, missing, 0); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("DeleteWorkflow: got %v, want ErrNotFound", err)
+	}
+	if err := store.UpdateExecution(ctx, &workflow.ExecutionLog{ID: missing, Status: "completed"}); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("UpdateExecution: got %v, want ErrNotFound", err)
+	}
+	orphan := newExecution(newWorkflow(userID, "never saved"), "running", now())
+	if err := store.CreateExecution(ctx, orphan); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("CreateExecution for a missing workflow: got %v, want ErrNotFound", err)
+	}
+	if _, err := store.GetExecutionByID(ctx, userID, missing); !errors.Is(err, workflow.ErrExecutionNotFound) {
+		t.Fatalf("GetExecutionByID: got %v, want ErrExecutionNotFound", err)
+	}
+	if _, _, err := store.GetExecutionLogsSince(ctx, userID, missing, 0); !errors.Is(err, workflow.ErrExecutionNotFound) {
+		t.Fatalf("GetExecutionLogsSince: got %v, want ErrExecutionNotFound", err)
+	}
+	if _, err := store.ClaimSuspendedExecution(ctx, missing, "", ""); !errors.Is(err, workflow.ErrExecutionNotSuspended) {
+		t.Fatalf("ClaimSuspendedExecution: got %v, want ErrExecutionNotSuspended", err)
+	}
+	list, err := store.ListWorkflows(ctx, userID, workflow.WorkflowFilter{})
+	if err != nil || len(list) != 0 {
+		t.Fatalf("ListWorkflows for a new user: got %d workflows, err %v", len(list), err)
+	}
+	if got := mustList(t, store, userID, workflow.ExecutionFilter{}); got == nil || len(got) != 0 {
+		t.Fatalf("ListExecutions for a new user should return an empty, non-nil list")
+	}
+}
+
+func testTrash(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	wf := newWorkflow(userID, "trashed")
+	mustSaveWorkflow(t, store, wf)
+	keep := newWorkflow(userID, "kept")
+	mustSaveWorkflow(t, store, keep)
+	exec := newExecution(wf, "completed", now())
+	mustCreateExecution(t, store, exec)
+
+	if err := store.DeleteWorkflow(ctx, userID, wf.ID, 0); err != nil {
+		t.Fatalf("DeleteWorkflow: %v", err)
+	}
+	// En la papelera no es visible para el resto de operaciones...
+	if _, err := store.GetWorkflowByID(ctx, userID, wf.ID); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("GetWorkflowByID of a trashed workflow: got %v, want ErrNotFound", err)
+	}
+	list, _ := store.ListWorkflows(ctx, userID, workflow.WorkflowFilter{})
+	if len(list) != 1 || list[0].ID != keep.ID {
+		t.Fatalf("ListWorkflows should skip trashed workflows, got %v", list)
+	}
+	scheduled, _ := store.GetAllEnabledScheduledWorkflows(ctx)
+	for _, s := range scheduled {
+		if s.ID == wf.ID {
+			t.Fatalf("trashed workflow is still scheduled")
+		}
+	}
+	update := *wf
+	update.Name = "edited in the trash"
+	if err := store.SaveWorkflow(ctx, &update); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("SaveWorkflow of a trashed workflow: got %v, want ErrNotFound", err)
+	}
+	// ...pero su historial de ejecuciones se conserva.
+	if _, err := store.GetExecutionByID(ctx, userID, exec.ID); err != nil {
+		t.Fatalf("execution of a trashed workflow: %v", err)
+	}
+
+	trash, err := store.ListDeletedWorkflows(ctx, userID)
+	if err != nil {
+		t.Fatalf("ListDeletedWorkflows: %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != wf.ID || trash[0].DeletedAt == nil {
+		t.Fatalf("ListDeletedWorkflows returned %+v", trash)
+	}
+	if other, _ := store.ListDeletedWorkflows(ctx, newUserID()); len(other) != 0 {
+		t.Fatalf("ListDeletedWorkflows leaked another user's trash")
+	}
+
+	if _, err := store.RestoreWorkflow(ctx, newUserID(), wf.ID); !errors.Is(err, workflow.ErrForbidden) {
+		t.Fatalf("RestoreWorkflow by another user: got %v, want ErrForbidden", err)
+	}
+	restored, err := store.RestoreWorkflow(ctx, userID, wf.ID)
+	if err != nil {
+		t.Fatalf("RestoreWorkflow: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.Name != "trashed" {
+		t.Fatalf("RestoreWorkflow returned %+v", restored)
+	}
+	if _, err := store.GetWorkflowByID(ctx, userID, wf.ID); err != nil {
+		t.Fatalf("GetWorkflowByID after restore: %v", err)
+	}
+	if _, err := store.RestoreWorkflow(ctx, userID, wf.ID); !errors.Is(err, workflow.ErrConflict) {
+		t.Fatalf("RestoreWorkflow of a live workflow: got %v, want ErrConflict", err)
+	}
+	if _, err := store.RestoreWorkflow(ctx, userID, uuid.New()); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("RestoreWorkflow of a missing workflow: got %v, want ErrNotFound", err)
+	}
+}
+
+func testPurgeDeletedWorkflows(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	wf := newWorkflow(userID, "purged")
+	mustSaveWorkflow(t, store, wf)
+	live := newWorkflow(userID, "live")
+	mustSaveWorkflow(t, store, live)
+	exec := newExecution(wf, "completed", now())
+	mustCreateExecution(t, store, exec)
+	if err := store.DeleteWorkflow(ctx, userID, wf.ID, 0); err != nil {
+		t.Fatalf("DeleteWorkflow: %v", err)
+	}
+
+	// Dentro del periodo de gracia no se purga nada.
+	if n, err := store.PurgeDeletedWorkflows(ctx, now().Add(-time.Hour), 10); err != nil || n != 0 {
+		t.Fatalf("PurgeDeletedWorkflows inside the grace period: got %d, %v", n, err)
+	}
+	if n, err := store.PurgeDeletedWorkflows(ctx, now().Add(time.Minute), 10); err != nil || n != 1 {
+		t.Fatalf("PurgeDeletedWorkflows: got %d, %v; want 1", n, err)
+	}
+	if trash, _ := store.ListDeletedWorkflows(ctx, userID); len(trash) != 0 {
+		t.Fatalf("purged workflow is still in the trash")
+	}
+	if _, err := store.GetExecutionByID(ctx, userID, exec.ID); !errors.Is(err, workflow.ErrExecutionNotFound) {
+		t.Fatalf("execution survived the purge of its workflow: %v", err)
+	}
+	if _, err := store.RestoreWorkflow(ctx, userID, wf.ID); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("RestoreWorkflow after purge: got %v, want ErrNotFound", err)
+	}
+	if _, err := store.GetWorkflowByID(ctx, userID, live.ID); err != nil {
+		t.Fatalf("purge removed a live workflow: %v", err)
+	}
+}
+
+func testWorkflowVersions(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	wf := newWorkflow(userID, "first name")
+	mustSaveWorkflow(t, store, wf)
+	if wf.Version != 1 {
+		t.Fatalf("first save should be version 1, got %d", wf.Version)
+	}
+
+	update := *wf
+	update.Name = "second name"
+	update.UpdatedAt = now()
+	mustSaveWorkflow(t, store, &update)
+	if update.Version != 2 {
+		t.Fatalf("second save should be version 2, got %d", update.Version)
+	}
+	got, _ := store.GetWorkflowByID(ctx, userID, wf.ID)
+	if got.Version != 2 {
+		t.Fatalf("GetWorkflowByID returned version %d, want 2", got.Version)
+	}
+
+	versions, err := store.ListWorkflowVersions(ctx, userID, wf.ID)
+	if err != nil {
+		t.Fatalf("ListWorkflowVersions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || versions[1].Version != 1 {
+		t.Fatalf("ListWorkflowVersions should list newest first, got %+v", versions)
+	}
+	v1, err := store.GetWorkflowVersion(ctx, userID, wf.ID, 1)
+	if err != nil {
+		t.Fatalf("GetWorkflowVersion: %v", err)
+	}
+	if v1.Definition == nil || v1.Definition.Name != "first name" || len(v1.Definition.Actions) != 1 {
+		t.Fatalf("version 1 does not keep the original definition: %+v", v1.Definition)
+	}
+
+	changes, err := workflow.DiffVersions(v1, versions[0])
+	if err != nil {
+		t.Fatalf("DiffVersions: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != "/name" || changes[0].Op != "replace" {
+		t.Fatalf("DiffVersions returned %+v", changes)
+	}
+
+	if _, err := store.GetWorkflowVersion(ctx, userID, wf.ID, 3); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("GetWorkflowVersion of a missing version: got %v, want ErrNotFound", err)
+	}
+	if _, err := store.ListWorkflowVersions(ctx, newUserID(), wf.ID); !errors.Is(err, workflow.ErrForbidden) {
+		t.Fatalf("ListWorkflowVersions by another user: got %v, want ErrForbidden", err)
+	}
+	if _, err := store.GetWorkflowVersion(ctx, newUserID(), wf.ID, 1); !errors.Is(err, workflow.ErrForbidden) {
+		t.Fatalf("GetWorkflowVersion by another user: got %v, want ErrForbidden", err)
+	}
+
+	// Cada ejecución recuerda la versión con la que se lanzó.
+	exec := newExecution(got, "completed", now())
+	exec.WorkflowVersion = got.Version
+	mustCreateExecution(t, store, exec)
+	gotExec, err := store.GetExecutionByID(ctx, userID, exec.ID)
+	if err != nil {
+		t.Fatalf("GetExecutionByID: %v", err)
+	}
+	if gotExec.WorkflowVersion != 2 {
+		t.Fatalf("execution workflow_version = %d, want 2", gotExec.WorkflowVersion)
+	}
+
+	if err := store.DeleteWorkflow(ctx, userID, wf.ID, 0); err != nil {
+		t.Fatalf("DeleteWorkflow: %v", err)
+	}
+	if _, err := store.ListWorkflowVersions(ctx, userID, wf.ID); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("ListWorkflowVersions after delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func testOptimisticConcurrency(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	wf := newWorkflow(userID, "shared")
+	mustSaveWorkflow(t, store, wf)
+
+	// Dos editores leen la versión 1; el primero en guardar gana.
+	first, _ := store.GetWorkflowByID(ctx, userID, wf.ID)
+	second, _ := store.GetWorkflowByID(ctx, userID, wf.ID)
+	first.Name = "first editor"
+	mustSaveWorkflow(t, store, first)
+	if first.Version != 2 {
+		t.Fatalf("conditional save should produce version 2, got %d", first.Version)
+	}
+	second.Name = "second editor"
+	if err := store.SaveWorkflow(ctx, second); !errors.Is(err, workflow.ErrPreconditionFailed) {
+		t.Fatalf("stale SaveWorkflow: got %v, want ErrPreconditionFailed", err)
+	}
+	if second.Version != 1 {
+		t.Fatalf("failed SaveWorkflow changed the version to %d", second.Version)
+	}
+	got, _ := store.GetWorkflowByID(ctx, userID, wf.ID)
+	if got.Name != "first editor" || got.Version != 2 {
+		t.Fatalf("stale save overwrote the workflow: %+v", got)
+	}
+
+	hijack := *got
+	hijack.UserID = newUserID()
+	if err := store.SaveWorkflow(ctx, &hijack); !errors.Is(err, workflow.ErrForbidden) {
+		t.Fatalf("conditional SaveWorkflow by another user: got %v, want ErrForbidden", err)
+	}
+	missing := newWorkflow(userID, "missing")
+	missing.Version = 1
+	if err := store.SaveWorkflow(ctx, missing); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("conditional SaveWorkflow of a missing workflow: got %v, want ErrNotFound", err)
+	}
+
+	if err := store.DeleteWorkflow(ctx, userID, wf.ID, 1); !errors.Is(err, workflow.ErrPreconditionFailed) {
+		t.Fatalf("stale DeleteWorkflow: got %v, want ErrPreconditionFailed", err)
+	}
+	if err := store.DeleteWorkflow(ctx, userID, wf.ID, 2); err != nil {
+		t.Fatalf("DeleteWorkflow with the current version: %v", err)
+	}
+	if err := store.DeleteWorkflow(ctx, userID, wf.ID, 2); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("DeleteWorkflow of a deleted workflow: got %v, want ErrNotFound", err)
+	}
+}
+
+func testPurgeExpiredExecutions(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	wf := newWorkflow(userID, "retention")
+	keepAll := newWorkflow(userID, "retention override")
+	unlimited := 0
+	keepAll.Retention = &workflow.RetentionPolicy{MaxCount: &unlimited}
+	mustSaveWorkflow(t, store, wf)
+	mustSaveWorkflow(t, store, keepAll)
+
+	base := now().Add(-time.Hour)
+	oldest := newExecution(wf, "completed", base)
+	oldRunning := newExecution(wf, "running", base.Add(time.Minute))
+	middle := newExecution(wf, "failed", base.Add(2*time.Minute))
+	newest := newExecution(wf, "completed", base.Add(3*time.Minute))
+	overridden := newExecution(keepAll, "completed", base)
+	overriddenNew := newExecution(keepAll, "completed", base.Add(time.Minute))
+	for _, exec := range []*workflow.ExecutionLog{oldest, oldRunning, middle, newest, overridden, overriddenNew} {
+		mustCreateExecution(t, store, exec)
+	}
+
+	var archived []*workflow.ExecutionLog
+	deleted, err := store.PurgeExpiredExecutions(ctx, workflow.RetentionLimits{MaxCount: 1}, now(), 100, func(batch []*workflow.ExecutionLog) error {
+		archived = append(archived, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("PurgeExpiredExecutions: %v", err)
+	}
+	if deleted != 2 || len(archived) != 2 {
+		t.Fatalf("deleted %d and archived %d executions, want 2 and 2", deleted, len(archived))
+	}
+	// Se conservan la más reciente, las que siguen en curso y las del workflow sin límite.
+	assertIDs(t, mustList(t, store, userID, workflow.ExecutionFilter{WorkflowID: &wf.ID}), newest, oldRunning)
+	assertIDs(t, mustList(t, store, userID, workflow.ExecutionFilter{WorkflowID: &keepAll.ID}), overriddenNew, overridden)
+
+	// Si el archivo falla no se borra nada.
+	extra := newExecution(wf, "completed", base.Add(-time.Minute))
+	mustCreateExecution(t, store, extra)
+	_, err = store.PurgeExpiredExecutions(ctx, workflow.RetentionLimits{MaxCount: 1}, now(), 100, func([]*workflow.ExecutionLog) error {
+		return errors.New("disk full")
+	})
+	if err == nil {
+		t.Fatalf("PurgeExpiredExecutions should fail when archiving fails")
+	}
+	if _, err := store.GetExecutionByID(ctx, userID, extra.ID); err != nil {
+		t.Fatalf("execution deleted despite archive failure: %v", err)
+	}
+
+	// Por antigüedad.
+	deleted, err = store.PurgeExpiredExecutions(ctx, workflow.RetentionLimits{MaxAgeDays: 1}, now().Add(48*time.Hour), 100, nil)
+	if err != nil {
+		t.Fatalf("PurgeExpiredExecutions by age: %v", err)
+	}
+	if deleted != 4 {
+		t.Fatalf("purged %d executions by age, want 4", deleted)
+	}
+	assertIDs(t, mustList(t, store, userID, workflow.ExecutionFilter{}), oldRunning)
+}
+
+func testApplyWorkflowChanges(t *testing.T, store workflow.Store) {
+	ctx := t.Context()
+	userID := newUserID()
+	updated := newWorkflow(userID, "updated")
+	deleted := newWorkflow(userID, "deleted")
+	mustSaveWorkflow(t, store, updated)
+	mustSaveWorkflow(t, store, deleted)
+
+	versionsOf := func(wf *workflow.Workflow) int {
+		t.Helper()
+		versions, err := store.ListWorkflowVersions(ctx, userID, wf.ID)
+		if err != nil {
+			t.Fatalf("ListWorkflowVersions(%s): %v", wf.Name, err)
+		}
+		return len(versions)
+	}
+
+	created := newWorkflow(userID, "created")
+	updated.Description = "changed"
+	err := store.ApplyWorkflowChanges(ctx, userID, []workflow.WorkflowChange{
+		{Save: created}, {Save: updated}, {Delete: deleted},
+	})
+	if err != nil {
+		t.Fatalf("ApplyWorkflowChanges: %v", err)
+	}
+	if created.Version != 1 || updated.Version != 2 {
+		t.Fatalf("versions after apply: created %d, updated %d; want 1 and 2", created.Version, updated.Version)
+	}
+	if got, err := store.GetWorkflowByID(ctx, userID, created.ID); err != nil || got.Name != "created" {
+		t.Fatalf("created workflow: %+v, err %v", got, err)
+	}
+	if got, err := store.GetWorkflowByID(ctx, userID, updated.ID); err != nil || got.Description != "changed" {
+		t.Fatalf("updated workflow: %+v, err %v", got, err)
+	}
+	if _, err := store.GetWorkflowByID(ctx, userID, deleted.ID); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("deleted workflow: got %v, want ErrNotFound", err)
+	}
+
+	// Un cambio que falla a mitad deshace los anteriores del lote.
+	notCreated := newWorkflow(userID, "not-created")
+	updated.Description = "rolled back"
+	stale := *updated
+	stale.Version = 1
+	err = store.ApplyWorkflowChanges(ctx, userID, []workflow.WorkflowChange{
+		{Save: notCreated}, {Save: updated}, {Save: &stale},
+	})
+	var changeErr *workflow.WorkflowChangeError
+	if !errors.As(err, &changeErr) || changeErr.Index != 2 || !errors.Is(err, workflow.ErrPreconditionFailed) {
+		t.Fatalf("stale change: got %v, want a WorkflowChangeError at index 2 wrapping ErrPreconditionFailed", err)
+	}
+	if _, err := store.GetWorkflowByID(ctx, userID, notCreated.ID); !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("workflow created before the failure was kept: err %v", err)
+	}
+	if got, _ := store.GetWorkflowByID(ctx, userID, updated.ID); got == nil || got.Version != 2 || got.Description != "changed" {
+		t.Fatalf("workflow updated before the failure was kept: %+v", got)
+	}
+	if n := versionsOf(updated); n != 2 {
+		t.Fatalf("history has %d versions after a rolled back batch, want 2", n)
+	}
+	if notCreated.Version != 0 || updated.Version != 2 {
+		t.Fatalf("caller versions not restored: not-created %d, updated %d", notCreated.Version, updated.Version)
+	}
+
+	// Un borrado que falla deshace también los guardados anteriores.
+	missing := newWorkflow(userID, "missing")
+	err = store.ApplyWorkflowChanges(ctx, userID, []workflow.WorkflowChange{
+		{Save: updated}, {Delete: missing},
+	})
+	if !errors.As(err, &changeErr) || changeErr.Index != 1 || !errors.Is(err, workflow.ErrNotFound) {
+		t.Fatalf("missing delete: got %v, want a WorkflowChangeError at index 1 wrapping ErrNotFound", err)
+	}
+	if got, _ := store.GetWorkflowByID(ctx, userID, updated.ID); got == nil || got.Version != 2 {
+		t.Fatalf("save before a failed delete was kept: %+v", got)
+	}
+
+	// Los borrados deshechos vuelven a estar fuera de la papelera.
+	kept := newWorkflow(userID, "kept")
+	mustSaveWorkflow(t, store, kept)
+	err = store.ApplyWorkflowChanges(ctx, userID, []workflow.WorkflowChange{
+		{Delete: kept}, {Delete: missing},
+	})
+	if !errors.As(err, &changeErr) || changeErr.Index != 1 {
+		t.Fatalf("delete batch: got %v, want a WorkflowChangeError at index 1", err)
+	}
+	if _, err := store.GetWorkflowByID(ctx, userID, kept.ID); err != nil {
+		t.Fatalf("delete before a failed change was kept: %v", err)
+	}
+
+	// No se pueden guardar workflows de otro usuario.
+	foreign := newWorkflow(newUserID(), "foreign")
+	err = store.ApplyWorkflowChanges(ctx, userID, []workflow.WorkflowChange{{Save: foreign}})
+	if !errors.As(err, &changeErr) || changeErr.Index != 0 || !errors.Is(err, workflow.ErrForbidden) {
+		t.Fatalf("foreign save: got %v, want a WorkflowChangeError wrapping ErrForbidden", err)
+	}
+	if err := store.ApplyWorkflowChanges(ctx, userID, nil); err != nil {
+		t.Fatalf("empty batch: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+// services/task-orchestrator-service/internal/workflow/version.go
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkflowVersion es una copia inmutable de la definición de un workflow tal como
+// quedó tras un guardado. SaveWorkflow escribe una por cada creación o actualización.
+type WorkflowVersion struct {
+	WorkflowID uuid.UUID `json:"workflow_id"`
+	Version    int       `json:"version"`
+	Definition *Workflow `json:"definition"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// VersionChange es una diferencia entre dos versiones. Path es un JSON Pointer
+// (RFC 6901) dentro de la definición; Op es "add", "remove" o "replace".
+type VersionChange struct {
+	Op   string      `json:"op"`
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// metadataFields no forman parte de la definición: cambian en cada guardado.
+var metadataFields = []string{"id", "user_id", "version", "created_at", "updated_at", "last_run_at", "next_run_at", "deleted_at", "managed_by"}
+
+// DiffVersions compara las definiciones de dos versiones campo a campo. Las
+// listas se comparan por posición.
+func DiffVersions(from, to *WorkflowVersion) ([]VersionChange, error) {
+	a, err := definitionTree(from.Definition)
+	if err != nil {
+		return nil, err
+	}
+	b, err := definitionTree(to.Definition)
+	if err != nil {
+		return nil, err
+	}
+	changes := []VersionChange{}
+	diffValues("", a, b, &changes)
+	return changes, nil
+}
+
+// ApplyDefinition copia en wf los campos editables de la definición de una versión.
+func (wf *Workflow) ApplyDefinition(def *Workflow) {
+	wf.Name = def.Name
+	wf.Description = def.Description
+	wf.Tags = def.Tags
+	wf.Folder = def.Folder
+	wf.Trigger = def.Trigger
+	wf.Actions = def.Actions
+	wf.OnFailure = def.OnFailure
+	wf.FailFast = def.FailFast
+	wf.Retention = def.Retention
+	wf.IsEnabled = def.IsEnabled
+}
+
+// definitionTree convierte la definición en mapas y listas genéricos, sin metadatos.
+func definitionTree(wf *Workflow) (map[string]interface{}, error) {
+	data, err := json.Marshal(wf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workflow definition: %w", err)
+	}
+	var tree map[string]interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workflow definition: %w", err)
+	}
+	for _, field := range metadataFields {
+		delete(tree, field)
+	}
+	return tree, nil
+}
+
+func diffValues(path string, a, b interface{}, changes *[]VersionChange) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(av)+len(bv))
+			for key := range av {
+				keys = append(keys, key)
+			}
+			for key := range bv {
+				if _, ok := av[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				childPath := path + "/" + escapePointer(key)
+				aChild, inA := av[key]
+				bChild, inB := bv[key]
+				switch {
+				case !inA:
+					*changes = append(*changes, VersionChange{Op: "add", Path: childPath, To: bChild})
+				case !inB:
+					*changes = append(*changes, VersionChange{Op: "remove", Path: childPath, From: aChild})
+				default:
+					diffValues(childPath, aChild, bChild, changes)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			for i := 0; i < len(av) || i < len(bv); i++ {
+				childPath := fmt.Sprintf("%s/%d", path, i)
+				switch {
+				case i >= len(av):
+					*changes = append(*changes, VersionChange{Op: "add", Path: childPath, To: bv[i]})
+				case i >= len(bv):
+					*changes = append(*changes, VersionChange{Op: "remove", Path: childPath, From: av[i]})
+				default:
+					diffValues(childPath, av[i], bv[i], changes)
+				}
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, VersionChange{Op: "replace", Path: path, From: a, To: b})
+	}
+}
+
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
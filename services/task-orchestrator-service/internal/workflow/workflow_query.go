@@ -0,0 +1,50 @@
+// services/task-orchestrator-service/internal/workflow/workflow_query.go
+package workflow
+
+import "strings"
+
+// TagSelector exige que el workflow tenga la etiqueta Key; si Value no es nil,
+// además con ese valor exacto.
+type TagSelector struct {
+	Key   string
+	Value *string
+}
+
+// WorkflowFilter restringe el listado de workflows. Los campos vacíos no filtran.
+type WorkflowFilter struct {
+	Tags            []TagSelector // Deben cumplirse todos
+	Folder          *string       // "" selecciona los workflows sin carpeta
+	TriggerType     TriggerType
+	Enabled         *bool
+	LastRunStatuses []string // Estado de la ejecución más reciente de cada workflow
+	Search          string   // Texto libre sobre nombre y descripción
+	ManagedBy       *string  // Origen que los gestiona; "" selecciona los no gestionados
+}
+
+// SearchTerms separa la búsqueda en palabras en minúsculas. Los stores sin
+// búsqueda de texto completo exigen que todas aparezcan en el nombre o la descripción.
+func (f WorkflowFilter) SearchTerms() []string {
+	return strings.Fields(strings.ToLower(f.Search))
+}
+
+// matchesTags indica si tags cumple todos los selectores.
+func matchesTags(tags map[string]string, selectors []TagSelector) bool {
+	for _, sel := range selectors {
+		value, ok := tags[sel.Key]
+		if !ok || (sel.Value != nil && value != *sel.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesSearch indica si todas las palabras aparecen en el nombre o la descripción.
+func matchesSearch(wf *Workflow, terms []string) bool {
+	name, description := strings.ToLower(wf.Name), strings.ToLower(wf.Description)
+	for _, term := range terms {
+		if !strings.Contains(name, term) && !strings.Contains(description, term) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,8 +2,12 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +16,58 @@ type Config struct {
 	Port               string
 	AuthServiceBaseURL string
 	DatabaseURL        string // Nomenclatura correcta (URL en mayúsculas)
+	// Backend de almacenamiento: "postgres", "sqlite" (según el esquema de DATABASE_URL)
+	// o "memory" (STORE_BACKEND=memory, sin persistencia, para desarrollo y tests).
+	StoreBackend string
+
+	// Relay SMTP usado por la acción send_email. Si SMTPHost está vacío,
+	// la acción falla indicando que el envío de correo no está configurado.
+	SMTPHost        string
+	SMTPPort        string
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPFrom        string
+	SMTPStartTLS    bool
+	SMTPMaxAttempts int
+
+	// Cada cuánto busca el scheduler ejecuciones suspendidas que deban reanudarse.
+	ResumePollInterval time.Duration
+
+	// URL pública del servicio, usada para construir los enlaces de aprobación.
+	PublicBaseURL string
+	// Clave HMAC para firmar los enlaces de aprobación. Sin ella solo se puede
+	// aprobar/rechazar a través de la API autenticada.
+	ApprovalSigningKey string
+
+	// Clave HMAC para firmar el token de eventos de cada workflow (cabecera
+	// X-Event-Token de POST /events/:name). Sin ella, el endpoint de eventos está deshabilitado.
+	EventsSigningKey string
+
+	// Retención de ejecuciones terminadas. Valores por defecto que cada workflow
+	// puede sobrescribir; 0 desactiva el límite correspondiente.
+	RetentionMaxAgeDays  int
+	RetentionMaxCount    int
+	RetentionInterval    time.Duration
+	RetentionBatchSize   int
+	// Directorio donde archivar (NDJSON con gzip) las ejecuciones antes de borrarlas.
+	// Vacío: no se archivan.
+	RetentionArchiveDir string
+	// Días que un workflow borrado permanece en la papelera antes de purgarse con
+	// sus ejecuciones. 0: nunca se purga.
+	TrashRetentionDays int
+
+	// Frase usada para cifrar los secretos de los usuarios (AES-256-GCM).
+	SecretsKey string
+
+	// Límites máximos del sandbox de la acción script. Un workflow puede pedir
+	// valores menores en timeout_seconds, max_steps y max_memory_mb, nunca mayores.
+	ScriptMaxTimeoutSeconds int
+	ScriptMaxSteps          int
+	ScriptMaxMemoryMB       int
+
+	// Límites máximos de la acción sql_query (max_rows y timeout_seconds).
+	SQLMaxRows           int
+	SQLMaxTimeoutSeconds int
 }
 
 var AppConfig Config
@@ -28,8 +84,61 @@ func LoadConfig() {
     // 2. URL para comunicación interna entre contenedores
 	AppConfig.AuthServiceBaseURL = getEnv("AUTH_SERVICE_BASE_URL", "http://auth_service:5000/api/baas/v1/auth")
 	
-    // 3. La URL de la BD es requerida. La app fallará si no se provee.
-	AppConfig.DatabaseURL = getEnv("DATABASE_URL", "")
+    // 3. La URL de la BD es requerida salvo con STORE_BACKEND=memory; su esquema
+	// (postgres:// o sqlite://) decide el backend.
+	if getOptionalEnv("STORE_BACKEND") == "memory" {
+		AppConfig.StoreBackend = "memory"
+		AppConfig.DatabaseURL = getOptionalEnv("DATABASE_URL")
+	} else {
+		AppConfig.DatabaseURL = getEnv("DATABASE_URL", "")
+		backend, err := StoreBackendFromURL(AppConfig.DatabaseURL)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		if explicit := getOptionalEnv("STORE_BACKEND"); explicit != "" && explicit != backend {
+			log.Fatalf("FATAL: STORE_BACKEND=%s does not match the DATABASE_URL scheme (%s)", explicit, backend)
+		}
+		AppConfig.StoreBackend = backend
+	}
+
+	// 4. Configuración SMTP opcional para la acción send_email.
+	AppConfig.SMTPHost = getOptionalEnv("SMTP_HOST")
+	AppConfig.SMTPPort = getEnv("SMTP_PORT", "587")
+	AppConfig.SMTPUsername = getOptionalEnv("SMTP_USERNAME")
+	AppConfig.SMTPPassword = getOptionalEnv("SMTP_PASSWORD")
+	AppConfig.SMTPFrom = getOptionalEnv("SMTP_FROM")
+	AppConfig.SMTPStartTLS = getBoolEnv("SMTP_STARTTLS", true)
+	AppConfig.SMTPMaxAttempts = getIntEnv("SMTP_MAX_ATTEMPTS", 5)
+
+	// 5. Clave de cifrado de secretos. Sin ella no se pueden guardar ni leer secretos.
+	AppConfig.SecretsKey = getOptionalEnv("SECRETS_KEY")
+
+	// 6. Intervalo de sondeo para reanudar ejecuciones suspendidas (delay, wait_until).
+	AppConfig.ResumePollInterval = getDurationEnv("RESUME_POLL_INTERVAL", 15*time.Second)
+
+	// 7. Enlaces firmados de aprobación.
+	AppConfig.PublicBaseURL = getEnv("PUBLIC_BASE_URL", "http://localhost:"+AppConfig.Port)
+	AppConfig.ApprovalSigningKey = getOptionalEnv("APPROVAL_SIGNING_KEY")
+
+	// 8. Clave para firmar las URLs de eventos externos (wait_for_event).
+	AppConfig.EventsSigningKey = getOptionalEnv("EVENTS_SIGNING_KEY")
+
+	// 9. Retención y purga de ejecuciones antiguas.
+	AppConfig.RetentionMaxAgeDays = getIntEnv("EXECUTION_RETENTION_DAYS", 0)
+	AppConfig.RetentionMaxCount = getIntEnv("EXECUTION_RETENTION_MAX_COUNT", 0)
+	AppConfig.RetentionInterval = getDurationEnv("EXECUTION_RETENTION_INTERVAL", time.Hour)
+	AppConfig.RetentionBatchSize = getIntEnv("EXECUTION_RETENTION_BATCH_SIZE", 500)
+	AppConfig.RetentionArchiveDir = getOptionalEnv("EXECUTION_ARCHIVE_DIR")
+	AppConfig.TrashRetentionDays = getIntEnv("WORKFLOW_TRASH_RETENTION_DAYS", 30)
+
+	// 10. Límites máximos de los scripts (acción script).
+	AppConfig.ScriptMaxTimeoutSeconds = getIntEnv("SCRIPT_MAX_TIMEOUT_SECONDS", 30)
+	AppConfig.ScriptMaxSteps = getIntEnv("SCRIPT_MAX_STEPS", 100_000_000)
+	AppConfig.ScriptMaxMemoryMB = getIntEnv("SCRIPT_MAX_MEMORY_MB", 256)
+
+	// 11. Límites máximos de las consultas SQL (acción sql_query).
+	AppConfig.SQLMaxRows = getIntEnv("SQL_MAX_ROWS", 10_000)
+	AppConfig.SQLMaxTimeoutSeconds = getIntEnv("SQL_MAX_TIMEOUT_SECONDS", 300)
 
 	log.Println("Configuration loaded for task-orchestrator-service")
 }
@@ -47,4 +156,64 @@ func getEnv(key, fallback string) string {
 	}
 
 	return fallback
-}
\ No newline at end of file
+}
+
+// StoreBackendFromURL devuelve el backend que corresponde al esquema de la URL de la BD.
+func StoreBackendFromURL(databaseURL string) (string, error) {
+	scheme, _, found := strings.Cut(databaseURL, "://")
+	if !found {
+		return "", fmt.Errorf("DATABASE_URL must start with postgres:// or sqlite://")
+	}
+	switch strings.ToLower(scheme) {
+	case "postgres", "postgresql":
+		return "postgres", nil
+	case "sqlite", "sqlite3":
+		return "sqlite", nil
+	default:
+		return "", fmt.Errorf("unsupported DATABASE_URL scheme '%s' (expected postgres:// or sqlite://)", scheme)
+	}
+}
+
+// getOptionalEnv obtiene una variable de entorno opcional; devuelve "" si no existe.
+func getOptionalEnv(key string) string {
+	return os.Getenv(key)
+}
+
+// getBoolEnv interpreta una variable de entorno como booleano.
+func getBoolEnv(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("FATAL: Environment variable %s must be a boolean, got %q", key, value)
+	}
+	return parsed
+}
+
+// getIntEnv interpreta una variable de entorno como entero.
+func getIntEnv(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("FATAL: Environment variable %s must be an integer, got %q", key, value)
+	}
+	return parsed
+}
+
+// getDurationEnv interpreta una variable de entorno como duración de Go (ej. "15s").
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("FATAL: Environment variable %s must be a duration, got %q", key, value)
+	}
+	return parsed
+}
@@ -0,0 +1,381 @@
+// services/task-orchestrator-service/pkg/database/migrate.go
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Las migraciones son ficheros NNNN_nombre.up.sql / NNNN_nombre.down.sql embebidos
+// en el binario, uno por backend.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationService identifica las migraciones de este servicio en schema_migrations,
+// que comparte la base de datos con auth-service.
+const migrationService = "task-orchestrator-service"
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var ErrUnknownMigration = errors.New("unknown migration version")
+
+// Migration es una versión del esquema con su script de subida y, opcionalmente, de bajada.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus indica si una migración está aplicada y desde cuándo.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// migrationDialect agrupa lo que cambia entre Postgres y SQLite.
+type migrationDialect struct {
+	name        string
+	createTable string
+	// rebind adapta los placeholders $N de las consultas del migrador.
+	rebind func(query string) string
+	// lock y unlock serializan las migraciones entre procesos; se ejecutan en la
+	// misma conexión que las migraciones.
+	lock, unlock func(ctx context.Context, conn *sql.Conn) error
+}
+
+var postgresDialect = migrationDialect{
+	name: "postgres",
+	createTable: `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        service VARCHAR(100) NOT NULL,
+        version BIGINT NOT NULL,
+        name VARCHAR(255) NOT NULL,
+        applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (service, version)
+    )`,
+	rebind: func(query string) string { return query },
+	// El advisory lock es común a todos los servicios: también protege la creación
+	// de schema_migrations cuando varios arrancan a la vez.
+	lock: func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock(hashtext('schema_migrations'))`)
+		return err
+	},
+	unlock: func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext('schema_migrations'))`)
+		return err
+	},
+}
+
+// En SQLite las transacciones toman el bloqueo de escritura al empezar (_txlock=immediate),
+// y cada migración comprueba dentro de su transacción si otro proceso ya la aplicó.
+var sqliteDialect = migrationDialect{
+	name: "sqlite",
+	createTable: `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        service TEXT NOT NULL,
+        version INTEGER NOT NULL,
+        name TEXT NOT NULL,
+        applied_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (service, version)
+    )`,
+	rebind: func(query string) string { return strings.ReplaceAll(query, "$", "?") },
+	lock:   func(context.Context, *sql.Conn) error { return nil },
+	unlock: func(context.Context, *sql.Conn) error { return nil },
+}
+
+// Migrator aplica y revierte las migraciones de un backend.
+type Migrator struct {
+	db         *sql.DB
+	dialect    migrationDialect
+	migrations []Migration
+}
+
+func NewPostgresMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	return newMigrator(stdlib.OpenDBFromPool(pool), postgresDialect)
+}
+
+func NewSQLiteMigrator(db *sql.DB) (*Migrator, error) {
+	return newMigrator(db, sqliteDialect)
+}
+
+func newMigrator(db *sql.DB, dialect migrationDialect) (*Migrator, error) {
+	migrations, err := LoadMigrations(migrationFiles, path.Join("migrations", dialect.name))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// LoadMigrations lee los ficheros de un directorio y los ordena por versión.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name '%s' (expected NNNN_name.up.sql or NNNN_name.down.sql)", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration '%s': %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: '%s' and '%s'", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest devuelve la versión más alta disponible (0 si no hay migraciones).
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status devuelve todas las migraciones conocidas y cuándo se aplicó cada una.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Up aplica todas las migraciones pendientes y devuelve cuántas aplicó.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down revierte las `steps` últimas migraciones aplicadas.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := sortedVersions(applied)
+		for i := len(versions) - 1; i >= 0 && count < steps; i-- {
+			if err := m.revert(ctx, conn, versions[i]); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// To lleva el esquema a la versión indicada: aplica las pendientes hasta ella y
+// revierte, de la más reciente a la más antigua, las aplicadas por encima.
+func (m *Migrator) To(ctx context.Context, target int64) (int, error) {
+	if target != 0 && m.find(target) == nil {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownMigration, target)
+	}
+	count := 0
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := sortedVersions(applied)
+		for i := len(versions) - 1; i >= 0 && versions[i] > target; i-- {
+			if err := m.revert(ctx, conn, versions[i]); err != nil {
+				return err
+			}
+			count++
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > target {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// withConn fija una conexión, toma el bloqueo y se asegura de que exista schema_migrations.
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a connection for migrations: %w", err)
+	}
+	defer conn.Close()
+
+	if err := m.dialect.lock(ctx, conn); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if err := m.dialect.unlock(context.Background(), conn); err != nil {
+			log.Printf("WARNING: Failed to release migration lock: %v", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, m.dialect.rebind(`SELECT version, applied_at FROM schema_migrations WHERE service = $1`), migrationService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// apply ejecuta el script de subida y lo registra en la misma transacción.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	return m.inTx(ctx, conn, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRowContext(ctx, m.dialect.rebind(`SELECT 1 FROM schema_migrations WHERE service = $1 AND version = $2`),
+			migrationService, migration.Version).Scan(&exists)
+		if err == nil {
+			return nil // Otro proceso la aplicó mientras tanto.
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		_, err = tx.ExecContext(ctx, m.dialect.rebind(`INSERT INTO schema_migrations (service, version, name, applied_at) VALUES ($1, $2, $3, $4)`),
+			migrationService, migration.Version, migration.Name, time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+		log.Printf("✓ Applied migration %d_%s", migration.Version, migration.Name)
+		return nil
+	})
+}
+
+// revert ejecuta el script de bajada y borra el registro en la misma transacción.
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, version int64) error {
+	migration := m.find(version)
+	if migration == nil {
+		return fmt.Errorf("%w: %d is applied but its files are missing", ErrUnknownMigration, version)
+	}
+	if migration.Down == "" {
+		return fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+	}
+	return m.inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+			return fmt.Errorf("rollback of migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.ExecContext(ctx, m.dialect.rebind(`DELETE FROM schema_migrations WHERE service = $1 AND version = $2`),
+			migrationService, migration.Version)
+		if err != nil {
+			return fmt.Errorf("failed to unrecord migration %d: %w", migration.Version, err)
+		}
+		log.Printf("✓ Reverted migration %d_%s", migration.Version, migration.Name)
+		return nil
+	})
+}
+
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func sortedVersions(applied map[int64]time.Time) []int64 {
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
@@ -0,0 +1,61 @@
+-- Esquema inicial. Es idempotente (IF NOT EXISTS) para que las bases creadas
+-- antes de las migraciones versionadas puedan adoptarlo sin cambios.
+CREATE TABLE IF NOT EXISTS workflows (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    trigger JSONB NOT NULL,
+    actions JSONB NOT NULL,
+    is_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS on_failure JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS fail_fast BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE workflows ADD COLUMN IF NOT EXISTS retention JSONB;
+
+CREATE TABLE IF NOT EXISTS workflow_executions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id UUID NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'running',
+    triggered_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    logs JSONB DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_workflow_executions_workflow_id
+        FOREIGN KEY (workflow_id) REFERENCES workflows(id) ON DELETE CASCADE
+);
+
+ALTER TABLE workflow_executions ADD COLUMN IF NOT EXISTS steps JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE workflow_executions ADD COLUMN IF NOT EXISTS resume_at TIMESTAMPTZ;
+ALTER TABLE workflow_executions ADD COLUMN IF NOT EXISTS state JSONB;
+ALTER TABLE workflow_executions ADD COLUMN IF NOT EXISTS wait_event VARCHAR(255);
+ALTER TABLE workflow_executions ADD COLUMN IF NOT EXISTS wait_key VARCHAR(512);
+ALTER TABLE workflow_executions ADD COLUMN IF NOT EXISTS trigger_source VARCHAR(50);
+ALTER TABLE workflow_executions ADD COLUMN IF NOT EXISTS parent_execution_id UUID
+    REFERENCES workflow_executions(id) ON DELETE SET NULL;
+
+-- Valores cifrados, referenciados por nombre desde las acciones.
+CREATE TABLE IF NOT EXISTS secrets (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    value BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_secrets_user_name UNIQUE (user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_workflow_id ON workflow_executions(workflow_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_user_id ON workflow_executions(user_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_status ON workflow_executions(status);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_triggered_at ON workflow_executions(triggered_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_resume_at ON workflow_executions(resume_at) WHERE status = 'suspended';
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_user_page ON workflow_executions(user_id, triggered_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_workflow_page ON workflow_executions(workflow_id, triggered_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_wait_event ON workflow_executions(wait_event, wait_key) WHERE status = 'suspended';
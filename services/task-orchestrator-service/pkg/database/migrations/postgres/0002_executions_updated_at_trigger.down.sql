@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_workflow_executions_updated_at ON workflow_executions;
+DROP FUNCTION IF EXISTS update_updated_at_column();
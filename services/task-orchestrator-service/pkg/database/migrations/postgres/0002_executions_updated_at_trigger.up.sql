@@ -0,0 +1,15 @@
+-- Postgres no admite CREATE TRIGGER IF NOT EXISTS; la versión anterior fallaba
+-- en silencio y el trigger nunca llegaba a crearse.
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS update_workflow_executions_updated_at ON workflow_executions;
+CREATE TRIGGER update_workflow_executions_updated_at
+    BEFORE UPDATE ON workflow_executions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
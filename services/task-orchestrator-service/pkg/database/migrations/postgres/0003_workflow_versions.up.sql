@@ -0,0 +1,24 @@
+-- Historial inmutable de definiciones: cada guardado de un workflow incrementa
+-- workflows.version y escribe la definición resultante en workflow_versions.
+ALTER TABLE workflows ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE workflow_versions (
+    workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    definition JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workflow_id, version)
+);
+
+-- Los workflows existentes parten de la versión 1 con su definición actual.
+INSERT INTO workflow_versions (workflow_id, version, definition, created_at)
+SELECT id, 1, jsonb_build_object(
+        'id', id, 'user_id', user_id, 'name', name, 'description', COALESCE(description, ''),
+        'trigger', trigger, 'actions', actions, 'on_failure', on_failure, 'fail_fast', fail_fast,
+        'retention', retention, 'is_enabled', is_enabled, 'version', 1,
+        'created_at', created_at, 'updated_at', updated_at),
+    updated_at
+FROM workflows;
+
+-- Versión del workflow con la que corrió cada ejecución (NULL en las anteriores).
+ALTER TABLE workflow_executions ADD COLUMN workflow_version INTEGER;
@@ -0,0 +1,5 @@
+-- Borrado lógico: un workflow borrado queda en la papelera (deleted_at) con su
+-- historial de ejecuciones hasta que el janitor lo purga tras el periodo de gracia.
+ALTER TABLE workflows ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_workflows_deleted_at ON workflows (deleted_at) WHERE deleted_at IS NOT NULL;
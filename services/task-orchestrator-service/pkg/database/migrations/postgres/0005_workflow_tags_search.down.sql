@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_workflows_user_folder;
+DROP INDEX IF EXISTS idx_workflows_search;
+DROP INDEX IF EXISTS idx_workflows_tags;
+ALTER TABLE workflows DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE workflows DROP COLUMN IF EXISTS folder;
+ALTER TABLE workflows DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,13 @@
+-- Etiquetas clave/valor y carpeta para organizar los workflows, y un tsvector
+-- generado para la búsqueda de texto completo sobre nombre y descripción.
+-- Se usa la configuración 'simple' (sin stemming) porque los nombres mezclan idiomas.
+ALTER TABLE workflows ADD COLUMN tags JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE workflows ADD COLUMN folder TEXT NOT NULL DEFAULT '';
+ALTER TABLE workflows ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_workflows_tags ON workflows USING GIN (tags);
+CREATE INDEX idx_workflows_search ON workflows USING GIN (search_vector);
+CREATE INDEX idx_workflows_user_folder ON workflows (user_id, folder) WHERE deleted_at IS NULL;
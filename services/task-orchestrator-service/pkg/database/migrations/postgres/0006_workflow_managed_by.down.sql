@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_workflows_managed_by;
+ALTER TABLE workflows DROP COLUMN IF EXISTS managed_by;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workflow_executions_claimed_at;
+ALTER TABLE workflow_executions DROP COLUMN IF EXISTS claimed_by;
+ALTER TABLE workflow_executions DROP COLUMN IF EXISTS claimed_at;
@@ -0,0 +1,6 @@
+-- Reclamación de las ejecuciones reanudadas: cuándo y qué réplica la tiene. Una
+-- ejecución 'running' cuyo claimed_at no se renueva a tiempo se vuelve a reclamar.
+ALTER TABLE workflow_executions ADD COLUMN claimed_at TIMESTAMPTZ;
+ALTER TABLE workflow_executions ADD COLUMN claimed_by TEXT;
+
+CREATE INDEX idx_workflow_executions_claimed_at ON workflow_executions (claimed_at) WHERE status = 'running';
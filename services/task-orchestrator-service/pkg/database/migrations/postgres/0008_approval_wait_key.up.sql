@@ -0,0 +1,5 @@
+-- Las aprobaciones guardan su nonce en wait_key para que la reclamación de una
+-- decisión sea condicional. Se rellena en las que ya estaban suspendidas.
+UPDATE workflow_executions
+SET wait_key = state->'wait'->'data'->>'nonce'
+WHERE status = 'suspended' AND state->'wait'->>'kind' = 'approval';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS secrets;
+DROP TABLE IF EXISTS workflow_executions;
+DROP TABLE IF EXISTS workflows;
@@ -0,0 +1,53 @@
+-- Mismo esquema que en Postgres adaptado a SQLite: los campos JSON se guardan como
+-- TEXT validado con json_valid, los UUID como TEXT y las fechas como TEXT UTC de
+-- ancho fijo, de modo que se ordenan correctamente.
+CREATE TABLE IF NOT EXISTS workflows (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    "trigger" TEXT NOT NULL CHECK (json_valid("trigger")),
+    actions TEXT NOT NULL CHECK (json_valid(actions)),
+    on_failure TEXT NOT NULL DEFAULT '[]' CHECK (json_valid(on_failure)),
+    fail_fast INTEGER NOT NULL DEFAULT 1,
+    retention TEXT CHECK (retention IS NULL OR json_valid(retention)),
+    is_enabled INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS workflow_executions (
+    id TEXT PRIMARY KEY,
+    workflow_id TEXT NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running',
+    triggered_at TEXT NOT NULL,
+    completed_at TEXT,
+    logs TEXT NOT NULL DEFAULT '[]' CHECK (json_valid(logs)),
+    steps TEXT NOT NULL DEFAULT '[]' CHECK (json_valid(steps)),
+    resume_at TEXT,
+    state TEXT CHECK (state IS NULL OR json_valid(state)),
+    wait_event TEXT,
+    wait_key TEXT,
+    trigger_source TEXT,
+    parent_execution_id TEXT REFERENCES workflow_executions(id) ON DELETE SET NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS secrets (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    value BLOB NOT NULL,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workflows_user_id ON workflows(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_user_page ON workflow_executions(user_id, triggered_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_workflow_page ON workflow_executions(workflow_id, triggered_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_resume_at ON workflow_executions(resume_at) WHERE status = 'suspended';
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_wait_event ON workflow_executions(wait_event, wait_key) WHERE status = 'suspended';
+CREATE INDEX IF NOT EXISTS idx_workflow_executions_parent ON workflow_executions(parent_execution_id);
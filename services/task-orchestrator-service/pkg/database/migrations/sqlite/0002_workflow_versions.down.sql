@@ -0,0 +1,3 @@
+ALTER TABLE workflow_executions DROP COLUMN workflow_version;
+DROP TABLE IF EXISTS workflow_versions;
+ALTER TABLE workflows DROP COLUMN version;
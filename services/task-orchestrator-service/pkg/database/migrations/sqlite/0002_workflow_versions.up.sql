@@ -0,0 +1,23 @@
+-- Historial inmutable de definiciones (ver la migración equivalente de Postgres).
+ALTER TABLE workflows ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE workflow_versions (
+    workflow_id TEXT NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    definition TEXT NOT NULL CHECK (json_valid(definition)),
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (workflow_id, version)
+);
+
+INSERT INTO workflow_versions (workflow_id, version, definition, created_at)
+SELECT id, 1, json_object(
+        'id', id, 'user_id', user_id, 'name', name, 'description', COALESCE(description, ''),
+        'trigger', json("trigger"), 'actions', json(actions), 'on_failure', json(on_failure),
+        'fail_fast', json(CASE WHEN fail_fast THEN 'true' ELSE 'false' END),
+        'retention', json(retention),
+        'is_enabled', json(CASE WHEN is_enabled THEN 'true' ELSE 'false' END),
+        'version', 1, 'created_at', created_at, 'updated_at', updated_at),
+    updated_at
+FROM workflows;
+
+ALTER TABLE workflow_executions ADD COLUMN workflow_version INTEGER;
@@ -0,0 +1,4 @@
+-- Los workflows que estaban en la papelera se borran de verdad al revertir.
+DELETE FROM workflows WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_workflows_deleted_at;
+ALTER TABLE workflows DROP COLUMN deleted_at;
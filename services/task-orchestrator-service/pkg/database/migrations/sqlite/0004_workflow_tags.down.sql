@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workflows_user_folder;
+ALTER TABLE workflows DROP COLUMN folder;
+ALTER TABLE workflows DROP COLUMN tags;
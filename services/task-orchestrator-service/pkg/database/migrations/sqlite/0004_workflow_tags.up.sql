@@ -0,0 +1,6 @@
+-- Etiquetas clave/valor (JSON en TEXT) y carpeta para organizar los workflows.
+-- SQLite no tiene tsvector: la búsqueda compara palabras con LIKE.
+ALTER TABLE workflows ADD COLUMN tags TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE workflows ADD COLUMN folder TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_workflows_user_folder ON workflows (user_id, folder) WHERE deleted_at IS NULL;
@@ -0,0 +1,5 @@
+-- Origen declarativo (sync desde un directorio de ficheros) que gestiona el
+-- workflow. Vacío = se edita desde la API; si no, la API lo trata como solo lectura.
+ALTER TABLE workflows ADD COLUMN managed_by TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_workflows_managed_by ON workflows (user_id, managed_by) WHERE managed_by <> '' AND deleted_at IS NULL;
@@ -0,0 +1,3 @@
+UPDATE workflow_executions
+SET wait_key = ''
+WHERE status = 'suspended' AND json_extract(state, '$.wait.kind') = 'approval';
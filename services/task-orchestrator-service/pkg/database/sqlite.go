@@ -0,0 +1,73 @@
+// services/task-orchestrator-service/pkg/database/sqlite.go
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/url"
+	"strings"
+
+	_ "modernc.org/sqlite" // Driver SQLite en Go puro (sin cgo)
+)
+
+// sqlitePragmas se aplican a cada conexión: claves foráneas (ON DELETE CASCADE),
+// WAL para que las lecturas no bloqueen a la escritura y espera en lugar de SQLITE_BUSY.
+var sqlitePragmas = []string{"foreign_keys(1)", "journal_mode(WAL)", "busy_timeout(5000)"}
+
+// SQLiteDSN convierte una DATABASE_URL sqlite://<ruta>[?params] en el DSN del driver.
+// sqlite:///var/lib/orchestrator.db es una ruta absoluta y sqlite://data.db una relativa.
+func SQLiteDSN(databaseURL string) (dsn string, inMemory bool) {
+	path := databaseURL
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+3:]
+	}
+	path, rawQuery, _ := strings.Cut(path, "?")
+
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		params = url.Values{}
+	}
+	for _, pragma := range sqlitePragmas {
+		params.Add("_pragma", pragma)
+	}
+	// Las transacciones toman el bloqueo de escritura al empezar; así dos escrituras
+	// concurrentes esperan (busy_timeout) en lugar de fallar al intentar promocionar el bloqueo.
+	if params.Get("_txlock") == "" {
+		params.Set("_txlock", "immediate")
+	}
+	inMemory = path == ":memory:" || params.Get("mode") == "memory"
+	return "file:" + path + "?" + params.Encode(), inMemory
+}
+
+func ConnectSQLite(databaseURL string) *sql.DB {
+	log.Println("Opening SQLite database...")
+	dsn, inMemory := SQLiteDSN(databaseURL)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		log.Fatalf("Unable to open SQLite database: %v\n", err)
+	}
+	// Cada conexión a una base en memoria vería su propia base vacía.
+	if inMemory {
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.Ping(); err != nil {
+		log.Fatalf("SQLite ping failed: %v\n", err)
+	}
+	log.Println("SQLite database ready.")
+	return db
+}
+
+// RunSQLiteMigrations aplica al arrancar las migraciones pendientes de SQLite.
+func RunSQLiteMigrations(db *sql.DB) {
+	log.Println("Running SQLite migrations for task-orchestrator...")
+	migrator, err := NewSQLiteMigrator(db)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v\n", err)
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		log.Fatalf("SQLite migration failed: %v\n", err)
+	}
+	log.Printf("🎉 Orchestrator SQLite migrations completed successfully (%d applied, schema version %d).", applied, migrator.Latest())
+}
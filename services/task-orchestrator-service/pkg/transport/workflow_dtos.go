@@ -1,13 +1,87 @@
 package transport
 
 import (
+    "github.com/google/uuid"
     "github.com/guildmember145/task-orchestrator-service/internal/workflow"
 )
 
 type CreateWorkflowRequest struct {
     Name        string                           `json:"name" validate:"required,min=3,max=100"`
     Description string                           `json:"description,omitempty"`
+    Tags        map[string]string                `json:"tags,omitempty" validate:"omitempty,max=50,dive,keys,min=1,max=63,excludesall=:,endkeys,max=255"`
+    Folder      string                           `json:"folder,omitempty" validate:"omitempty,max=100"`
     Trigger     workflow.TriggerDefinition       `json:"trigger" validate:"required"`
     Actions     []workflow.ActionDefinition    `json:"actions" validate:"required,min=1"`
+    OnFailure   []workflow.ActionDefinition    `json:"on_failure,omitempty"`
+    FailFast    *bool                            `json:"fail_fast,omitempty"` // Por defecto true
+    Retention   *workflow.RetentionPolicy        `json:"retention,omitempty"`
     IsEnabled   bool                             `json:"is_enabled"`
-}
\ No newline at end of file
+}
+
+// FailFastOrDefault devuelve fail_fast, que por defecto es true si no se indica.
+func (r CreateWorkflowRequest) FailFastOrDefault() bool {
+    if r.FailFast == nil {
+        return true
+    }
+    return *r.FailFast
+}
+
+// DuplicateWorkflowRequest es el cuerpo opcional de POST /workflows/:id/duplicate.
+type DuplicateWorkflowRequest struct {
+    Name string `json:"name,omitempty" validate:"omitempty,min=3,max=100"` // Por defecto "<nombre> (copy)"
+}
+
+// ImportWorkflowsResponse es la respuesta de POST /workflows/import, tanto en
+// dry-run como al aplicar. Si la importación se detiene, Error explica por qué.
+type ImportWorkflowsResponse struct {
+    DryRun     bool                   `json:"dry_run"`
+    OnConflict string                 `json:"on_conflict"`
+    Results    []ImportWorkflowResult `json:"results"`
+    Error      string                 `json:"error,omitempty"`
+}
+
+// ImportWorkflowResult describe qué se hace (o se haría) con un workflow del bundle.
+type ImportWorkflowResult struct {
+    Name           string     `json:"name"`
+    Action         string     `json:"action,omitempty"`          // create, overwrite, rename, skip o unchanged; vacío si hay error
+    WorkflowID     *uuid.UUID `json:"workflow_id,omitempty"`     // El existente, o el creado si ya se aplicó
+    ImportedAs     string     `json:"imported_as,omitempty"`     // Nombre con el que se crea al renombrar
+    MissingSecrets []string   `json:"missing_secrets,omitempty"` // Secretos referenciados que no existen en este entorno
+    Error          string     `json:"error,omitempty"`
+}
+
+// SyncWorkflowsResponse es la respuesta de POST /workflows/sync: el plan (dry-run)
+// o lo aplicado. Si la sincronización se detiene, Error explica por qué.
+type SyncWorkflowsResponse struct {
+    Source  string               `json:"source"`
+    DryRun  bool                 `json:"dry_run"`
+    Prune   bool                 `json:"prune"`
+    Results []SyncWorkflowResult `json:"results"`
+    Error   string               `json:"error,omitempty"`
+}
+
+// SyncWorkflowResult describe qué se hace (o se haría) con un workflow del origen
+// o con uno gestionado que ya no está en él.
+type SyncWorkflowResult struct {
+    Name           string                   `json:"name"`
+    Action         string                   `json:"action,omitempty"`          // create, update, adopt, unchanged, prune u orphan; vacío si hay error
+    WorkflowID     *uuid.UUID               `json:"workflow_id,omitempty"`
+    Changes        []workflow.VersionChange `json:"changes,omitempty"`         // Diferencias con la definición actual (update y adopt)
+    MissingSecrets []string                 `json:"missing_secrets,omitempty"`
+    Error          string                   `json:"error,omitempty"`
+}
+
+type SaveSecretRequest struct {
+    Name  string `json:"name" validate:"required,min=1,max=255"`
+    Value string `json:"value" validate:"required"`
+}
+
+type ApprovalDecisionRequest struct {
+    Decision string `json:"decision" form:"decision" validate:"required,oneof=approve reject"`
+    Comment  string `json:"comment,omitempty" form:"comment"`
+}
+
+type DeliverEventRequest struct {
+    CorrelationKey string      `json:"correlation_key" validate:"required"`
+    Payload        interface{} `json:"payload"`
+}